@@ -15,6 +15,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"sort"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
 	"github.com/DataDog/datadog-agent/cmd/agent/gui"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -46,6 +48,8 @@ func SetupHandlers(r *mux.Router) *mux.Router {
 	r.HandleFunc("/stop", stopAgent).Methods("POST")
 	r.HandleFunc("/status", getStatus).Methods("GET")
 	r.HandleFunc("/dogstatsd-stats", getDogstatsdStats).Methods("GET")
+	r.HandleFunc("/dogstatsd-capture", captureDogstatsdTraffic).Methods("POST")
+	r.HandleFunc("/aggregator-context-limiter", getContextLimiterStats).Methods("GET")
 	r.HandleFunc("/status/formatted", getFormattedStatus).Methods("GET")
 	r.HandleFunc("/status/health", getHealth).Methods("GET")
 	r.HandleFunc("/{component}/status", componentStatusGetterHandler).Methods("GET")
@@ -221,6 +225,45 @@ func getDogstatsdStats(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonStats)
 }
 
+func getContextLimiterStats(w http.ResponseWriter, r *http.Request) {
+	log.Info("Got a request for the aggregator context limiter stats.")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(aggregator.GetJSONContextLimiterStats())
+}
+
+func captureDogstatsdTraffic(w http.ResponseWriter, r *http.Request) {
+	log.Info("Got a request for a Dogstatsd traffic capture.")
+
+	w.Header().Set("Content-Type", "application/json")
+	if !config.Datadog.GetBool("use_dogstatsd") || common.DSD == nil {
+		body, _ := json.Marshal(map[string]string{
+			"error":      "Dogstatsd not enabled in the Agent configuration",
+			"error_type": "no server",
+		})
+		w.WriteHeader(400)
+		w.Write(body)
+		return
+	}
+
+	duration, err := time.ParseDuration(r.FormValue("duration"))
+	if err != nil {
+		body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("invalid capture duration: %v", err)})
+		http.Error(w, string(body), 400)
+		return
+	}
+
+	path, err := common.DSD.Capture(duration)
+	if err != nil {
+		log.Errorf("Error starting the Dogstatsd traffic capture: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	body, _ := json.Marshal(map[string]string{"path": path})
+	w.Write(body)
+}
+
 func getFormattedStatus(w http.ResponseWriter, r *http.Request) {
 	log.Info("Got a request for the formatted status. Making formatted status.")
 	s, err := status.GetAndFormatStatus()
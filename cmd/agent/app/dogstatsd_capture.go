@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dsdCaptureDuration time.Duration
+	dsdReplayFilePath  string
+	dsdReplaySpeed     float64
+	dsdReplaySocket    string
+	dsdReplayUDP       bool
+)
+
+func init() {
+	AgentCmd.AddCommand(dogstatsdCaptureCmd)
+	dogstatsdCaptureCmd.Flags().DurationVarP(&dsdCaptureDuration, "duration", "d", time.Minute, "duration of the traffic capture")
+
+	AgentCmd.AddCommand(dogstatsdReplayCmd)
+	dogstatsdReplayCmd.Flags().StringVarP(&dsdReplayFilePath, "file", "f", "", "path of the capture file to replay")
+	dogstatsdReplayCmd.Flags().Float64VarP(&dsdReplaySpeed, "speed", "s", 1, "replay speed, 1 replays at the original pace and 0 as fast as possible")
+	dogstatsdReplayCmd.Flags().StringVarP(&dsdReplaySocket, "socket", "", "", "path of the dogstatsd socket to replay to, defaults to dogstatsd_socket")
+	dogstatsdReplayCmd.Flags().BoolVarP(&dsdReplayUDP, "udp", "", false, "replay to the dogstatsd UDP port instead of the socket")
+	dogstatsdReplayCmd.MarkFlagRequired("file") //nolint:errcheck
+}
+
+var dogstatsdCaptureCmd = &cobra.Command{
+	Use:   "dogstatsd-capture",
+	Short: "Record the traffic received by dogstatsd in a capture file",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath, "")
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return requestDogstatsdCapture()
+	},
+}
+
+var dogstatsdReplayCmd = &cobra.Command{
+	Use:   "dogstatsd-replay",
+	Short: "Replay a dogstatsd traffic capture to a running agent",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath, "")
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return replayDogstatsdCapture()
+	},
+}
+
+func requestDogstatsdCapture() error {
+	fmt.Printf("Starting a dogstatsd traffic capture of %s.\n\n", dsdCaptureDuration)
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	urlstr := fmt.Sprintf("https://%v:%v/agent/dogstatsd-capture", ipcAddress, config.Datadog.GetInt("cmd_port"))
+
+	// Set session token
+	if err = util.SetAuthToken(); err != nil {
+		return err
+	}
+
+	form := url.Values{"duration": {dsdCaptureDuration.String()}}
+	r, e := util.DoPost(c, urlstr, "application/x-www-form-urlencoded", bytes.NewBufferString(form.Encode()))
+	var respMap = make(map[string]string)
+	json.Unmarshal(r, &respMap) //nolint:errcheck
+	if e != nil {
+		// If the error has been marshalled into a json object, check it and return it properly
+		if err, found := respMap["error"]; found {
+			e = fmt.Errorf(err)
+		}
+
+		if len(respMap["error_type"]) > 0 {
+			fmt.Println(e)
+			return nil
+		}
+
+		fmt.Printf("Could not start the capture: %v \nMake sure the agent is running before requesting a dogstatsd capture and contact support if you continue having issues. \n", e)
+		return e
+	}
+
+	fmt.Printf("The capture will be written in: %s\n", respMap["path"])
+	return nil
+}
+
+func replayDogstatsdCapture() error {
+	f, err := os.Open(dsdReplayFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to open the capture file: %v", err)
+	}
+	defer f.Close()
+
+	reader, err := replay.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("unable to read the capture file: %v", err)
+	}
+	defer reader.Close()
+
+	var conn net.Conn
+	if dsdReplayUDP {
+		conn, err = net.Dial("udp", net.JoinHostPort(config.Datadog.GetString("bind_host"), config.Datadog.GetString("dogstatsd_port")))
+	} else {
+		socketPath := dsdReplaySocket
+		if socketPath == "" {
+			socketPath = config.Datadog.GetString("dogstatsd_socket")
+		}
+		if socketPath == "" {
+			return fmt.Errorf("no dogstatsd socket configured, use --socket or --udp")
+		}
+		conn, err = net.Dial("unixgram", socketPath)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to connect to dogstatsd: %v", err)
+	}
+	defer conn.Close()
+
+	fmt.Printf("Replaying %s to %s.\n", dsdReplayFilePath, conn.RemoteAddr())
+	count, err := replay.Replay(reader, conn, dsdReplaySpeed)
+	fmt.Printf("Replayed %d packets.\n", count)
+	return err
+}
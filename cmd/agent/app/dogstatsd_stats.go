@@ -10,9 +10,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
@@ -107,6 +109,7 @@ func requestDogstatsdStats() error {
 			fmt.Printf("Could not format the statistics, the data must be inconsistent. You may want to try the JSON output. Contact the support if you continue having issues.\n")
 			return nil
 		}
+		s += requestContextLimiterStats(c, ipcAddress)
 	}
 
 	if dsdStatsFilePath == "" {
@@ -130,3 +133,18 @@ func requestDogstatsdStats() error {
 
 	return nil
 }
+
+// requestContextLimiterStats returns the formatted statistics of the aggregator
+// contexts cardinality limits, an empty string if they can't be retrieved.
+func requestContextLimiterStats(c *http.Client, ipcAddress string) string {
+	urlstr := fmt.Sprintf("https://%v:%v/agent/aggregator-context-limiter", ipcAddress, config.Datadog.GetInt("cmd_port"))
+	r, err := util.DoGet(c, urlstr)
+	if err != nil {
+		return ""
+	}
+	s, err := aggregator.FormatContextLimiterStats(r)
+	if err != nil {
+		return ""
+	}
+	return "\n\nAggregator contexts cardinality limits:\n" + s
+}
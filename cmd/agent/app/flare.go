@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -37,6 +38,11 @@ func init() {
 	flareCmd.Flags().BoolVarP(&forceLocal, "local", "l", false, "Force the creation of the flare by the command line instead of the agent process (useful when running in a containerized env)")
 	flareCmd.Flags().IntVarP(&profiling, "profile", "p", -1, "Add performance profiling data to the flare. It will collect a heap profile and a CPU profile for the amount of seconds passed to the flag, with a minimum of 30s")
 	flareCmd.SetArgs([]string{"caseID"})
+
+	flareCmd.AddCommand(flareListCmd)
+	flareCmd.AddCommand(flareSendCmd)
+	flareSendCmd.Flags().StringVarP(&customerEmail, "email", "e", "", "Your email")
+	flareSendCmd.Flags().BoolVarP(&autoconfirm, "send", "s", false, "Automatically send flare (don't prompt for confirmation)")
 }
 
 var flareCmd = &cobra.Command{
@@ -44,30 +50,80 @@ var flareCmd = &cobra.Command{
 	Short: "Collect a flare and send it to Datadog",
 	Long:  ``,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupFlareCommand(); err != nil {
+			return err
+		}
 
-		if flagNoColor {
-			color.NoColor = true
+		caseID := ""
+		if len(args) > 0 {
+			caseID = args[0]
 		}
 
-		err := common.SetupConfig(confFilePath)
-		if err != nil {
-			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		if customerEmail == "" {
+			var err error
+			customerEmail, err = input.AskForEmail()
+			if err != nil {
+				fmt.Println("Error reading email, please retry or contact support")
+				return err
+			}
 		}
 
-		// The flare command should not log anything, all errors should be reported directly to the console without the log format
-		err = config.SetupLogger(loggerName, "off", "", "", false, true, false)
+		return makeFlare(caseID)
+	},
+}
+
+var flareListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the flares created automatically by the agent",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupFlareCommand(); err != nil {
+			return err
+		}
+
+		dir := flare.GetTriggeredArchivesPath()
+		archives, err := flare.ListTriggeredArchives(dir)
 		if err != nil {
-			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return fmt.Errorf("unable to list the flares of %s: %v", dir, err)
+		}
+		if len(archives) == 0 {
+			fmt.Printf("No flare in %s\n", dir)
+			return nil
+		}
+
+		fmt.Printf("Flares in %s, the most recent first:\n", dir)
+		for _, archive := range archives {
+			trigger := "unknown trigger"
+			if archive.Trigger != nil {
+				trigger = fmt.Sprintf("%s: %s", archive.Trigger.Reason, archive.Trigger.Message)
+			}
+			fmt.Fprintf(color.Output, "  %s  %s  %d bytes  %s\n", color.YellowString(archive.Name), archive.Created.Format(time.RFC3339), archive.Size, trigger)
+		}
+		return nil
+	},
+}
+
+var flareSendCmd = &cobra.Command{
+	Use:   "send <name> [caseID]",
+	Short: "Send a flare created automatically by the agent to Datadog",
+	Long:  `Send a flare created automatically by the agent to Datadog. Run "flare list" to get the names of the flares.`,
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupFlareCommand(); err != nil {
 			return err
 		}
 
+		archive, err := flare.GetTriggeredArchive(flare.GetTriggeredArchivesPath(), args[0])
+		if err != nil {
+			return fmt.Errorf("unable to find the flare %s: %v", args[0], err)
+		}
+
 		caseID := ""
-		if len(args) > 0 {
-			caseID = args[0]
+		if len(args) > 1 {
+			caseID = args[1]
 		}
 
 		if customerEmail == "" {
-			var err error
 			customerEmail, err = input.AskForEmail()
 			if err != nil {
 				fmt.Println("Error reading email, please retry or contact support")
@@ -75,10 +131,29 @@ var flareCmd = &cobra.Command{
 			}
 		}
 
-		return makeFlare(caseID)
+		return sendFlare(archive.Path, caseID)
 	},
 }
 
+func setupFlareCommand() error {
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	err := common.SetupConfig(confFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to set up global agent configuration: %v", err)
+	}
+
+	// The flare command should not log anything, all errors should be reported directly to the console without the log format
+	err = config.SetupLogger(loggerName, "off", "", "", false, true, false)
+	if err != nil {
+		fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+		return err
+	}
+	return nil
+}
+
 func makeFlare(caseID string) error {
 	logFile := config.Datadog.GetString("log_file")
 	if logFile == "" {
@@ -117,6 +192,10 @@ func makeFlare(caseID string) error {
 		return err
 	}
 
+	return sendFlare(filePath, caseID)
+}
+
+func sendFlare(filePath, caseID string) error {
 	fmt.Fprintln(color.Output, fmt.Sprintf("%s is going to be uploaded to Datadog", color.YellowString(filePath)))
 	if !autoconfirm {
 		confirmation := input.AskForConfirmation("Are you sure you want to upload a flare? [y/N]")
@@ -29,6 +29,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed/jmx"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
+	"github.com/DataDog/datadog-agent/pkg/flare"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/logs"
 	"github.com/DataDog/datadog-agent/pkg/metadata"
@@ -70,6 +71,9 @@ var (
 var (
 	// flags variables
 	pidfilePath string
+
+	// autoFlare creates flares automatically, nil when disabled
+	autoFlare *flare.AutoFlare
 )
 
 func init() {
@@ -335,6 +339,20 @@ func StartAgent() error {
 		}
 	}
 
+	// start the automatic flares
+	if config.Datadog.GetBool("auto_flare_enabled") {
+		logFile := config.Datadog.GetString("log_file")
+		if logFile == "" {
+			logFile = common.DefaultLogFile
+		}
+		autoFlare, err = flare.NewAutoFlare(common.GetDistPath(), common.PyChecksPath, logFile)
+		if err != nil {
+			log.Errorf("Could not start the automatic flares: %s", err)
+		} else {
+			autoFlare.Start()
+		}
+	}
+
 	// start dependent services
 	startDependentServices()
 	return nil
@@ -354,6 +372,10 @@ func StopAgent() {
 	// gracefully shut down any component
 	common.MainCtxCancel()
 
+	if autoFlare != nil {
+		autoFlare.Stop()
+	}
+
 	if common.DSD != nil {
 		common.DSD.Stop()
 	}
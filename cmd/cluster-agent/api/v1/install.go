@@ -34,4 +34,5 @@ func Install(r *mux.Router, sc clusteragent.ServerContext) {
 	}
 	installClusterCheckEndpoints(r, sc)
 	installEndpointsCheckEndpoints(r, sc)
+	installLocalMetricsEndpoints(r)
 }
@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/localmetrics"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// seriesPayload is the payload of the series reported by the node agents,
+// as marshalled by metrics.Series
+type seriesPayload struct {
+	Series metrics.Series `json:"series"`
+}
+
+// installLocalMetricsEndpoints registers the endpoint receiving the metrics of the local external metrics backend
+func installLocalMetricsEndpoints(r *mux.Router) {
+	r.HandleFunc("/series", postSeries).Methods("POST")
+}
+
+// postSeries stores the series reported by the node agents in the local metrics store
+func postSeries(w http.ResponseWriter, r *http.Request) {
+	if !config.Datadog.GetBool("external_metrics_provider.local_backend.enabled") {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write([]byte("The local metrics backend is not enabled"))
+		incrementRequestMetric("postSeries", http.StatusPreconditionFailed)
+		return
+	}
+
+	if redirectToLeader(w, r, "postSeries") {
+		return
+	}
+
+	var payload seriesPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		incrementRequestMetric("postSeries", http.StatusBadRequest)
+		return
+	}
+
+	store := localmetrics.GetStore()
+	response := apiv1.SeriesResponse{}
+	for _, serie := range payload.Series {
+		tags := serie.Tags
+		if serie.Host != "" {
+			tags = append(tags, "host:"+serie.Host)
+		}
+		if serie.Device != "" {
+			// the device tag is moved to its own field when the series are marshalled
+			tags = append(tags, "device:"+serie.Device)
+		}
+		points := make([]localmetrics.Point, 0, len(serie.Points))
+		for _, p := range serie.Points {
+			points = append(points, localmetrics.Point{Timestamp: int64(p.Ts), Value: p.Value})
+		}
+		if store.AddPoints(serie.Name, tags, points) {
+			response.Accepted++
+		} else {
+			response.Dropped++
+		}
+	}
+	if response.Dropped > 0 {
+		log.Warnf("Dropped %d series: the local metrics store is full, consider raising external_metrics_provider.local_backend.max_series", response.Dropped)
+	}
+
+	slcB, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("postSeries", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(slcB)
+	incrementRequestMetric("postSeries", http.StatusOK)
+}
+
+// redirectToLeader redirects the request to the leader if leader election is
+// enabled and the cluster agent is a follower: only the leader queries the
+// local store. It returns whether the request was handled.
+func redirectToLeader(w http.ResponseWriter, r *http.Request, handler string) bool {
+	if !config.Datadog.GetBool("leader_election") {
+		return false
+	}
+
+	engine, err := leaderelection.GetLeaderEngine()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		incrementRequestMetric(handler, http.StatusServiceUnavailable)
+		return true
+	}
+	if engine.IsLeader() {
+		return false
+	}
+
+	leaderIP, err := engine.GetLeaderIP()
+	if err != nil || leaderIP == "" {
+		http.Error(w, "Leader not found", http.StatusServiceUnavailable)
+		incrementRequestMetric(handler, http.StatusServiceUnavailable)
+		return true
+	}
+
+	// 307 preserves the method and the body of the request
+	url := r.URL
+	url.Scheme = "https"
+	url.Host = fmt.Sprintf("%s:%d", leaderIP, config.Datadog.GetInt("cluster_agent.cmd_port"))
+	http.Redirect(w, r, url.String(), http.StatusTemporaryRedirect)
+	incrementRequestMetric(handler, http.StatusTemporaryRedirect)
+	return true
+}
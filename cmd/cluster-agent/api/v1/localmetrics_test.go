@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/localmetrics"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/serializer"
+)
+
+// TestLocalMetrics checks that the series flushed by the aggregator of a node
+// agent can be queried from the local metrics store of the cluster agent
+func TestLocalMetrics(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("external_metrics_provider.local_backend.enabled", true)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Major":1, "Minor":9, "Patch":0}`))
+	})
+	installLocalMetricsEndpoints(r.PathPrefix("/api/v1").Subrouter())
+	ts := httptest.NewTLSServer(r)
+	defer ts.Close()
+
+	mockConfig.Set("cluster_agent.enabled", true)
+	mockConfig.Set("cluster_agent.url", ts.URL)
+	mockConfig.Set("cluster_agent.auth_token", "01234567890123456789012345678901")
+	mockConfig.Set("cluster_agent.forwarded_metrics", []string{"requests"})
+
+	s := &serializer.MockSerializer{}
+	s.On("SendSeries", testifymock.Anything).Return(nil)
+	s.On("SendServiceChecks", testifymock.Anything).Return(nil)
+	agg := aggregator.NewBufferedAggregator(s, "node1", 0)
+	ticker := make(chan time.Time)
+	agg.TickerChan = ticker
+	aggregator.SetDefaultAggregator(agg)
+	defer aggregator.StopDefaultAggregator()
+
+	aggregator.AddRecurrentSeries(&metrics.Serie{
+		Name:   "requests",
+		Points: []metrics.Point{{Value: 42}},
+		Tags:   []string{"app:web", "device:sda"},
+		MType:  metrics.APIGaugeType,
+	})
+	aggregator.AddRecurrentSeries(&metrics.Serie{
+		Name:   "other",
+		Points: []metrics.Point{{Value: 1}},
+		MType:  metrics.APIGaugeType,
+	})
+
+	start := time.Now()
+	ticker <- start
+
+	// only the forwarded metrics are stored
+	store := localmetrics.GetStore()
+	require.Eventually(t, func() bool { return store.Len() > 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, store.Len())
+
+	series, err := localmetrics.NewClient(store).QueryMetrics(start.Unix()-60, start.Unix()+60, "avg:requests{app:web,device:sda,host:node1}")
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	require.Len(t, series[0].Points, 1)
+	assert.Equal(t, float64(42), *series[0].Points[0][1])
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/orchestrator"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/logs"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util"
@@ -43,6 +44,7 @@ import (
 	apicommon "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/clustername"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/eventlogs"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
@@ -282,6 +284,13 @@ func start(cmd *cobra.Command, args []string) error {
 		log.Debug("Cluster check Autodiscovery disabled")
 	}
 
+	// Start the logs-agent to send the Kubernetes events as logs
+	if config.Datadog.GetBool("logs_enabled") && eventlogs.IsEnabled() {
+		if err := logs.Start(); err != nil {
+			log.Errorf("Could not start logs-agent: %v", err)
+		}
+	}
+
 	// Start the cmd HTTPS server
 	// We always need to start it, even with nil clusterCheckHandler
 	// as it's also used to perform the agent commands (e.g. agent status)
@@ -314,6 +323,8 @@ func start(cmd *cobra.Command, args []string) error {
 		server := admissioncmd.NewServer()
 		server.Register(config.Datadog.GetString("admission_controller.inject_config.endpoint"), mutate.InjectConfig, apiCl.DynamicCl)
 		server.Register(config.Datadog.GetString("admission_controller.inject_tags.endpoint"), mutate.InjectTags, apiCl.DynamicCl)
+		server.Register(config.Datadog.GetString("admission_controller.auto_instrumentation.endpoint"), mutate.InjectAutoInstrumentation, apiCl.DynamicCl)
+		server.Register(config.Datadog.GetString("admission_controller.inject_dogstatsd_socket.endpoint"), mutate.InjectDogStatsDSocket, apiCl.DynamicCl)
 
 		// Start the k8s admission webhook server
 		wg.Add(1)
@@ -358,6 +369,8 @@ func start(cmd *cobra.Command, args []string) error {
 	// the Admission Webhook Server to stop properly
 	wg.Wait()
 
+	logs.Stop()
+
 	if stopCh != nil {
 		close(stopCh)
 	}
@@ -208,7 +208,8 @@ type BufferedAggregator struct {
 	TickerChan         <-chan time.Time // For test/benchmark purposes: it allows the flush to be controlled from the outside
 	stopChan           chan struct{}
 	health             *health.Handle
-	agentName          string // Name of the agent for telemetry metrics
+	agentName          string                 // Name of the agent for telemetry metrics
+	localMetrics       *localMetricsForwarder // Forwards some series to the cluster agent, nil if disabled
 }
 
 // NewBufferedAggregator instantiates a BufferedAggregator
@@ -249,6 +250,7 @@ func NewBufferedAggregator(s serializer.MetricSerializer, hostname string, flush
 		stopChan:           make(chan struct{}),
 		health:             health.RegisterLiveness("aggregator"),
 		agentName:          agentName,
+		localMetrics:       newLocalMetricsForwarder(),
 	}
 
 	return aggregator
@@ -491,6 +493,16 @@ func (agg *BufferedAggregator) sendSeries(start time.Time, series metrics.Series
 	} else {
 		go agg.pushSeries(start, series)
 	}
+
+	if agg.localMetrics != nil {
+		if forwarded := agg.localMetrics.filter(series); len(forwarded) > 0 {
+			if waitForSerializer {
+				agg.localMetrics.submit(forwarded)
+			} else {
+				go agg.localMetrics.submit(forwarded)
+			}
+		}
+	}
 }
 
 func (agg *BufferedAggregator) sendSketches(start time.Time, sketches metrics.SketchSeriesList, waitForSerializer bool) {
@@ -191,6 +191,46 @@ func TestHistogramIntervalSampling(t *testing.T) {
 	assert.True(t, foundCount)
 }
 
+func TestCheckDistributionSampling(t *testing.T) {
+	checkSampler := newCheckSampler()
+
+	mSample1 := metrics.MetricSample{
+		Name:       "my.distribution",
+		Value:      1,
+		Mtype:      metrics.DistributionType,
+		Tags:       []string{"foo", "bar"},
+		SampleRate: 1,
+		Timestamp:  12345.0,
+	}
+	mSample2 := metrics.MetricSample{
+		Name:       "my.distribution",
+		Value:      2,
+		Mtype:      metrics.DistributionType,
+		Tags:       []string{"foo", "bar"},
+		SampleRate: 1,
+		Timestamp:  12345.0,
+	}
+	checkSampler.addSample(&mSample1)
+	checkSampler.addSample(&mSample2)
+
+	checkSampler.commit(12346.0)
+	series, sketches := checkSampler.flush()
+	assert.Equal(t, 0, len(series))
+	require.Equal(t, 1, len(sketches))
+
+	expSketch := &quantile.Sketch{}
+	expSketch.Insert(quantile.Default(), 1, 2)
+
+	metrics.AssertSketchSeriesEqual(t, metrics.SketchSeries{
+		Name: "my.distribution",
+		Tags: []string{"foo", "bar"},
+		Points: []metrics.SketchPoint{
+			{Ts: 12345, Sketch: expSketch},
+		},
+		ContextKey: generateContextKey(&mSample1),
+	}, sketches[0])
+}
+
 func TestCheckHistogramBucketSampling(t *testing.T) {
 	checkSampler := newCheckSampler()
 	checkSampler.bucketExpiry = 10 * time.Millisecond
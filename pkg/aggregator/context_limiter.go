@@ -0,0 +1,270 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// maxExcludedTagsCacheSize is the number of metric names for which the
+// excluded tag keys are cached before the cache is reset.
+const maxExcludedTagsCacheSize = 10000
+
+// overflowTag is the tag of the context in which the samples of the contexts
+// rejected by the per metric contexts limit are folded.
+const overflowTag = "overflow:true"
+
+var (
+	contextLimiterExpvars             = expvar.Map{}
+	aggregatorSamplesRejected         = expvar.Int{}
+	aggregatorSamplesRejectedByMetric = expvar.Map{}
+	aggregatorSamplesWithStrippedTags = expvar.Int{}
+	aggregatorOverflowContextsTracked = expvar.Int{}
+	tlmSamplesRejected                = telemetry.NewCounter("aggregator", "samples_rejected",
+		nil, "Count of samples folded in an overflow context as their context was rejected by the per metric contexts limit")
+	tlmSamplesWithStrippedTags = telemetry.NewCounter("aggregator", "samples_with_stripped_tags",
+		nil, "Count of samples which had tags stripped by the excluded tags rules")
+)
+
+func init() {
+	contextLimiterExpvars.Init()
+	aggregatorSamplesRejectedByMetric.Init()
+	contextLimiterExpvars.Set("SamplesRejected", &aggregatorSamplesRejected)
+	contextLimiterExpvars.Set("SamplesRejectedByMetric", &aggregatorSamplesRejectedByMetric)
+	contextLimiterExpvars.Set("SamplesWithStrippedTags", &aggregatorSamplesWithStrippedTags)
+	contextLimiterExpvars.Set("OverflowContexts", &aggregatorOverflowContextsTracked)
+	aggregatorExpvars.Set("ContextLimiter", &contextLimiterExpvars)
+}
+
+// ExcludedTagsRule describes the tag keys to strip from the metrics
+// matching a name or a glob pattern.
+type ExcludedTagsRule struct {
+	Metric string   `mapstructure:"metric" json:"metric"`
+	Tags   []string `mapstructure:"tags" json:"tags"`
+}
+
+type excludedTagsRule struct {
+	pattern string
+	tagKeys map[string]struct{}
+}
+
+// contextLimiter bounds the cardinality of the contexts of a ContextResolver:
+// it strips tag keys before the context key is generated and caps the number
+// of contexts of each metric.
+type contextLimiter struct {
+	excludedTagsRules    []excludedTagsRule
+	maxContextsPerMetric int
+
+	// excludedTagKeysByMetric caches the tag keys to strip for a metric name,
+	// a nil value means that no rule matches the metric.
+	excludedTagKeysByMetric map[string]map[string]struct{}
+	// contextsByMetric counts the contexts tracked for each metric name,
+	// overflow contexts are not counted.
+	contextsByMetric map[string]int
+	overflowContexts map[ckey.ContextKey]struct{}
+}
+
+// newContextLimiterFromConfig returns a contextLimiter configured from the
+// agent configuration, nil if no limit is configured.
+func newContextLimiterFromConfig() *contextLimiter {
+	var rules []ExcludedTagsRule
+	if config.Datadog.IsSet("aggregator_excluded_tags") {
+		if err := config.Datadog.UnmarshalKey("aggregator_excluded_tags", &rules); err != nil {
+			log.Errorf("Could not parse aggregator_excluded_tags, no tag will be stripped: %v", err)
+			rules = nil
+		}
+	}
+	return newContextLimiter(rules, config.Datadog.GetInt("aggregator_max_contexts_per_metric"))
+}
+
+func newContextLimiter(rules []ExcludedTagsRule, maxContextsPerMetric int) *contextLimiter {
+	var excludedTagsRules []excludedTagsRule
+	for _, rule := range rules {
+		// matching the pattern against itself reports malformed patterns
+		if _, err := path.Match(rule.Metric, rule.Metric); err != nil || rule.Metric == "" {
+			log.Errorf("Ignoring excluded tags rule with invalid metric pattern %q", rule.Metric)
+			continue
+		}
+		if len(rule.Tags) == 0 {
+			continue
+		}
+		tagKeys := make(map[string]struct{}, len(rule.Tags))
+		for _, tagKey := range rule.Tags {
+			tagKeys[tagKey] = struct{}{}
+		}
+		excludedTagsRules = append(excludedTagsRules, excludedTagsRule{pattern: rule.Metric, tagKeys: tagKeys})
+	}
+
+	if len(excludedTagsRules) == 0 && maxContextsPerMetric <= 0 {
+		return nil
+	}
+
+	return &contextLimiter{
+		excludedTagsRules:       excludedTagsRules,
+		maxContextsPerMetric:    maxContextsPerMetric,
+		excludedTagKeysByMetric: make(map[string]map[string]struct{}),
+		contextsByMetric:        make(map[string]int),
+		overflowContexts:        make(map[ckey.ContextKey]struct{}),
+	}
+}
+
+// excludedTagKeys returns the tag keys to strip from the given metric, nil if none
+func (l *contextLimiter) excludedTagKeys(name string) map[string]struct{} {
+	if len(l.excludedTagsRules) == 0 {
+		return nil
+	}
+	if tagKeys, found := l.excludedTagKeysByMetric[name]; found {
+		return tagKeys
+	}
+
+	var tagKeys map[string]struct{}
+	for _, rule := range l.excludedTagsRules {
+		if matched, _ := path.Match(rule.pattern, name); !matched {
+			continue
+		}
+		if tagKeys == nil {
+			tagKeys = make(map[string]struct{}, len(rule.tagKeys))
+		}
+		for tagKey := range rule.tagKeys {
+			tagKeys[tagKey] = struct{}{}
+		}
+	}
+
+	if len(l.excludedTagKeysByMetric) >= maxExcludedTagsCacheSize {
+		l.excludedTagKeysByMetric = make(map[string]map[string]struct{})
+	}
+	l.excludedTagKeysByMetric[name] = tagKeys
+	return tagKeys
+}
+
+// filterTags returns the tags of the metric without the excluded tag keys.
+// The given slice is returned untouched if no tag has to be stripped.
+func (l *contextLimiter) filterTags(name string, tags []string) []string {
+	tagKeys := l.excludedTagKeys(name)
+	if tagKeys == nil {
+		return tags
+	}
+
+	var filtered []string
+	for i, tag := range tags {
+		_, excluded := tagKeys[tagKey(tag)]
+		if excluded && filtered == nil {
+			// first excluded tag, copy the previous ones to keep the sample untouched
+			filtered = make([]string, i, len(tags)-1)
+			copy(filtered, tags[:i])
+		} else if !excluded && filtered != nil {
+			filtered = append(filtered, tag)
+		}
+	}
+
+	if filtered == nil {
+		return tags
+	}
+	aggregatorSamplesWithStrippedTags.Add(1)
+	tlmSamplesWithStrippedTags.Inc()
+	return filtered
+}
+
+// tagKey returns the key of a `key:value` tag, the whole tag if it has no value
+func tagKey(tag string) string {
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// acceptContext returns whether a new context can be tracked for the given
+// metric, and counts it if so. Rejected contexts are not tracked, so it is
+// called for every sample of a rejected context: the samples are counted.
+func (l *contextLimiter) acceptContext(name string) bool {
+	if l.maxContextsPerMetric > 0 && l.contextsByMetric[name] >= l.maxContextsPerMetric {
+		aggregatorSamplesRejected.Add(1)
+		aggregatorSamplesRejectedByMetric.Add(name, 1)
+		tlmSamplesRejected.Inc()
+		return false
+	}
+	l.contextsByMetric[name]++
+	return true
+}
+
+func (l *contextLimiter) trackOverflowContext(contextKey ckey.ContextKey) {
+	l.overflowContexts[contextKey] = struct{}{}
+	aggregatorOverflowContextsTracked.Add(1)
+}
+
+// expireContext stops counting an expired context
+func (l *contextLimiter) expireContext(contextKey ckey.ContextKey, name string) {
+	if _, overflow := l.overflowContexts[contextKey]; overflow {
+		delete(l.overflowContexts, contextKey)
+		aggregatorOverflowContextsTracked.Add(-1)
+		return
+	}
+	if count := l.contextsByMetric[name]; count > 1 {
+		l.contextsByMetric[name] = count - 1
+	} else {
+		delete(l.contextsByMetric, name)
+	}
+}
+
+// ContextLimiterStats holds the statistics of the contexts cardinality limits
+type ContextLimiterStats struct {
+	SamplesRejected         int64            `json:"SamplesRejected"`
+	SamplesRejectedByMetric map[string]int64 `json:"SamplesRejectedByMetric"`
+	SamplesWithStrippedTags int64            `json:"SamplesWithStrippedTags"`
+	OverflowContexts        int64            `json:"OverflowContexts"`
+}
+
+// GetJSONContextLimiterStats returns jsonified statistics of the contexts cardinality limits.
+func GetJSONContextLimiterStats() []byte {
+	return []byte(contextLimiterExpvars.String())
+}
+
+// FormatContextLimiterStats returns a printable version of the statistics of
+// the contexts cardinality limits.
+func FormatContextLimiterStats(rawStats []byte) (string, error) {
+	var stats ContextLimiterStats
+	if err := json.Unmarshal(rawStats, &stats); err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(fmt.Sprintf("Samples with stripped tags: %d\n", stats.SamplesWithStrippedTags))
+	buf.WriteString(fmt.Sprintf("Samples folded in overflow contexts: %d\n", stats.SamplesRejected))
+	buf.WriteString(fmt.Sprintf("Overflow contexts: %d\n", stats.OverflowContexts))
+
+	if len(stats.SamplesRejectedByMetric) == 0 {
+		return buf.String(), nil
+	}
+
+	// put metrics in order: first is the most rejected
+	order := make([]string, 0, len(stats.SamplesRejectedByMetric))
+	for metric := range stats.SamplesRejectedByMetric {
+		order = append(order, metric)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return stats.SamplesRejectedByMetric[order[i]] > stats.SamplesRejectedByMetric[order[j]]
+	})
+
+	header := fmt.Sprintf("\n%-40s | %-10s\n", "Metric", "Rejected")
+	buf.WriteString(header)
+	buf.WriteString(strings.Repeat("-", len(header)) + "\n")
+	for _, metric := range order {
+		buf.WriteString(fmt.Sprintf("%-40s | %-10d\n", metric, stats.SamplesRejectedByMetric[metric]))
+	}
+
+	return buf.String(), nil
+}
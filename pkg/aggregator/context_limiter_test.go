@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestNewContextLimiter(t *testing.T) {
+	assert.Nil(t, newContextLimiter(nil, 0))
+	// invalid or empty rules are ignored
+	assert.Nil(t, newContextLimiter([]ExcludedTagsRule{{Metric: "[", Tags: []string{"foo"}}, {Metric: "bar"}}, 0))
+
+	limiter := newContextLimiter([]ExcludedTagsRule{{Metric: "my.metric", Tags: []string{"foo"}}}, 0)
+	require.NotNil(t, limiter)
+	assert.Len(t, limiter.excludedTagsRules, 1)
+
+	limiter = newContextLimiter(nil, 10)
+	require.NotNil(t, limiter)
+	assert.Equal(t, 10, limiter.maxContextsPerMetric)
+}
+
+func TestContextLimiterFilterTags(t *testing.T) {
+	limiter := newContextLimiter([]ExcludedTagsRule{
+		{Metric: "my.app.*", Tags: []string{"request_id"}},
+		{Metric: "my.app.requests", Tags: []string{"user", "flag"}},
+	}, 0)
+
+	tags := []string{"env:prod", "request_id:1234", "user:bob", "flag"}
+	assert.Equal(t, []string{"env:prod"}, limiter.filterTags("my.app.requests", tags))
+	assert.Equal(t, []string{"env:prod", "user:bob", "flag"}, limiter.filterTags("my.app.errors", tags))
+	// the original tags are left untouched
+	assert.Equal(t, []string{"env:prod", "request_id:1234", "user:bob", "flag"}, tags)
+
+	// no rule matching the metric
+	filtered := limiter.filterTags("other.metric", tags)
+	assert.Equal(t, tags, filtered)
+
+	// no tag to strip
+	noMatch := []string{"env:prod"}
+	assert.Equal(t, noMatch, limiter.filterTags("my.app.requests", noMatch))
+	assert.Nil(t, limiter.filterTags("my.app.requests", nil))
+
+	assert.Len(t, limiter.excludedTagKeysByMetric, 3)
+	assert.Nil(t, limiter.excludedTagKeysByMetric["other.metric"])
+}
+
+func TestTrackContextWithExcludedTags(t *testing.T) {
+	contextResolver := newContextResolver()
+	contextResolver.limiter = newContextLimiter([]ExcludedTagsRule{{Metric: "my.metric.*", Tags: []string{"request_id"}}}, 0)
+
+	mSample1 := metrics.MetricSample{
+		Name:  "my.metric.name",
+		Mtype: metrics.GaugeType,
+		Tags:  []string{"foo", "request_id:1"},
+	}
+	mSample2 := metrics.MetricSample{
+		Name:  "my.metric.name",
+		Mtype: metrics.GaugeType,
+		Tags:  []string{"foo", "request_id:2"},
+	}
+
+	contextKey1 := contextResolver.trackContext(&mSample1, 1)
+	contextKey2 := contextResolver.trackContext(&mSample2, 2)
+
+	assert.Equal(t, contextKey1, contextKey2)
+	assert.Len(t, contextResolver.contextsByKey, 1)
+	assert.Equal(t, []string{"foo"}, contextResolver.contextsByKey[contextKey1].Tags)
+	assert.Equal(t, float64(2), contextResolver.lastSeenByKey[contextKey1])
+}
+
+func TestTrackContextWithMaxContextsPerMetric(t *testing.T) {
+	contextResolver := newContextResolver()
+	contextResolver.limiter = newContextLimiter(nil, 2)
+
+	newSample := func(name string, tags ...string) *metrics.MetricSample {
+		return &metrics.MetricSample{Name: name, Mtype: metrics.GaugeType, Tags: tags}
+	}
+
+	contextKey1 := contextResolver.trackContext(newSample("my.metric", "foo:1"), 1)
+	contextKey2 := contextResolver.trackContext(newSample("my.metric", "foo:2"), 1)
+	// other metrics have their own limit
+	contextKey3 := contextResolver.trackContext(newSample("other.metric", "foo:3"), 1)
+	assert.Len(t, contextResolver.contextsByKey, 3)
+
+	// the contexts over the limit are folded into the overflow context
+	overflowKey1 := contextResolver.trackContext(newSample("my.metric", "foo:3"), 2)
+	overflowKey2 := contextResolver.trackContext(newSample("my.metric", "foo:4"), 3)
+	assert.Equal(t, overflowKey1, overflowKey2)
+	assert.NotContains(t, []interface{}{contextKey1, contextKey2, contextKey3}, overflowKey1)
+	assert.Equal(t, &Context{Name: "my.metric", Tags: []string{"overflow:true"}}, contextResolver.contextsByKey[overflowKey1])
+	assert.Len(t, contextResolver.contextsByKey, 4)
+	assert.Len(t, contextResolver.limiter.overflowContexts, 1)
+
+	// the already tracked contexts are still accepted
+	assert.Equal(t, contextKey1, contextResolver.trackContext(newSample("my.metric", "foo:1"), 3))
+
+	// expiring a context makes room for a new one
+	contextResolver.expireContexts(2)
+	assert.Equal(t, 1, contextResolver.limiter.contextsByMetric["my.metric"])
+	assert.Len(t, contextResolver.limiter.overflowContexts, 1)
+	contextKey4 := contextResolver.trackContext(newSample("my.metric", "foo:5"), 4)
+	assert.Equal(t, []string{"foo:5"}, contextResolver.contextsByKey[contextKey4].Tags)
+
+	contextResolver.expireContexts(5)
+	assert.Len(t, contextResolver.contextsByKey, 0)
+	assert.Len(t, contextResolver.limiter.contextsByMetric, 0)
+	assert.Len(t, contextResolver.limiter.overflowContexts, 0)
+}
+
+func TestFormatContextLimiterStats(t *testing.T) {
+	stats := []byte(`{"SamplesRejected": 12, "SamplesRejectedByMetric": {"my.metric": 10, "other.metric": 2}, "SamplesWithStrippedTags": 3, "OverflowContexts": 2}`)
+
+	formatted, err := FormatContextLimiterStats(stats)
+	require.NoError(t, err)
+	assert.Contains(t, formatted, "Samples with stripped tags: 3\n")
+	assert.Contains(t, formatted, "Samples folded in overflow contexts: 12\n")
+	assert.Contains(t, formatted, "Overflow contexts: 2\n")
+	assert.Regexp(t, "(?s)my.metric +\\| 10 .*other.metric +\\| 2 ", formatted)
+
+	_, err = FormatContextLimiterStats([]byte("invalid"))
+	assert.Error(t, err)
+}
@@ -24,6 +24,8 @@ type ContextResolver struct {
 	contextsByKey map[ckey.ContextKey]*Context
 	lastSeenByKey map[ckey.ContextKey]float64
 	keyGenerator  *ckey.KeyGenerator
+	// limiter is nil when no cardinality limit is configured
+	limiter *contextLimiter
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -36,11 +38,16 @@ func newContextResolver() *ContextResolver {
 		contextsByKey: make(map[ckey.ContextKey]*Context),
 		lastSeenByKey: make(map[ckey.ContextKey]float64),
 		keyGenerator:  ckey.NewKeyGenerator(),
+		limiter:       newContextLimiterFromConfig(),
 	}
 }
 
 // trackContext returns the contextKey associated with the context of the metricSample and tracks that context
 func (cr *ContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) ckey.ContextKey {
+	if cr.limiter != nil {
+		return cr.trackLimitedContext(metricSampleContext, currentTimestamp)
+	}
+
 	contextKey := cr.generateContextKey(metricSampleContext)
 	if _, ok := cr.contextsByKey[contextKey]; !ok {
 		cr.contextsByKey[contextKey] = &Context{
@@ -49,11 +56,43 @@ func (cr *ContextResolver) trackContext(metricSampleContext metrics.MetricSample
 			Host: metricSampleContext.GetHost(),
 		}
 	}
-	cr.lastSeenByKey[contextKey] = currentTimestamp
+	cr.updateLastSeen(contextKey, currentTimestamp)
+
+	return contextKey
+}
+
+// trackLimitedContext tracks the context of the metricSample once the
+// excluded tags are stripped, the samples of a metric that reached its
+// contexts limit are folded in its overflow context.
+func (cr *ContextResolver) trackLimitedContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) ckey.ContextKey {
+	name, host := metricSampleContext.GetName(), metricSampleContext.GetHost()
+	tags := cr.limiter.filterTags(name, metricSampleContext.GetTags())
+
+	contextKey := cr.keyGenerator.Generate(name, host, tags)
+	if _, ok := cr.contextsByKey[contextKey]; !ok {
+		if cr.limiter.acceptContext(name) {
+			cr.contextsByKey[contextKey] = &Context{Name: name, Tags: tags, Host: host}
+		} else {
+			tags = []string{overflowTag}
+			contextKey = cr.keyGenerator.Generate(name, host, tags)
+			if _, ok := cr.contextsByKey[contextKey]; !ok {
+				cr.contextsByKey[contextKey] = &Context{Name: name, Tags: tags, Host: host}
+				cr.limiter.trackOverflowContext(contextKey)
+			}
+		}
+	}
+	cr.updateLastSeen(contextKey, currentTimestamp)
 
 	return contextKey
 }
 
+func (cr *ContextResolver) updateLastSeen(contextKey ckey.ContextKey, currentTimestamp float64) {
+	// samples sent with a client timestamp can be older than the last one seen
+	if lastSeen, ok := cr.lastSeenByKey[contextKey]; !ok || lastSeen < currentTimestamp {
+		cr.lastSeenByKey[contextKey] = currentTimestamp
+	}
+}
+
 // updateTrackedContext updates the last seen timestamp on a given context key
 func (cr *ContextResolver) updateTrackedContext(contextKey ckey.ContextKey, timestamp float64) error {
 	if _, ok := cr.lastSeenByKey[contextKey]; ok && cr.lastSeenByKey[contextKey] < timestamp {
@@ -79,6 +118,9 @@ func (cr *ContextResolver) expireContexts(expireTimestamp float64) []ckey.Contex
 
 	// Delete expired context keys
 	for _, expiredContextKey := range expiredContextKeys {
+		if cr.limiter != nil {
+			cr.limiter.expireContext(expiredContextKey, cr.contextsByKey[expiredContextKey].Name)
+		}
 		delete(cr.contextsByKey, expiredContextKey)
 		delete(cr.lastSeenByKey, expiredContextKey)
 	}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"encoding/json"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// seriesPoster submits series to the cluster agent
+type seriesPoster interface {
+	PostSeries(series json.Marshaler) (apiv1.SeriesResponse, error)
+}
+
+// localMetricsForwarder submits the series listed in cluster_agent.forwarded_metrics
+// to the cluster agent, whose local external metrics backend answers the
+// queries of the autoscalers from them
+type localMetricsForwarder struct {
+	metrics   map[string]struct{}
+	getClient func() (seriesPoster, error)
+}
+
+// newLocalMetricsForwarder returns a localMetricsForwarder, or nil if
+// the cluster agent is disabled or no metric is to be forwarded
+func newLocalMetricsForwarder() *localMetricsForwarder {
+	names := config.Datadog.GetStringSlice("cluster_agent.forwarded_metrics")
+	if !config.Datadog.GetBool("cluster_agent.enabled") || len(names) == 0 {
+		return nil
+	}
+
+	f := &localMetricsForwarder{
+		metrics: make(map[string]struct{}, len(names)),
+		getClient: func() (seriesPoster, error) {
+			return clusteragent.GetClusterAgentClient()
+		},
+	}
+	for _, name := range names {
+		f.metrics[name] = struct{}{}
+	}
+	return f
+}
+
+// filter returns a copy of the series to forward: the flushed series
+// are also serialized for Datadog and must not be shared
+func (f *localMetricsForwarder) filter(series metrics.Series) metrics.Series {
+	var filtered metrics.Series
+	for _, serie := range series {
+		if _, found := f.metrics[serie.Name]; !found {
+			continue
+		}
+		serieCopy := *serie
+		serieCopy.Tags = append([]string(nil), serie.Tags...)
+		serieCopy.Points = append([]metrics.Point(nil), serie.Points...)
+		filtered = append(filtered, &serieCopy)
+	}
+	return filtered
+}
+
+// submit posts the series to the cluster agent
+func (f *localMetricsForwarder) submit(series metrics.Series) {
+	log.Debugf("Submitting %d series to the cluster agent", len(series))
+	state := stateOk
+	if err := f.post(series); err != nil {
+		log.Warnf("Error submitting series to the cluster agent: %v", err)
+		state = stateError
+	}
+	tlmFlush.Add(float64(len(series)), "cluster_agent_series", state)
+}
+
+func (f *localMetricsForwarder) post(series metrics.Series) error {
+	client, err := f.getClient()
+	if err != nil {
+		return err
+	}
+	response, err := client.PostSeries(series)
+	if err != nil {
+		return err
+	}
+	if response.Dropped > 0 {
+		log.Warnf("The cluster agent dropped %d of the %d series submitted, its local metrics store is full", response.Dropped, len(series))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	// stdlib
+	"encoding/json"
+	"errors"
+	"testing"
+
+	// 3p
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestNewLocalMetricsForwarder(t *testing.T) {
+	assert.Nil(t, newLocalMetricsForwarder())
+
+	config.Datadog.Set("cluster_agent.enabled", true)
+	defer config.Datadog.Set("cluster_agent.enabled", false)
+	assert.Nil(t, newLocalMetricsForwarder())
+
+	config.Datadog.Set("cluster_agent.forwarded_metrics", []string{"foo", "bar"})
+	defer config.Datadog.Set("cluster_agent.forwarded_metrics", []string{})
+	f := newLocalMetricsForwarder()
+	require.NotNil(t, f)
+	assert.Len(t, f.metrics, 2)
+}
+
+func TestLocalMetricsForwarderFilter(t *testing.T) {
+	f := &localMetricsForwarder{metrics: map[string]struct{}{"foo": {}}}
+	serie := &metrics.Serie{Name: "foo", Tags: []string{"a:1"}, Points: []metrics.Point{{Ts: 10, Value: 1}}}
+
+	filtered := f.filter(metrics.Series{serie, &metrics.Serie{Name: "bar"}})
+	require.Len(t, filtered, 1)
+	assert.Equal(t, serie, filtered[0])
+
+	// the forwarded series don't share their tags with the flushed ones
+	filtered[0].Tags[0] = "a:2"
+	assert.Equal(t, []string{"a:1"}, serie.Tags)
+}
+
+type fakeSeriesPoster struct {
+	series metrics.Series
+	err    error
+}
+
+func (p *fakeSeriesPoster) PostSeries(series json.Marshaler) (apiv1.SeriesResponse, error) {
+	submitted := series.(metrics.Series)
+	p.series = append(p.series, submitted...)
+	return apiv1.SeriesResponse{Accepted: len(submitted)}, p.err
+}
+
+func TestLocalMetricsForwarderSubmit(t *testing.T) {
+	poster := &fakeSeriesPoster{}
+	f := &localMetricsForwarder{getClient: func() (seriesPoster, error) { return poster, nil }}
+
+	f.submit(metrics.Series{{Name: "foo"}})
+	assert.Len(t, poster.series, 1)
+
+	// errors are logged, the next flushes retry
+	poster.err = errors.New("leader not found")
+	f.submit(metrics.Series{{Name: "foo"}})
+	f.getClient = func() (seriesPoster, error) { return nil, errors.New("no cluster agent") }
+	f.submit(metrics.Series{{Name: "foo"}})
+	assert.Len(t, poster.series, 2)
+}
@@ -39,6 +39,11 @@ func (m *MockSender) Historate(metric string, value float64, hostname string, ta
 	m.Called(metric, value, hostname, tags)
 }
 
+//Distribution adds a distribution type to the mock calls.
+func (m *MockSender) Distribution(metric string, value float64, hostname string, tags []string) {
+	m.Called(metric, value, hostname, tags)
+}
+
 //Gauge adds a gauge type to the mock calls.
 func (m *MockSender) Gauge(metric string, value float64, hostname string, tags []string) {
 	m.Called(metric, value, hostname, tags)
@@ -399,6 +399,7 @@ func TestCheckSenderInterface(t *testing.T) {
 	checkSender.MonotonicCount("my.monotonic_count_metric", 12.0, "my-hostname", []string{"foo", "bar"})
 	checkSender.Counter("my.counter_metric", 1.0, "my-hostname", []string{"foo", "bar"})
 	checkSender.Histogram("my.histo_metric", 3.0, "my-hostname", []string{"foo", "bar"})
+	checkSender.Distribution("my.distribution_metric", 4.0, "my-hostname", []string{"foo", "bar"})
 	checkSender.HistogramBucket("my.histogram_bucket", 42, 1.0, 2.0, true, "my-hostname", []string{"foo", "bar"})
 	checkSender.Commit()
 	checkSender.ServiceCheck("my_service.can_connect", metrics.ServiceCheckOK, "my-hostname", []string{"foo", "bar"}, "message")
@@ -446,6 +447,11 @@ func TestCheckSenderInterface(t *testing.T) {
 	assert.Equal(t, metrics.HistogramType, histoSenderSample.metricSample.Mtype)
 	assert.Equal(t, false, histoSenderSample.commit)
 
+	distributionSenderSample := <-senderMetricSampleChan
+	assert.EqualValues(t, checkID1, distributionSenderSample.id)
+	assert.Equal(t, metrics.DistributionType, distributionSenderSample.metricSample.Mtype)
+	assert.Equal(t, false, distributionSenderSample.commit)
+
 	commitSenderSample := <-senderMetricSampleChan
 	assert.EqualValues(t, checkID1, commitSenderSample.id)
 	assert.Equal(t, true, commitSenderSample.commit)
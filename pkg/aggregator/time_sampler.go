@@ -6,14 +6,27 @@
 package aggregator
 
 import (
+	"expvar"
+
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 const defaultExpiry = 300.0 // number of seconds after which contexts are expired
 
+var (
+	aggregatorTimestampedSamplesTooOld = expvar.Int{}
+	tlmTimestampedSamplesTooOld        = telemetry.NewCounter("aggregator", "timestamped_samples_too_old",
+		nil, "Count of samples dropped as their client timestamp is older than dogstatsd_timestamp_max_age")
+)
+
+func init() {
+	aggregatorExpvars.Set("TimestampedSamplesTooOld", &aggregatorTimestampedSamplesTooOld)
+}
+
 // SerieSignature holds the elements that allow to know whether two similar `Serie`s
 // from the same bucket can be merged into one
 type SerieSignature struct {
@@ -30,6 +43,10 @@ type TimeSampler struct {
 	counterLastSampledByContext map[ckey.ContextKey]float64
 	lastCutOffTime              int64
 	sketchMap                   sketchMap
+	// gauges sent with a client timestamp, forwarded as-is at the next flush
+	timestampedGauges metrics.Series
+	// samples with a client timestamp older than this number of seconds are dropped
+	timestampMaxAge float64
 }
 
 // NewTimeSampler returns a newly initialized TimeSampler
@@ -43,6 +60,7 @@ func NewTimeSampler(interval int64) *TimeSampler {
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
+		timestampMaxAge:             config.Datadog.GetDuration("dogstatsd_timestamp_max_age").Seconds(),
 	}
 }
 
@@ -54,10 +72,34 @@ func (s *TimeSampler) isBucketStillOpen(bucketStartTimestamp, timestamp int64) b
 	return bucketStartTimestamp+s.interval > timestamp
 }
 
+func (s *TimeSampler) isBucketAlreadyFlushed(bucketStartTimestamp int64) bool {
+	return bucketStartTimestamp+s.interval <= s.lastCutOffTime
+}
+
 // Add the metricSample to the correct bucket
 func (s *TimeSampler) addSample(metricSample *metrics.MetricSample, timestamp float64) {
+	if metricSample.Timestamp > 0 {
+		// A sample with a client timestamp is sampled at that timestamp, it
+		// is dropped if too old and brought back to the current time if in the future.
+		if metricSample.Timestamp < timestamp-s.timestampMaxAge {
+			aggregatorTimestampedSamplesTooOld.Add(1)
+			tlmTimestampedSamplesTooOld.Inc()
+			log.Debugf("Dropping sample '%s' on host '%s' and tags '%s': its timestamp %f is too old", metricSample.Name, metricSample.Host, metricSample.Tags, metricSample.Timestamp)
+			return
+		}
+		if metricSample.Timestamp < timestamp {
+			timestamp = metricSample.Timestamp
+		}
+	}
+
 	// Keep track of the context
 	contextKey := s.contextResolver.trackContext(metricSample, timestamp)
+
+	if metricSample.Timestamp > 0 && metricSample.Mtype == metrics.GaugeType {
+		s.addTimestampedGauge(contextKey, metricSample.Value, timestamp)
+		return
+	}
+
 	bucketStart := s.calculateBucketStart(timestamp)
 
 	switch metricSample.Mtype {
@@ -70,9 +112,12 @@ func (s *TimeSampler) addSample(metricSample *metrics.MetricSample, timestamp fl
 			bucketMetrics = metrics.MakeContextMetrics()
 			s.metricsByTimestamp[bucketStart] = bucketMetrics
 		}
-		// Update LastSampled timestamp for counters
+		// Update LastSampled timestamp for counters, a late sample sent with
+		// a client timestamp must not shorten the expiry of the counter
 		if metricSample.Mtype == metrics.CounterType {
-			s.counterLastSampledByContext[contextKey] = timestamp
+			if lastSampled, ok := s.counterLastSampledByContext[contextKey]; !ok || lastSampled < timestamp {
+				s.counterLastSampledByContext[contextKey] = timestamp
+			}
 		}
 
 		// Add sample to bucket
@@ -82,6 +127,21 @@ func (s *TimeSampler) addSample(metricSample *metrics.MetricSample, timestamp fl
 	}
 }
 
+// addTimestampedGauge keeps a gauge sent with a client timestamp to be forwarded
+// as-is, it is not aggregated with the other samples of its bucket.
+func (s *TimeSampler) addTimestampedGauge(contextKey ckey.ContextKey, value float64, timestamp float64) {
+	// the context is resolved right away, it can be expired before the next flush
+	context := s.contextResolver.contextsByKey[contextKey]
+	s.timestampedGauges = append(s.timestampedGauges, &metrics.Serie{
+		Name:       context.Name,
+		Points:     []metrics.Point{{Ts: timestamp, Value: value}},
+		Tags:       context.Tags,
+		Host:       context.Host,
+		MType:      metrics.APIGaugeType,
+		ContextKey: contextKey,
+	})
+}
+
 func (s *TimeSampler) newSketchSeries(ck ckey.ContextKey, points []metrics.SketchPoint) metrics.SketchSeries {
 	ctx := s.contextResolver.contextsByKey[ck]
 	ss := metrics.SketchSeries{
@@ -104,22 +164,29 @@ func (s *TimeSampler) flushSeries(cutoffTime int64) metrics.Series {
 	// Map to hold the expired contexts that will need to be deleted after the flush so that we stop sending zeros
 	counterContextsToDelete := map[ckey.ContextKey]struct{}{}
 
-	if len(s.metricsByTimestamp) > 0 {
-		for bucketTimestamp, contextMetrics := range s.metricsByTimestamp {
-			// disregard when the timestamp is too recent
-			if s.isBucketStillOpen(bucketTimestamp, cutoffTime) {
-				continue
-			}
+	// Buckets that were already flushed can only be filled by late samples
+	// sent with a client timestamp, their counters already had their zero values sent.
+	onlyLateBuckets := true
+	for bucketTimestamp, contextMetrics := range s.metricsByTimestamp {
+		// disregard when the timestamp is too recent
+		if s.isBucketStillOpen(bucketTimestamp, cutoffTime) {
+			onlyLateBuckets = false
+			continue
+		}
 
+		if !s.isBucketAlreadyFlushed(bucketTimestamp) {
+			onlyLateBuckets = false
 			// Add a 0 sample to all the counters that are not expired.
 			// It is ok to add 0 samples to a counter that was already sampled for real in the bucket, since it won't change its value
 			s.countersSampleZeroValue(bucketTimestamp, contextMetrics, counterContextsToDelete)
+		}
 
-			rawSeries = append(rawSeries, s.flushContextMetrics(bucketTimestamp, contextMetrics)...)
+		rawSeries = append(rawSeries, s.flushContextMetrics(bucketTimestamp, contextMetrics)...)
 
-			delete(s.metricsByTimestamp, bucketTimestamp)
-		}
-	} else if s.lastCutOffTime+s.interval <= cutoffTime {
+		delete(s.metricsByTimestamp, bucketTimestamp)
+	}
+
+	if onlyLateBuckets && s.lastCutOffTime+s.interval <= cutoffTime {
 		// Even if there is no metric in this flush, recreate empty counters,
 		// but only if we've passed an interval since the last flush
 
@@ -135,6 +202,9 @@ func (s *TimeSampler) flushSeries(cutoffTime int64) metrics.Series {
 		delete(s.counterLastSampledByContext, context)
 	}
 
+	series = append(series, s.timestampedGauges...)
+	s.timestampedGauges = nil
+
 	for _, serie := range rawSeries {
 		serieSignature := SerieSignature{serie.MType, serie.ContextKey, serie.NameSuffix}
 
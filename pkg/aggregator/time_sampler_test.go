@@ -62,6 +62,122 @@ func TestBucketSampling(t *testing.T) {
 	}
 }
 
+func TestTimestampedGaugeSampling(t *testing.T) {
+	sampler := NewTimeSampler(10)
+
+	mSample := metrics.MetricSample{
+		Name:       "my.metric.name",
+		Value:      1,
+		Mtype:      metrics.GaugeType,
+		Tags:       []string{"bar", "foo"},
+		SampleRate: 1,
+		Timestamp:  12321.0,
+	}
+	sampler.addSample(&mSample, 12355.0)
+	// timestamps in the future are brought back to the current time
+	futureSample := mSample
+	futureSample.Value = 2
+	futureSample.Timestamp = 99999.0
+	sampler.addSample(&futureSample, 12356.0)
+
+	series, _ := sampler.flush(12360.0)
+
+	// gauges are forwarded as-is, without being aggregated in a bucket
+	expectedSeries := metrics.Series{
+		&metrics.Serie{
+			Name:   "my.metric.name",
+			Tags:   []string{"bar", "foo"},
+			Points: []metrics.Point{{Ts: 12321.0, Value: 1}},
+			MType:  metrics.APIGaugeType,
+		},
+		&metrics.Serie{
+			Name:   "my.metric.name",
+			Tags:   []string{"bar", "foo"},
+			Points: []metrics.Point{{Ts: 12356.0, Value: 2}},
+			MType:  metrics.APIGaugeType,
+		},
+	}
+	assert.Equal(t, 0, len(sampler.metricsByTimestamp))
+	require.Equal(t, 2, len(series))
+	for i, serie := range series {
+		expectedSeries[i].ContextKey = generateSerieContextKey(expectedSeries[i])
+		metrics.AssertSerieEqual(t, expectedSeries[i], serie)
+	}
+
+	series, _ = sampler.flush(12370.0)
+	assert.Equal(t, 0, len(series))
+}
+
+func TestTimestampedGaugeSamplingWithContextLimiter(t *testing.T) {
+	sampler := NewTimeSampler(10)
+	sampler.contextResolver.limiter = newContextLimiter([]ExcludedTagsRule{{Metric: "my.metric.name", Tags: []string{"request_id"}}}, 1)
+
+	newSample := func(tags ...string) *metrics.MetricSample {
+		return &metrics.MetricSample{Name: "my.metric.name", Value: 1, Mtype: metrics.GaugeType, Tags: tags, SampleRate: 1, Timestamp: 12321.0}
+	}
+	sampler.addSample(newSample("foo", "request_id:1"), 12355.0)
+	// the context over the limit is folded into the overflow context
+	sampler.addSample(newSample("bar", "request_id:2"), 12355.0)
+
+	series, _ := sampler.flush(12360.0)
+	require.Equal(t, 2, len(series))
+	assert.Equal(t, []string{"foo"}, series[0].Tags)
+	assert.Equal(t, []string{overflowTag}, series[1].Tags)
+	for _, serie := range series {
+		assert.Contains(t, sampler.contextResolver.contextsByKey, serie.ContextKey)
+	}
+}
+
+func TestTimestampedSampleTooOld(t *testing.T) {
+	sampler := NewTimeSampler(10)
+	sampler.timestampMaxAge = 60
+	tooOld := aggregatorTimestampedSamplesTooOld.Value()
+
+	for _, mtype := range []metrics.MetricType{metrics.GaugeType, metrics.CounterType} {
+		sampler.addSample(&metrics.MetricSample{
+			Name:       "my.metric.name",
+			Value:      1,
+			Mtype:      mtype,
+			SampleRate: 1,
+			Timestamp:  12290.0,
+		}, 12355.0)
+	}
+
+	series, _ := sampler.flush(12360.0)
+	assert.Equal(t, 0, len(series))
+	assert.Len(t, sampler.contextResolver.contextsByKey, 0)
+	assert.Equal(t, tooOld+2, aggregatorTimestampedSamplesTooOld.Value())
+}
+
+func TestTimestampedCounterSampling(t *testing.T) {
+	sampler := NewTimeSampler(10)
+
+	mSample := metrics.MetricSample{
+		Name:       "my.counter.name",
+		Value:      10,
+		Mtype:      metrics.CounterType,
+		Tags:       []string{"bar", "foo"},
+		SampleRate: 1,
+	}
+	sampler.addSample(&mSample, 12355.0)
+	series, _ := sampler.flush(12360.0)
+	require.Equal(t, 1, len(series))
+	assert.Equal(t, []metrics.Point{{Ts: 12350.0, Value: 1}}, series[0].Points)
+
+	// a late sample is routed to the bucket of its client timestamp
+	lateSample := mSample
+	lateSample.Value = 20
+	lateSample.Timestamp = 12345.0
+	sampler.addSample(&lateSample, 12365.0)
+	series, _ = sampler.flush(12370.0)
+	require.Equal(t, 1, len(series))
+	// no zero value is added to the bucket that was already flushed
+	assert.Equal(t, []metrics.Point{{Ts: 12340.0, Value: 2}, {Ts: 12360.0, Value: 0}}, series[0].Points)
+
+	// the late sample doesn't shorten the expiry of the counter
+	assert.Equal(t, 12355.0, sampler.counterLastSampledByContext[series[0].ContextKey])
+}
+
 func TestContextSampling(t *testing.T) {
 	sampler := NewTimeSampler(10)
 
@@ -8,3 +8,6 @@
 package admission
 
 const EnabledLabelKey = "admission.datadoghq.com/enabled"
+
+// DogStatsDSocketLabelKey is the pod or namespace label requesting the DogStatsD socket injection
+const DogStatsDSocketLabelKey = "admission.datadoghq.com/dogstatsd-socket.enabled"
@@ -0,0 +1,238 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package mutate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/metrics"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	admiv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+type language string
+
+const (
+	java   language = "java"
+	js     language = "js"
+	python language = "python"
+
+	// libVersionAnnotationKeyFormat is the annotation requesting the injection of a library version
+	// e.g. admission.datadoghq.com/java-lib.version: "v0.87.0"
+	libVersionAnnotationKeyFormat = "admission.datadoghq.com/%s-lib.version"
+	// customLibAnnotationKeyFormat is the annotation requesting the injection of a library from a custom image
+	// e.g. admission.datadoghq.com/java-lib.custom-image: "registry.example.com/dd-lib-java-init:v0.87.0"
+	customLibAnnotationKeyFormat = "admission.datadoghq.com/%s-lib.custom-image"
+
+	libVolumeName = "datadog-auto-instrumentation"
+	libMountPath  = "/datadog-lib"
+)
+
+var (
+	supportedLanguages = []language{java, js, python}
+
+	libVolume = corev1.Volume{
+		Name: libVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+
+	libVolumeMount = corev1.VolumeMount{
+		Name:      libVolumeName,
+		MountPath: libMountPath,
+	}
+
+	// libEnvVars are the env vars loading the tracing library of each language
+	libEnvVars = map[language]libEnvVar{
+		java: {
+			name:      "JAVA_TOOL_OPTIONS",
+			value:     "-javaagent:" + libMountPath + "/dd-java-agent.jar",
+			separator: " ",
+		},
+		js: {
+			name:      "NODE_OPTIONS",
+			value:     "--require=" + libMountPath + "/node_modules/dd-trace/init",
+			separator: " ",
+		},
+		python: {
+			name:      "PYTHONPATH",
+			value:     libMountPath + "/",
+			separator: ":",
+			prepend:   true,
+		},
+	}
+)
+
+// libInfo holds the tracing library to inject for a language
+type libInfo struct {
+	lang  language
+	image string
+}
+
+// libEnvVar describes how a library value is merged into an env var
+// that may already be defined by the user
+type libEnvVar struct {
+	name      string
+	value     string
+	separator string
+	prepend   bool
+}
+
+// InjectAutoInstrumentation adds an init container copying the APM tracing libraries
+// requested by the pod annotations into a shared volume, and configures the
+// application containers to load them
+func InjectAutoInstrumentation(req *admiv1beta1.AdmissionRequest, dc dynamic.Interface) (*admiv1beta1.AdmissionResponse, error) {
+	return mutate(req, injectAutoInstrumentation, dc)
+}
+
+// injectAutoInstrumentation injects the APM tracing libraries into a pod template if needed
+func injectAutoInstrumentation(pod *corev1.Pod, _ string, _ dynamic.Interface) error {
+	var injected bool
+	defer func() {
+		metrics.MutationAttempts.Inc(metrics.LibInjectionMutationType, strconv.FormatBool(injected))
+	}()
+
+	if pod == nil {
+		metrics.MutationErrors.Inc(metrics.LibInjectionMutationType, "nil pod")
+		return errors.New("cannot inject lib into nil pod")
+	}
+
+	if !shouldInjectLib(pod) {
+		// Ignore pod if it has the label admission.datadoghq.com/enabled=false
+		return nil
+	}
+
+	libs := extractLibInfo(pod, config.Datadog.GetString("admission_controller.auto_instrumentation.container_registry"))
+	if len(libs) == 0 {
+		return nil
+	}
+
+	injected = injectLibs(pod, libs)
+	return nil
+}
+
+// shouldInjectLib returns whether we should try to inject the tracing libraries
+func shouldInjectLib(pod *corev1.Pod) bool {
+	if val := pod.GetLabels()[admission.EnabledLabelKey]; val == "false" {
+		return false
+	}
+	return true
+}
+
+// extractLibInfo returns the tracing libraries requested by the pod annotations
+func extractLibInfo(pod *corev1.Pod, containerRegistry string) []libInfo {
+	var libs []libInfo
+	annotations := pod.GetAnnotations()
+	for _, lang := range supportedLanguages {
+		if image, found := annotations[fmt.Sprintf(customLibAnnotationKeyFormat, lang)]; found {
+			if image == "" {
+				log.Warnf("Ignoring empty annotation '%s' on pod %s", fmt.Sprintf(customLibAnnotationKeyFormat, lang), podString(pod))
+				continue
+			}
+			libs = append(libs, libInfo{lang: lang, image: image})
+			continue
+		}
+
+		if version, found := annotations[fmt.Sprintf(libVersionAnnotationKeyFormat, lang)]; found {
+			if version == "" {
+				log.Warnf("Ignoring empty annotation '%s' on pod %s", fmt.Sprintf(libVersionAnnotationKeyFormat, lang), podString(pod))
+				continue
+			}
+			image := fmt.Sprintf("%s/dd-lib-%s-init:%s", strings.TrimSuffix(containerRegistry, "/"), lang, version)
+			libs = append(libs, libInfo{lang: lang, image: image})
+		}
+	}
+	return libs
+}
+
+// injectLibs injects the init containers, the shared volume and the env vars
+// loading the libraries. It is idempotent: a pod that was already mutated is left untouched.
+func injectLibs(pod *corev1.Pod, libs []libInfo) bool {
+	injected := injectVolume(pod, libVolume, libVolumeMount)
+	for _, lib := range libs {
+		if injectLibInitContainer(pod, lib) {
+			injected = true
+		}
+		if injectLibEnv(pod, libEnvVars[lib.lang]) {
+			injected = true
+		}
+	}
+	return injected
+}
+
+// injectLibInitContainer adds the init container copying a library into the shared volume if it doesn't exist
+func injectLibInitContainer(pod *corev1.Pod, lib libInfo) bool {
+	name := fmt.Sprintf("datadog-lib-%s-init", lib.lang)
+	for _, ctr := range pod.Spec.InitContainers {
+		if ctr.Name == name {
+			log.Debugf("Ignoring init container '%s' in pod %s: already exists", name, podString(pod))
+			return false
+		}
+	}
+
+	log.Debugf("Injecting init container '%s' with image '%s' into pod %s", name, lib.image, podString(pod))
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{
+		Name:         name,
+		Image:        lib.image,
+		Command:      []string{"sh", "copy-lib.sh", libMountPath},
+		VolumeMounts: []corev1.VolumeMount{libVolumeMount},
+	})
+	return true
+}
+
+// injectLibEnv sets the env var loading a library in all the containers,
+// merging it with the value defined by the user if any
+func injectLibEnv(pod *corev1.Pod, libEnv libEnvVar) bool {
+	injected := false
+	podStr := podString(pod)
+	for i, ctr := range pod.Spec.Containers {
+		index := -1
+		for j, env := range ctr.Env {
+			if env.Name == libEnv.name {
+				index = j
+				break
+			}
+		}
+
+		if index < 0 {
+			pod.Spec.Containers[i].Env = append(pod.Spec.Containers[i].Env, corev1.EnvVar{Name: libEnv.name, Value: libEnv.value})
+			injected = true
+			continue
+		}
+
+		env := &pod.Spec.Containers[i].Env[index]
+		if env.ValueFrom != nil {
+			log.Warnf("Ignoring container '%s' in pod %s: env var '%s' is set from a reference and cannot be merged", ctr.Name, podStr, libEnv.name)
+			continue
+		}
+		if strings.Contains(env.Value, libEnv.value) {
+			log.Debugf("Ignoring container '%s' in pod %s: env var '%s' already loads the library", ctr.Name, podStr, libEnv.name)
+			continue
+		}
+
+		switch {
+		case env.Value == "":
+			env.Value = libEnv.value
+		case libEnv.prepend:
+			env.Value = libEnv.value + libEnv.separator + env.Value
+		default:
+			env.Value = env.Value + libEnv.separator + libEnv.value
+		}
+		injected = true
+	}
+	return injected
+}
@@ -0,0 +1,177 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package mutate
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_extractLibInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want []libInfo
+	}{
+		{
+			name: "java",
+			pod:  fakePodWithAnnotations("pod", map[string]string{"admission.datadoghq.com/java-lib.version": "v0.87.0"}),
+			want: []libInfo{{lang: java, image: "registry/dd-lib-java-init:v0.87.0"}},
+		},
+		{
+			name: "custom image takes precedence",
+			pod: fakePodWithAnnotations("pod", map[string]string{
+				"admission.datadoghq.com/python-lib.version":      "v0.50.0",
+				"admission.datadoghq.com/python-lib.custom-image": "foo/bar:baz",
+			}),
+			want: []libInfo{{lang: python, image: "foo/bar:baz"}},
+		},
+		{
+			name: "several languages",
+			pod: fakePodWithAnnotations("pod", map[string]string{
+				"admission.datadoghq.com/js-lib.version":   "v1.0.0",
+				"admission.datadoghq.com/java-lib.version": "v0.87.0",
+			}),
+			want: []libInfo{
+				{lang: java, image: "registry/dd-lib-java-init:v0.87.0"},
+				{lang: js, image: "registry/dd-lib-js-init:v1.0.0"},
+			},
+		},
+		{
+			name: "empty and unknown annotations",
+			pod: fakePodWithAnnotations("pod", map[string]string{
+				"admission.datadoghq.com/java-lib.version": "",
+				"admission.datadoghq.com/ruby-lib.version": "v1.0.0",
+			}),
+			want: nil,
+		},
+		{
+			name: "no annotation",
+			pod:  fakePod("pod"),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, extractLibInfo(tt.pod, "registry/"))
+		})
+	}
+}
+
+func Test_injectLibEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		lang    language
+		env     []corev1.EnvVar
+		wantEnv []corev1.EnvVar
+		want    bool
+	}{
+		{
+			name:    "env var not set",
+			lang:    java,
+			wantEnv: []corev1.EnvVar{fakeEnvWithValue("JAVA_TOOL_OPTIONS", "-javaagent:/datadog-lib/dd-java-agent.jar")},
+			want:    true,
+		},
+		{
+			name:    "env var appended",
+			lang:    js,
+			env:     []corev1.EnvVar{fakeEnvWithValue("NODE_OPTIONS", "--max-old-space-size=4096")},
+			wantEnv: []corev1.EnvVar{fakeEnvWithValue("NODE_OPTIONS", "--max-old-space-size=4096 --require=/datadog-lib/node_modules/dd-trace/init")},
+			want:    true,
+		},
+		{
+			name:    "env var prepended",
+			lang:    python,
+			env:     []corev1.EnvVar{fakeEnvWithValue("PYTHONPATH", "/app")},
+			wantEnv: []corev1.EnvVar{fakeEnvWithValue("PYTHONPATH", "/datadog-lib/:/app")},
+			want:    true,
+		},
+		{
+			name:    "empty env var",
+			lang:    python,
+			env:     []corev1.EnvVar{fakeEnvWithValue("PYTHONPATH", "")},
+			wantEnv: []corev1.EnvVar{fakeEnvWithValue("PYTHONPATH", "/datadog-lib/")},
+			want:    true,
+		},
+		{
+			name:    "library already loaded",
+			lang:    python,
+			env:     []corev1.EnvVar{fakeEnvWithValue("PYTHONPATH", "/datadog-lib/:/app")},
+			wantEnv: []corev1.EnvVar{fakeEnvWithValue("PYTHONPATH", "/datadog-lib/:/app")},
+			want:    false,
+		},
+		{
+			name:    "env var from a reference",
+			lang:    java,
+			env:     []corev1.EnvVar{{Name: "JAVA_TOOL_OPTIONS", ValueFrom: &corev1.EnvVarSource{}}},
+			wantEnv: []corev1.EnvVar{{Name: "JAVA_TOOL_OPTIONS", ValueFrom: &corev1.EnvVarSource{}}},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := fakePodWithContainer("pod", corev1.Container{Name: "ctr", Env: tt.env})
+			assert.Equal(t, tt.want, injectLibEnv(pod, libEnvVars[tt.lang]))
+			assert.Equal(t, tt.wantEnv, pod.Spec.Containers[0].Env)
+		})
+	}
+}
+
+func Test_injectAutoInstrumentation(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("admission_controller.auto_instrumentation.container_registry", "gcr.io/datadoghq")
+
+	pod := fakePodWithAnnotations("pod", map[string]string{
+		"admission.datadoghq.com/java-lib.version": "v0.87.0",
+	})
+	pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "sidecar"})
+
+	err := injectAutoInstrumentation(pod, "", nil)
+	require.NoError(t, err)
+
+	require.Len(t, pod.Spec.Volumes, 1)
+	assert.Equal(t, libVolumeName, pod.Spec.Volumes[0].Name)
+	assert.NotNil(t, pod.Spec.Volumes[0].EmptyDir)
+
+	require.Len(t, pod.Spec.InitContainers, 1)
+	initContainer := pod.Spec.InitContainers[0]
+	assert.Equal(t, "datadog-lib-java-init", initContainer.Name)
+	assert.Equal(t, "gcr.io/datadoghq/dd-lib-java-init:v0.87.0", initContainer.Image)
+	assert.Equal(t, []string{"sh", "copy-lib.sh", "/datadog-lib"}, initContainer.Command)
+	assert.Equal(t, []corev1.VolumeMount{libVolumeMount}, initContainer.VolumeMounts)
+
+	for _, ctr := range pod.Spec.Containers {
+		assert.Equal(t, []corev1.VolumeMount{libVolumeMount}, ctr.VolumeMounts)
+		assert.Equal(t, []corev1.EnvVar{fakeEnvWithValue("JAVA_TOOL_OPTIONS", "-javaagent:/datadog-lib/dd-java-agent.jar")}, ctr.Env)
+	}
+
+	// the mutation is idempotent
+	mutated := pod.DeepCopy()
+	err = injectAutoInstrumentation(pod, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, mutated, pod)
+}
+
+func Test_injectAutoInstrumentationDisabled(t *testing.T) {
+	pod := fakePodWithLabel("admission.datadoghq.com/enabled", "false")
+	pod.Annotations = map[string]string{"admission.datadoghq.com/java-lib.version": "v0.87.0"}
+	pod.Spec.Containers = []corev1.Container{{Name: "ctr"}}
+	want := pod.DeepCopy()
+
+	err := injectAutoInstrumentation(pod, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, want, pod)
+
+	err = injectAutoInstrumentation(nil, "", nil)
+	assert.Error(t, err)
+}
@@ -80,6 +80,49 @@ func injectEnv(pod *corev1.Pod, env corev1.EnvVar) bool {
 	return injected
 }
 
+// injectVolume injects a volume into a pod template if it doesn't exist,
+// and mounts it into all the containers that don't mount it yet
+func injectVolume(pod *corev1.Pod, volume corev1.Volume, volumeMount corev1.VolumeMount) bool {
+	injected := false
+	podStr := podString(pod)
+	log.Debugf("Injecting volume '%s' into pod %s", volume.Name, podStr)
+
+	if !containsVolume(pod.Spec.Volumes, volume.Name) {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, volume)
+		injected = true
+	}
+
+	for i, ctr := range pod.Spec.Containers {
+		if containsVolumeMount(ctr.VolumeMounts, volumeMount.Name) {
+			log.Debugf("Ignoring container '%s' in pod %s: volume '%s' already mounted", ctr.Name, podStr, volumeMount.Name)
+			continue
+		}
+		pod.Spec.Containers[i].VolumeMounts = append(pod.Spec.Containers[i].VolumeMounts, volumeMount)
+		injected = true
+	}
+	return injected
+}
+
+// containsVolume returns whether Volume slice contains a volume with a given name
+func containsVolume(volumes []corev1.Volume, name string) bool {
+	for _, volume := range volumes {
+		if volume.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// containsVolumeMount returns whether VolumeMount slice contains a mount of a given volume
+func containsVolumeMount(volumeMounts []corev1.VolumeMount, name string) bool {
+	for _, volumeMount := range volumeMounts {
+		if volumeMount.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // podString returns a string that helps identify the pod
 func podString(pod *corev1.Pod) string {
 	if pod.GetNamespace() == "" || pod.GetName() == "" {
@@ -131,3 +131,76 @@ func Test_injectEnv(t *testing.T) {
 		})
 	}
 }
+
+func Test_injectVolume(t *testing.T) {
+	volume := corev1.Volume{
+		Name: "foo",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+	volumeMount := corev1.VolumeMount{Name: "foo", MountPath: "/foo"}
+
+	tests := []struct {
+		name        string
+		pod         *corev1.Pod
+		wantPodFunc func() corev1.Pod
+		want        bool
+	}{
+		{
+			name: "inject volume and mounts",
+			pod:  fakePodWithContainer("pod", fakeContainer("ctr1"), fakeContainer("ctr2")),
+			wantPodFunc: func() corev1.Pod {
+				pod := fakePodWithContainer("pod", fakeContainer("ctr1"), fakeContainer("ctr2"))
+				pod.Spec.Volumes = []corev1.Volume{volume}
+				pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{volumeMount}
+				pod.Spec.Containers[1].VolumeMounts = []corev1.VolumeMount{volumeMount}
+				return *pod
+			},
+			want: true,
+		},
+		{
+			name: "volume already mounted in one container",
+			pod: func() *corev1.Pod {
+				pod := fakePodWithContainer("pod", fakeContainer("ctr1"), fakeContainer("ctr2"))
+				pod.Spec.Volumes = []corev1.Volume{volume}
+				pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "foo", MountPath: "/custom"}}
+				return pod
+			}(),
+			wantPodFunc: func() corev1.Pod {
+				pod := fakePodWithContainer("pod", fakeContainer("ctr1"), fakeContainer("ctr2"))
+				pod.Spec.Volumes = []corev1.Volume{volume}
+				pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "foo", MountPath: "/custom"}}
+				pod.Spec.Containers[1].VolumeMounts = []corev1.VolumeMount{volumeMount}
+				return *pod
+			},
+			want: true,
+		},
+		{
+			name: "already injected",
+			pod: func() *corev1.Pod {
+				pod := fakePodWithContainer("pod", fakeContainer("ctr1"))
+				pod.Spec.Volumes = []corev1.Volume{volume}
+				pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{volumeMount}
+				return pod
+			}(),
+			wantPodFunc: func() corev1.Pod {
+				pod := fakePodWithContainer("pod", fakeContainer("ctr1"))
+				pod.Spec.Volumes = []corev1.Volume{volume}
+				pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{volumeMount}
+				return *pod
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := injectVolume(tt.pod, volume, volumeMount); got != tt.want {
+				t.Errorf("injectVolume() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(*tt.pod, tt.wantPodFunc()) {
+				t.Errorf("injectVolume() = %v, want %v", *tt.pod, tt.wantPodFunc())
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package mutate
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/metrics"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	admiv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	dogstatsdURLEnvVarName = "DD_DOGSTATSD_URL"
+	dogstatsdSocketVolume  = "datadog-dogstatsd-socket"
+)
+
+var namespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// InjectDogStatsDSocket mounts the DogStatsD socket directory of the node agent
+// and adds the DD_DOGSTATSD_URL and DD_AGENT_HOST env vars to the pod template
+func InjectDogStatsDSocket(req *admiv1beta1.AdmissionRequest, dc dynamic.Interface) (*admiv1beta1.AdmissionResponse, error) {
+	return mutate(req, injectDogStatsDSocket, dc)
+}
+
+// injectDogStatsDSocket injects the DogStatsD socket volume into a pod template
+// if requested by the pod or namespace labels
+func injectDogStatsDSocket(pod *corev1.Pod, ns string, dc dynamic.Interface) error {
+	var injected bool
+	defer func() {
+		metrics.MutationAttempts.Inc(metrics.DogStatsDSocketMutationType, strconv.FormatBool(injected))
+	}()
+
+	if pod == nil {
+		metrics.MutationErrors.Inc(metrics.DogStatsDSocketMutationType, "nil pod")
+		return errors.New("cannot inject dogstatsd socket into nil pod")
+	}
+
+	inject, err := shouldInjectDogStatsDSocket(pod, ns, dc)
+	if err != nil {
+		metrics.MutationErrors.Inc(metrics.DogStatsDSocketMutationType, "cannot get namespace")
+		return err
+	}
+	if !inject {
+		return nil
+	}
+
+	socketPath := config.Datadog.GetString("admission_controller.inject_dogstatsd_socket.socket_path")
+	if socketPath == "" {
+		metrics.MutationErrors.Inc(metrics.DogStatsDSocketMutationType, "empty socket path")
+		return errors.New("cannot inject dogstatsd socket: admission_controller.inject_dogstatsd_socket.socket_path is empty")
+	}
+
+	socketDir := filepath.Dir(socketPath)
+	hostPathType := corev1.HostPathDirectoryOrCreate
+	volume := corev1.Volume{
+		Name: dogstatsdSocketVolume,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: socketDir,
+				Type: &hostPathType,
+			},
+		},
+	}
+	volumeMount := corev1.VolumeMount{
+		Name:      dogstatsdSocketVolume,
+		MountPath: socketDir,
+		ReadOnly:  true,
+	}
+
+	injectedVolume := injectVolume(pod, volume, volumeMount)
+	injectedURL := injectEnv(pod, corev1.EnvVar{Name: dogstatsdURLEnvVarName, Value: "unix://" + socketPath})
+	// DD_AGENT_HOST is the fallback for the clients that don't support DD_DOGSTATSD_URL
+	injectedHost := injectEnv(pod, agentHostEnvVar)
+	injected = injectedVolume || injectedURL || injectedHost
+
+	return nil
+}
+
+// shouldInjectDogStatsDSocket returns whether the socket should be injected based on
+// the pod labels, or the namespace labels if the pod doesn't set the label
+func shouldInjectDogStatsDSocket(pod *corev1.Pod, ns string, dc dynamic.Interface) (bool, error) {
+	if val := pod.GetLabels()[admission.EnabledLabelKey]; val == "false" {
+		return false, nil
+	}
+
+	if val, found := pod.GetLabels()[admission.DogStatsDSocketLabelKey]; found {
+		return parseDogStatsDSocketLabel(val, "pod "+podString(pod)), nil
+	}
+
+	if ns == "" {
+		return false, nil
+	}
+
+	// Namespaces are cluster-scoped, fetch them like pod owners with an empty namespace
+	namespace, err := getAndCacheOwner(&ownerInfo{gvr: namespaceGVR, name: ns}, "", dc)
+	if err != nil {
+		return false, err
+	}
+
+	if val, found := namespace.GetLabels()[admission.DogStatsDSocketLabelKey]; found {
+		return parseDogStatsDSocketLabel(val, "namespace "+ns), nil
+	}
+
+	return false, nil
+}
+
+// parseDogStatsDSocketLabel returns the value of the socket injection label,
+// invalid values are ignored
+func parseDogStatsDSocketLabel(val, object string) bool {
+	switch val {
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		log.Warnf("Invalid label value '%s=%s' on %s should be either 'true' or 'false', ignoring it", admission.DogStatsDSocketLabelKey, val, object)
+		return false
+	}
+}
@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package mutate
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func newNamespaceWithLabels(name string, labels map[string]string) *unstructured.Unstructured {
+	ns := newUnstructured("v1", "Namespace", "", name)
+	ns.SetLabels(labels)
+	return ns
+}
+
+func Test_shouldInjectDogStatsDSocket(t *testing.T) {
+	tests := []struct {
+		name      string
+		pod       *corev1.Pod
+		namespace *unstructured.Unstructured
+		want      bool
+	}{
+		{
+			name:      "pod label true",
+			pod:       fakePodWithLabel("admission.datadoghq.com/dogstatsd-socket.enabled", "true"),
+			namespace: newNamespaceWithLabels(testNamespace, nil),
+			want:      true,
+		},
+		{
+			name:      "pod label overrides namespace label",
+			pod:       fakePodWithLabel("admission.datadoghq.com/dogstatsd-socket.enabled", "false"),
+			namespace: newNamespaceWithLabels(testNamespace, map[string]string{"admission.datadoghq.com/dogstatsd-socket.enabled": "true"}),
+			want:      false,
+		},
+		{
+			name:      "invalid pod label",
+			pod:       fakePodWithLabel("admission.datadoghq.com/dogstatsd-socket.enabled", "yes"),
+			namespace: newNamespaceWithLabels(testNamespace, nil),
+			want:      false,
+		},
+		{
+			name:      "namespace label true",
+			pod:       fakePodWithLabel("foo", "bar"),
+			namespace: newNamespaceWithLabels(testNamespace, map[string]string{"admission.datadoghq.com/dogstatsd-socket.enabled": "true"}),
+			want:      true,
+		},
+		{
+			name:      "admission disabled on pod",
+			pod:       fakePodWithLabel("admission.datadoghq.com/enabled", "false"),
+			namespace: newNamespaceWithLabels(testNamespace, map[string]string{"admission.datadoghq.com/dogstatsd-socket.enabled": "true"}),
+			want:      false,
+		},
+		{
+			name:      "no label",
+			pod:       fakePodWithLabel("foo", "bar"),
+			namespace: newNamespaceWithLabels(testNamespace, nil),
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer cache.Cache.Flush()
+			dc := fake.NewSimpleDynamicClient(runtime.NewScheme(), tt.namespace)
+			got, err := shouldInjectDogStatsDSocket(tt.pod, testNamespace, dc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_shouldInjectDogStatsDSocketUnknownNamespace(t *testing.T) {
+	defer cache.Cache.Flush()
+	dc := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	_, err := shouldInjectDogStatsDSocket(fakePod("pod"), testNamespace, dc)
+	assert.Error(t, err)
+}
+
+func Test_injectDogStatsDSocket(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("admission_controller.inject_dogstatsd_socket.socket_path", "/var/run/datadog/dsd.socket")
+
+	pod := fakePodWithContainer("pod", fakeContainer("ctr1"), fakeContainer("ctr2"))
+	pod.Labels = map[string]string{"admission.datadoghq.com/dogstatsd-socket.enabled": "true"}
+
+	err := injectDogStatsDSocket(pod, testNamespace, nil)
+	require.NoError(t, err)
+
+	require.Len(t, pod.Spec.Volumes, 1)
+	volume := pod.Spec.Volumes[0]
+	assert.Equal(t, "datadog-dogstatsd-socket", volume.Name)
+	require.NotNil(t, volume.HostPath)
+	assert.Equal(t, "/var/run/datadog", volume.HostPath.Path)
+	assert.Equal(t, corev1.HostPathDirectoryOrCreate, *volume.HostPath.Type)
+
+	for _, ctr := range pod.Spec.Containers {
+		assert.Equal(t, []corev1.VolumeMount{{Name: "datadog-dogstatsd-socket", MountPath: "/var/run/datadog", ReadOnly: true}}, ctr.VolumeMounts)
+		assert.Contains(t, ctr.Env, fakeEnvWithValue("DD_DOGSTATSD_URL", "unix:///var/run/datadog/dsd.socket"))
+		assert.Contains(t, ctr.Env, agentHostEnvVar)
+	}
+
+	// the mutation is idempotent
+	mutated := pod.DeepCopy()
+	err = injectDogStatsDSocket(pod, testNamespace, nil)
+	require.NoError(t, err)
+	assert.Equal(t, mutated, pod)
+
+	// user-defined values are kept
+	pod = fakePodWithEnv("pod", "DD_DOGSTATSD_URL")
+	pod.Labels = map[string]string{"admission.datadoghq.com/dogstatsd-socket.enabled": "true"}
+	err = injectDogStatsDSocket(pod, testNamespace, nil)
+	require.NoError(t, err)
+	assert.Contains(t, pod.Spec.Containers[0].Env, fakeEnv("DD_DOGSTATSD_URL"))
+
+	err = injectDogStatsDSocket(nil, testNamespace, nil)
+	assert.Error(t, err)
+}
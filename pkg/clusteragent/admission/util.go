@@ -63,6 +63,40 @@ func generateWebhooks() []admiv1beta1.MutatingWebhook {
 		webhooks = append(webhooks, webhook)
 	}
 
+	// APM tracing libraries injection
+	if config.Datadog.GetBool("admission_controller.auto_instrumentation.enabled") {
+		webhook := getWebhookSkeleton("lib", config.Datadog.GetString("admission_controller.auto_instrumentation.endpoint"))
+		// Accept all, ignore pods if they're explicitly filtered-out
+		// The libraries are only injected into pods requesting them with annotations
+		webhook.ObjectSelector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      EnabledLabelKey,
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{"false"},
+				},
+			},
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	// DogStatsD socket injection
+	if config.Datadog.GetBool("admission_controller.inject_dogstatsd_socket.enabled") {
+		webhook := getWebhookSkeleton("socket", config.Datadog.GetString("admission_controller.inject_dogstatsd_socket.endpoint"))
+		// Accept all, ignore pods if they're explicitly filtered-out
+		// The socket is only injected into pods or namespaces requesting it with labels
+		webhook.ObjectSelector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      EnabledLabelKey,
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{"false"},
+				},
+			},
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
 	return webhooks
 }
 
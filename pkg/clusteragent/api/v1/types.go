@@ -134,3 +134,9 @@ func NewMetadataResponse() *MetadataResponse {
 		Nodes: make(map[string]*MetadataResponseBundle),
 	}
 }
+
+// SeriesResponse is the response to a submission of series to /api/v1/series
+type SeriesResponse struct {
+	Accepted int `json:"accepted"`
+	Dropped  int `json:"dropped"`
+}
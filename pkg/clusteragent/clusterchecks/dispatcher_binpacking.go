@@ -0,0 +1,389 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package clusterchecks
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	le "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// busynessPlacementStrategy places checks on the least busy node
+	// and moves the heaviest checks of the busiest nodes
+	busynessPlacementStrategy = "busyness"
+	// binPackingPlacementStrategy packs the checks on the nodes based on
+	// their measured cost, see rebalanceByCost
+	binPackingPlacementStrategy = "binpacking"
+)
+
+// endpointInstanceFields are the instance fields from which the endpoint
+// targeted by a check is extracted, in order of precedence
+var endpointInstanceFields = []string{"url", "prometheus_url", "openmetrics_endpoint", "server", "host"}
+
+// placementCheck holds what the bin-packing needs to know about a cluster check
+type placementCheck struct {
+	id       string
+	node     string
+	weight   int
+	affinity string
+	// pinned checks were moved during the cooldown window, they stay on their node
+	pinned bool
+}
+
+// placementPlan holds the result of the bin-packing of the cluster checks
+type placementPlan struct {
+	assignments map[string]string // check ID to node name
+	loads       map[string]int    // expected busyness of each node
+}
+
+// endpointAffinityKey returns the endpoint targeted by the first instance of a
+// check configuration, checks targeting the same endpoint are placed on distinct
+// nodes when possible. It returns an empty string if no endpoint is found.
+func endpointAffinityKey(config integration.Config) string {
+	if len(config.Instances) == 0 {
+		return ""
+	}
+
+	rawInstance := integration.RawMap{}
+	if err := yaml.Unmarshal(config.Instances[0], &rawInstance); err != nil {
+		return ""
+	}
+
+	for _, field := range endpointInstanceFields {
+		value, found := rawInstance[field]
+		if !found {
+			continue
+		}
+		endpoint := strings.TrimSpace(fmt.Sprint(value))
+		if endpoint == "" {
+			continue
+		}
+		if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+			endpoint = u.Host
+		}
+		if port, found := rawInstance["port"]; found && field == "host" {
+			endpoint = net.JoinHostPort(endpoint, fmt.Sprint(port))
+		}
+		return strings.ToLower(endpoint)
+	}
+
+	return ""
+}
+
+// estimateCheckCost returns the weight expected for a check that is not
+// running yet: the average weight of the cluster checks already running.
+// The store lock must be held by the caller.
+func (d *dispatcher) estimateCheckCost() int {
+	total, count := 0, 0
+	for _, node := range d.store.nodes {
+		node.RLock()
+		for _, stats := range node.clcRunnerStats {
+			if stats.IsClusterCheck {
+				total += busynessFunc(stats)
+				count++
+			}
+		}
+		node.RUnlock()
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / count
+}
+
+// getNodeForConfig returns the node to dispatch a new configuration to
+func (d *dispatcher) getNodeForConfig(config integration.Config) string {
+	if !d.advancedDispatching || d.placementStrategy != binPackingPlacementStrategy {
+		return d.getLeastBusyNode()
+	}
+
+	if target := d.getLeastBusyNodeByCost(config); target != "" {
+		return target
+	}
+
+	// Every node is either full or already running a check on the same endpoint
+	log.Debugf("No node can host %s:%s within its capacity, falling back to the least busy node", config.Name, config.Digest())
+	return d.getLeastBusyNode()
+}
+
+// getLeastBusyNodeByCost returns the least busy node that can host the
+// configuration within its capacity, and that does not run a check on the
+// same endpoint. The estimated cost of the check is added to the busyness
+// of the chosen node until the next stats collection.
+func (d *dispatcher) getLeastBusyNodeByCost(config integration.Config) string {
+	affinity := endpointAffinityKey(config)
+
+	d.store.RLock()
+	defer d.store.RUnlock()
+
+	cost := d.estimateCheckCost()
+	nodeNames := make([]string, 0, len(d.store.nodes))
+	for name := range d.store.nodes {
+		if name != "" {
+			nodeNames = append(nodeNames, name)
+		}
+	}
+	sort.Strings(nodeNames)
+
+	var target *nodeStore
+	minBusyness, minCheckCount := 0, 0
+	for _, name := range nodeNames {
+		node := d.store.nodes[name]
+		node.RLock()
+		nodeBusyness := node.busyness
+		if nodeBusyness < 0 {
+			// stats not collected yet
+			nodeBusyness = 0
+		}
+		checkCount := len(node.digestToConfig)
+		conflict := affinity != "" && d.store.hostsEndpoint(node, affinity)
+		node.RUnlock()
+
+		if conflict {
+			continue
+		}
+		if d.nodeCapacity > 0 && nodeBusyness+cost > d.nodeCapacity {
+			continue
+		}
+		if target == nil || nodeBusyness < minBusyness || (nodeBusyness == minBusyness && checkCount < minCheckCount) {
+			target = node
+			minBusyness = nodeBusyness
+			minCheckCount = checkCount
+		}
+	}
+
+	if target == nil {
+		return ""
+	}
+
+	target.Lock()
+	if target.busyness > defaultBusynessValue {
+		target.busyness += cost
+	}
+	target.Unlock()
+
+	return target.name
+}
+
+// hostsEndpoint returns whether a node runs a check targeting the given endpoint.
+// The store and node locks must be held by the caller.
+func (s *clusterStore) hostsEndpoint(node *nodeStore, affinity string) bool {
+	for digest := range node.digestToConfig {
+		if s.digestToAffinity[digest] == affinity {
+			return true
+		}
+	}
+	return false
+}
+
+// collectPlacementChecks returns the cluster checks running on the nodes
+// and the busyness of each node caused by its non cluster checks
+func (d *dispatcher) collectPlacementChecks() ([]placementCheck, map[string]int) {
+	cooldownStart := timestampNow() - int64(d.rebalanceCooldown/time.Second)
+
+	d.store.RLock()
+	defer d.store.RUnlock()
+
+	var checks []placementCheck
+	baseLoads := make(map[string]int, len(d.store.nodes))
+	for name, node := range d.store.nodes {
+		if name == "" {
+			continue
+		}
+		baseLoads[name] = 0
+
+		node.RLock()
+		for id, stats := range node.clcRunnerStats {
+			weight := busynessFunc(stats)
+			if !stats.IsClusterCheck {
+				baseLoads[name] += weight
+				continue
+			}
+			lastMove, moved := d.store.checkLastMove[id]
+			checks = append(checks, placementCheck{
+				id:       id,
+				node:     name,
+				weight:   weight,
+				affinity: d.store.digestToAffinity[d.store.idToDigest[check.ID(id)]],
+				pinned:   moved && lastMove > cooldownStart,
+			})
+		}
+		node.RUnlock()
+	}
+
+	return checks, baseLoads
+}
+
+// planPlacement bin-packs the checks on the nodes: the heaviest checks are
+// placed first, each on the least loaded node that has enough capacity left
+// and does not already run a check on the same endpoint. A check stays on its
+// current node if that node is almost as good as the best one, to limit moves.
+func planPlacement(checks []placementCheck, baseLoads map[string]int, capacity int) placementPlan {
+	plan := placementPlan{
+		assignments: make(map[string]string, len(checks)),
+		loads:       make(map[string]int, len(baseLoads)),
+	}
+	endpoints := make(map[string]map[string]struct{}, len(baseLoads))
+	nodeNames := make([]string, 0, len(baseLoads))
+	for name, load := range baseLoads {
+		plan.loads[name] = load
+		endpoints[name] = make(map[string]struct{})
+		nodeNames = append(nodeNames, name)
+	}
+	sort.Strings(nodeNames)
+
+	assign := func(c placementCheck, node string) {
+		plan.assignments[c.id] = node
+		plan.loads[node] += c.weight
+		if c.affinity != "" {
+			endpoints[node][c.affinity] = struct{}{}
+		}
+	}
+
+	toPlace := make([]placementCheck, 0, len(checks))
+	for _, c := range checks {
+		if _, known := baseLoads[c.node]; c.pinned && known {
+			assign(c, c.node)
+			continue
+		}
+		toPlace = append(toPlace, c)
+	}
+
+	sort.Slice(toPlace, func(i, j int) bool {
+		if toPlace[i].weight != toPlace[j].weight {
+			return toPlace[i].weight > toPlace[j].weight
+		}
+		return toPlace[i].id < toPlace[j].id
+	})
+
+	for _, c := range toPlace {
+		best := pickPlacementNode(c, nodeNames, plan.loads, endpoints, capacity, true)
+		if best == "" {
+			// no node left without a check on the same endpoint
+			best = pickPlacementNode(c, nodeNames, plan.loads, endpoints, capacity, false)
+		}
+		if best == "" {
+			// every node is full, leave the check where it is
+			if _, known := baseLoads[c.node]; known {
+				best = c.node
+			} else if len(nodeNames) > 0 {
+				best = pickPlacementNode(c, nodeNames, plan.loads, endpoints, 0, false)
+			} else {
+				continue
+			}
+		}
+		assign(c, best)
+	}
+
+	return plan
+}
+
+// pickPlacementNode returns the node that should receive a check, or an empty
+// string if no node satisfies the capacity and affinity constraints
+func pickPlacementNode(c placementCheck, nodeNames []string, loads map[string]int, endpoints map[string]map[string]struct{}, capacity int, withAffinity bool) string {
+	best := ""
+	currentFits := false
+	for _, name := range nodeNames {
+		if capacity > 0 && loads[name]+c.weight > capacity {
+			continue
+		}
+		if withAffinity && c.affinity != "" {
+			if _, found := endpoints[name][c.affinity]; found {
+				continue
+			}
+		}
+		if name == c.node {
+			currentFits = true
+		}
+		if best == "" || loads[name] < loads[best] {
+			best = name
+		}
+	}
+
+	// lean towards stability over perfectly optimal balance
+	if currentFits && float64(loads[c.node])*tolerationMargin <= float64(loads[best]) {
+		return c.node
+	}
+	return best
+}
+
+// rebalanceByCost moves the cluster checks according to the bin-packing of
+// their measured cost. Checks moved less than rebalanceCooldown ago are not moved.
+func (d *dispatcher) rebalanceByCost() []types.RebalanceResponse {
+	checks, baseLoads := d.collectPlacementChecks()
+	if len(baseLoads) == 0 {
+		log.Debug("Cannot rebalance checks: zero nodes reporting")
+		return nil
+	}
+
+	currentLoads := make(map[string]int, len(baseLoads))
+	totalLoad := 0
+	for name, load := range baseLoads {
+		currentLoads[name] = load
+		totalLoad += load
+	}
+	for _, c := range checks {
+		currentLoads[c.node] += c.weight
+		totalLoad += c.weight
+	}
+	avg := totalLoad / len(baseLoads)
+
+	plan := planPlacement(checks, baseLoads, d.nodeCapacity)
+
+	checksMoved := []types.RebalanceResponse{}
+	for _, c := range checks {
+		dest, found := plan.assignments[c.id]
+		if !found || dest == c.node {
+			continue
+		}
+
+		rebalancingDecisions.Inc(le.JoinLeaderValue)
+		sourceDiff := currentLoads[c.node] - avg
+		destDiff := currentLoads[dest] - avg
+		if err := d.moveCheck(c.node, dest, c.id); err != nil {
+			log.Debugf("Cannot move check %s: %v", c.id, err)
+			continue
+		}
+
+		successfulRebalancing.Inc(le.JoinLeaderValue)
+		currentLoads[c.node] -= c.weight
+		currentLoads[dest] += c.weight
+		log.Tracef("Check %s with weight %d moved, total avg: %d, source diff: %d, dest diff: %d",
+			c.id, c.weight, avg, sourceDiff, destDiff)
+		checksMoved = append(checksMoved, types.RebalanceResponse{
+			CheckID:        c.id,
+			CheckWeight:    c.weight,
+			SourceNodeName: c.node,
+			SourceDiff:     sourceDiff,
+			DestNodeName:   dest,
+			DestDiff:       destDiff,
+		})
+	}
+
+	if d.nodeCapacity > 0 {
+		for name, load := range plan.loads {
+			if load > d.nodeCapacity {
+				log.Warnf("Node %s is over its cluster checks capacity: busyness %d, capacity %d", name, load, d.nodeCapacity)
+			}
+		}
+	}
+
+	return checksMoved
+}
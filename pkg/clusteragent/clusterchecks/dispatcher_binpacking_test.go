@@ -0,0 +1,261 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package clusterchecks
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateEndpointIntegration(name, instance string) integration.Config {
+	return integration.Config{
+		Name:       name,
+		Instances:  []integration.Data{integration.Data(instance)},
+		InitConfig: integration.Data(""),
+	}
+}
+
+func TestEndpointAffinityKey(t *testing.T) {
+	for i, tc := range []struct {
+		instance string
+		key      string
+	}{
+		{instance: "", key: ""},
+		{instance: "tags: [foo]", key: ""},
+		{instance: "url: http://Redis.default.svc:8080/metrics", key: "redis.default.svc:8080"},
+		{instance: "prometheus_url: http://10.0.0.1:9090/metrics", key: "10.0.0.1:9090"},
+		{instance: "host: 10.0.0.1\nport: 5432", key: "10.0.0.1:5432"},
+		{instance: "server: mysql.default.svc", key: "mysql.default.svc"},
+		{instance: "url: http://foo:80\nhost: bar", key: "foo:80"},
+	} {
+		t.Run(fmt.Sprintf("case %d", i), func(t *testing.T) {
+			assert.Equal(t, tc.key, endpointAffinityKey(generateEndpointIntegration("check", tc.instance)))
+		})
+	}
+
+	assert.Equal(t, "", endpointAffinityKey(integration.Config{Name: "check"}))
+}
+
+func TestEndpointAffinityKeyCache(t *testing.T) {
+	dispatcher := newDispatcher()
+	config := generateEndpointIntegration("check", "url: http://redis:8080/metrics")
+
+	dispatcher.addConfig(config, "")
+	assert.Equal(t, map[string]string{config.Digest(): "redis:8080"}, dispatcher.store.digestToAffinity)
+
+	dispatcher.store.RLock()
+	node := newNodeStore("node1", "")
+	node.addConfig(config)
+	assert.True(t, dispatcher.store.hostsEndpoint(node, "redis:8080"))
+	assert.False(t, dispatcher.store.hostsEndpoint(node, "redis:9090"))
+	dispatcher.store.RUnlock()
+
+	dispatcher.removeConfig(config.Digest())
+	assert.Len(t, dispatcher.store.digestToAffinity, 0)
+
+	requireNotLocked(t, dispatcher.store)
+}
+
+func TestPlanPlacement(t *testing.T) {
+	for i, tc := range []struct {
+		checks      []placementCheck
+		baseLoads   map[string]int
+		capacity    int
+		assignments map[string]string
+		loads       map[string]int
+	}{
+		{
+			// heaviest checks are spread first
+			checks: []placementCheck{
+				{id: "check1", node: "A", weight: 100},
+				{id: "check2", node: "A", weight: 90},
+				{id: "check3", node: "A", weight: 50},
+				{id: "check4", node: "A", weight: 40},
+			},
+			baseLoads:   map[string]int{"A": 0, "B": 0},
+			assignments: map[string]string{"check1": "A", "check2": "B", "check3": "A", "check4": "B"},
+			loads:       map[string]int{"A": 150, "B": 130},
+		},
+		{
+			// the load of the node checks is taken into account
+			checks: []placementCheck{
+				{id: "check1", node: "A", weight: 50},
+				{id: "check2", node: "A", weight: 50},
+			},
+			baseLoads:   map[string]int{"A": 100, "B": 0},
+			assignments: map[string]string{"check1": "B", "check2": "B"},
+			loads:       map[string]int{"A": 100, "B": 100},
+		},
+		{
+			// the capacity is respected, a check that fits nowhere stays on its node
+			checks: []placementCheck{
+				{id: "check1", node: "A", weight: 100},
+				{id: "check2", node: "A", weight: 90},
+				{id: "check3", node: "A", weight: 50},
+			},
+			baseLoads:   map[string]int{"A": 0, "B": 0},
+			capacity:    120,
+			assignments: map[string]string{"check1": "A", "check2": "B", "check3": "A"},
+			loads:       map[string]int{"A": 150, "B": 90},
+		},
+		{
+			// checks targeting the same endpoint are placed on distinct nodes
+			checks: []placementCheck{
+				{id: "check1", node: "A", weight: 10, affinity: "redis:6379"},
+				{id: "check2", node: "A", weight: 10, affinity: "redis:6379"},
+			},
+			baseLoads:   map[string]int{"A": 0, "B": 100},
+			assignments: map[string]string{"check1": "A", "check2": "B"},
+			loads:       map[string]int{"A": 10, "B": 110},
+		},
+		{
+			// the affinity is relaxed when there are not enough nodes
+			checks: []placementCheck{
+				{id: "check1", node: "A", weight: 10, affinity: "redis:6379"},
+				{id: "check2", node: "A", weight: 10, affinity: "redis:6379"},
+			},
+			baseLoads:   map[string]int{"A": 0},
+			assignments: map[string]string{"check1": "A", "check2": "A"},
+			loads:       map[string]int{"A": 20},
+		},
+		{
+			// pinned checks are not moved
+			checks: []placementCheck{
+				{id: "check1", node: "A", weight: 100, pinned: true},
+				{id: "check2", node: "A", weight: 100, pinned: true},
+				{id: "check3", node: "A", weight: 10},
+			},
+			baseLoads:   map[string]int{"A": 0, "B": 0},
+			assignments: map[string]string{"check1": "A", "check2": "A", "check3": "B"},
+			loads:       map[string]int{"A": 200, "B": 10},
+		},
+		{
+			// checks running on unknown nodes are placed
+			checks: []placementCheck{
+				{id: "check1", node: "C", weight: 100, pinned: true},
+			},
+			baseLoads:   map[string]int{"A": 10, "B": 0},
+			assignments: map[string]string{"check1": "B"},
+			loads:       map[string]int{"A": 10, "B": 100},
+		},
+	} {
+		t.Run(fmt.Sprintf("case %d", i), func(t *testing.T) {
+			plan := planPlacement(tc.checks, tc.baseLoads, tc.capacity)
+			assert.Equal(t, tc.assignments, plan.assignments)
+			assert.Equal(t, tc.loads, plan.loads)
+		})
+	}
+}
+
+func TestRebalanceByCost(t *testing.T) {
+	dispatcher := newDispatcher()
+	dispatcher.placementStrategy = binPackingPlacementStrategy
+	dispatcher.rebalanceCooldown = time.Hour
+
+	dispatcher.store.active = true
+	dispatcher.store.nodes["A"] = newNodeStore("A", "")
+	dispatcher.store.nodes["B"] = newNodeStore("B", "")
+
+	stats := types.CLCRunnersStats{}
+	configs := []integration.Config{
+		generateEndpointIntegration("redisdb", "host: redis\nport: 6379"),
+		generateEndpointIntegration("http_check", "url: http://foo:8080"),
+		generateEndpointIntegration("tcp_check", "host: foo\nport: 8080"),
+	}
+	ids := make([]string, 0, len(configs))
+	for i, config := range configs {
+		dispatcher.addConfig(config, "A")
+		id := string(check.BuildID(config.Name, config.Instances[0], config.InitConfig))
+		ids = append(ids, id)
+		stats[id] = types.CLCRunnerStats{AverageExecutionTime: 100, IsClusterCheck: true}
+		if i == 0 {
+			stats[id] = types.CLCRunnerStats{AverageExecutionTime: 200, IsClusterCheck: true}
+		}
+	}
+	dispatcher.store.nodes["A"].clcRunnerStats = stats
+
+	// one of the checks hitting foo:8080 is moved
+	moves := dispatcher.rebalance()
+	require.Len(t, moves, 1)
+	assert.Equal(t, "A", moves[0].SourceNodeName)
+	assert.Equal(t, "B", moves[0].DestNodeName)
+	assert.Equal(t, 80, moves[0].CheckWeight)
+	assert.Contains(t, ids[1:], moves[0].CheckID)
+	_, digest := dispatcher.getConfigAndDigest(moves[0].CheckID)
+	assert.Equal(t, "B", dispatcher.store.digestToNode[digest])
+	assert.Contains(t, dispatcher.store.checkLastMove, moves[0].CheckID)
+
+	// the moved check is pinned to its new node during the cooldown
+	dispatcher.store.nodes["A"].clcRunnerStats = types.CLCRunnersStats{
+		ids[0]: stats[ids[0]],
+	}
+	dispatcher.store.nodes["B"].clcRunnerStats = types.CLCRunnersStats{
+		// the stats of the moved check were removed from the map of node A
+		moves[0].CheckID: types.CLCRunnerStats{AverageExecutionTime: 100, IsClusterCheck: true},
+		"node_check":     types.CLCRunnerStats{AverageExecutionTime: 1000},
+	}
+	assert.Len(t, dispatcher.rebalance(), 0)
+
+	// it is moved back once the cooldown is over
+	dispatcher.store.checkLastMove[moves[0].CheckID] = timestampNow() - 7200
+	moves = dispatcher.rebalance()
+	require.Len(t, moves, 1)
+	assert.Equal(t, "B", moves[0].SourceNodeName)
+	assert.Equal(t, "A", moves[0].DestNodeName)
+
+	requireNotLocked(t, dispatcher.store)
+}
+
+func TestGetNodeForConfigByCost(t *testing.T) {
+	dispatcher := newDispatcher()
+	dispatcher.advancedDispatching = true
+	dispatcher.placementStrategy = binPackingPlacementStrategy
+
+	// No node registered -> empty string
+	assert.Equal(t, "", dispatcher.getNodeForConfig(generateIntegration("A")))
+
+	dispatcher.store.nodes["A"] = newNodeStore("A", "")
+	dispatcher.store.nodes["B"] = newNodeStore("B", "")
+	dispatcher.store.nodes["A"].busyness = 100
+	dispatcher.store.nodes["B"].busyness = 50
+	dispatcher.store.nodes["A"].clcRunnerStats = types.CLCRunnersStats{
+		"check1": types.CLCRunnerStats{AverageExecutionTime: 100, IsClusterCheck: true},
+	}
+	dispatcher.store.nodes["B"].clcRunnerStats = types.CLCRunnersStats{
+		"check2": types.CLCRunnerStats{AverageExecutionTime: 50, IsClusterCheck: true},
+	}
+
+	// least busy node, its busyness is increased by the estimated cost
+	config := generateEndpointIntegration("http_check", "url: http://foo:8080")
+	assert.Equal(t, "B", dispatcher.getNodeForConfig(config))
+	assert.Equal(t, 50+60, dispatcher.store.nodes["B"].busyness)
+	dispatcher.addConfig(config, "B")
+
+	// a node running a check on the same endpoint is avoided
+	dispatcher.store.nodes["B"].busyness = 50
+	assert.Equal(t, "A", dispatcher.getNodeForConfig(generateEndpointIntegration("tcp_check", "host: foo\nport: 8080")))
+
+	// nodes over capacity are avoided, the least busy node is used if none fits
+	dispatcher.store.nodes["A"].busyness = 100
+	dispatcher.store.nodes["B"].busyness = 50
+	dispatcher.nodeCapacity = 120
+	assert.Equal(t, "B", dispatcher.getNodeForConfig(generateIntegration("C")))
+	dispatcher.store.nodes["B"].busyness = 100
+	assert.Equal(t, "", dispatcher.getLeastBusyNodeByCost(generateIntegration("D")))
+	assert.NotEqual(t, "", dispatcher.getNodeForConfig(generateIntegration("D")))
+
+	requireNotLocked(t, dispatcher.store)
+}
@@ -48,6 +48,9 @@ func (d *dispatcher) addConfig(config integration.Config, targetNodeName string)
 	// Register config
 	digest := config.Digest()
 	d.store.digestToConfig[digest] = config
+	if _, found := d.store.digestToAffinity[digest]; !found {
+		d.store.digestToAffinity[digest] = endpointAffinityKey(config)
+	}
 	for _, instance := range config.Instances {
 		d.store.idToDigest[check.BuildID(config.Name, instance, config.InitConfig)] = digest
 	}
@@ -86,6 +89,7 @@ func (d *dispatcher) removeConfig(digest string) {
 	node, found := d.store.getNodeStore(d.store.digestToNode[digest])
 	delete(d.store.digestToNode, digest)
 	delete(d.store.digestToConfig, digest)
+	delete(d.store.digestToAffinity, digest)
 	delete(d.store.danglingConfigs, digest)
 
 	for k, v := range d.store.idToDigest {
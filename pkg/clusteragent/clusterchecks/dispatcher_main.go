@@ -31,6 +31,9 @@ type dispatcher struct {
 	extraTags             []string
 	clcRunnersClient      clusteragent.CLCRunnerClientInterface
 	advancedDispatching   bool
+	placementStrategy     string
+	nodeCapacity          int
+	rebalanceCooldown     time.Duration
 }
 
 func newDispatcher() *dispatcher {
@@ -55,6 +58,14 @@ func newDispatcher() *dispatcher {
 		return d
 	}
 
+	d.placementStrategy = config.Datadog.GetString("cluster_checks.placement_strategy")
+	if d.placementStrategy != busynessPlacementStrategy && d.placementStrategy != binPackingPlacementStrategy {
+		log.Warnf("Unknown cluster checks placement strategy %q, using %q", d.placementStrategy, busynessPlacementStrategy)
+		d.placementStrategy = busynessPlacementStrategy
+	}
+	d.nodeCapacity = config.Datadog.GetInt("cluster_checks.node_capacity")
+	d.rebalanceCooldown = time.Duration(config.Datadog.GetInt64("cluster_checks.rebalance_cooldown")) * time.Second
+
 	var err error
 	d.clcRunnersClient, err = clusteragent.GetCLCRunnerClient()
 	if err != nil {
@@ -128,7 +139,7 @@ func (d *dispatcher) reschedule(configs []integration.Config) {
 
 // add stores and delegates a given configuration
 func (d *dispatcher) add(config integration.Config) {
-	target := d.getLeastBusyNode()
+	target := d.getNodeForConfig(config)
 	if target == "" {
 		// If no node is found, store it in the danglingConfigs map for retrying later.
 		log.Warnf("No available node to dispatch %s:%s on, will retry later", config.Name, config.Digest())
@@ -168,6 +168,10 @@ func (d *dispatcher) moveCheck(src, dest, checkID string) error {
 	d.removeConfig(digest)
 	d.addConfig(config, dest)
 
+	d.store.Lock()
+	d.store.trackCheckMove(checkID, timestampNow()-int64(d.rebalanceCooldown/time.Second))
+	d.store.Unlock()
+
 	log.Debugf("Check %s moved from %s to %s", checkID, src, dest)
 
 	return nil
@@ -185,6 +189,10 @@ func (d *dispatcher) rebalance() []types.RebalanceResponse {
 	}()
 
 	log.Trace("Trying to rebalance cluster checks distribution if needed")
+	if d.placementStrategy == binPackingPlacementStrategy {
+		return d.rebalanceByCost()
+	}
+
 	totalAvg, err := d.calculateAvg()
 	if err != nil {
 		log.Debugf("Cannot rebalance checks: %v", err)
@@ -25,11 +25,13 @@ type clusterStore struct {
 	sync.RWMutex
 	active           bool
 	digestToConfig   map[string]integration.Config            // All configurations to dispatch
+	digestToAffinity map[string]string                        // Endpoint targeted by a config, see endpointAffinityKey
 	digestToNode     map[string]string                        // Node running a config
 	nodes            map[string]*nodeStore                    // All nodes known to the cluster-agent
 	danglingConfigs  map[string]integration.Config            // Configs we could not dispatch to any node
 	endpointsConfigs map[string]map[string]integration.Config // Endpoints configs to be consumed by node agents
 	idToDigest       map[check.ID]string                      // link check IDs to check configs
+	checkLastMove    map[string]int64                         // Last time a check was moved by the rebalancing
 }
 
 func newClusterStore() *clusterStore {
@@ -42,11 +44,13 @@ func newClusterStore() *clusterStore {
 func (s *clusterStore) reset() {
 	s.active = false
 	s.digestToConfig = make(map[string]integration.Config)
+	s.digestToAffinity = make(map[string]string)
 	s.digestToNode = make(map[string]string)
 	s.nodes = make(map[string]*nodeStore)
 	s.danglingConfigs = make(map[string]integration.Config)
 	s.endpointsConfigs = make(map[string]map[string]integration.Config)
 	s.idToDigest = make(map[check.ID]string)
+	s.checkLastMove = make(map[string]int64)
 }
 
 // getNodeStore retrieves the store struct for a given node name, if it exists
@@ -72,6 +76,17 @@ func (s *clusterStore) getOrCreateNodeStore(nodeName, clientIP string) *nodeStor
 	return node
 }
 
+// trackCheckMove records that a check was just moved, and forgets
+// the moves that happened before the cooldown start
+func (s *clusterStore) trackCheckMove(checkID string, cooldownStart int64) {
+	for id, lastMove := range s.checkLastMove {
+		if lastMove <= cooldownStart {
+			delete(s.checkLastMove, id)
+		}
+	}
+	s.checkLastMove[checkID] = timestampNow()
+}
+
 // clearDangling resets the danglingConfigs map to a new empty one
 func (s *clusterStore) clearDangling() {
 	s.danglingConfigs = make(map[string]integration.Config)
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localmetrics
+
+import (
+	"errors"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"gopkg.in/zorkian/go-datadog-api.v2"
+)
+
+// Client answers metric queries from a Store, with the same interface as the
+// Datadog API client so that it can be used by the autoscalers processor
+type Client struct {
+	store *Store
+}
+
+// NewClient returns a new Client querying the given store
+func NewClient(store *Store) *Client {
+	return &Client{store: store}
+}
+
+// QueryMetrics runs the comma-separated queries between from and to (in seconds).
+// Like the Datadog API, it returns one series per query with data, identified by
+// its QueryIndex, and timestamps in milliseconds.
+func (c *Client) QueryMetrics(from, to int64, query string) ([]datadog.Series, error) {
+	// Queries are sent periodically by the leader, use them to drop the stale series
+	c.store.Expire()
+
+	queries := SplitQueries(query)
+	series := make([]datadog.Series, 0, len(queries))
+	invalid := 0
+
+	for i, rawQuery := range queries {
+		q, err := ParseQuery(rawQuery)
+		if err != nil {
+			log.Warnf("Cannot answer query from the local metrics store: %v", err)
+			invalid++
+			continue
+		}
+
+		points := c.store.Query(q, from, to)
+		if len(points) == 0 {
+			log.Debugf("No local data for query %s", rawQuery)
+			continue
+		}
+
+		queryIndex := i
+		metric := q.Metric
+		scope := q.Scope()
+		expression := rawQuery
+		serie := datadog.Series{
+			Metric:     &metric,
+			Scope:      &scope,
+			Expression: &expression,
+			QueryIndex: &queryIndex,
+			Points:     make([]datadog.DataPoint, 0, len(points)),
+		}
+		for _, p := range points {
+			ts := float64(p.Timestamp * 1000)
+			value := p.Value
+			serie.Points = append(serie.Points, datadog.DataPoint{&ts, &value})
+		}
+		series = append(series, serie)
+	}
+
+	if len(queries) > 0 && invalid == len(queries) {
+		return nil, errors.New("none of the queries is supported by the local metrics store")
+	}
+
+	return series, nil
+}
+
+// GetRateLimitStats returns no rate limits, the local store isn't rate limited
+func (c *Client) GetRateLimitStats() map[string]datadog.RateLimit {
+	return map[string]datadog.RateLimit{}
+}
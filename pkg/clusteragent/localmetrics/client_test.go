@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientQueryMetrics(t *testing.T) {
+	now := int64(10000)
+	s := newTestStore(now, 0)
+	s.AddPoints("foo", []string{"app:web"}, []Point{{Timestamp: 9900, Value: 1}, {Timestamp: 9930, Value: 3}})
+	s.AddPoints("bar", []string{"app:web"}, []Point{{Timestamp: 9900, Value: 2}})
+	c := NewClient(s)
+
+	series, err := c.QueryMetrics(now-300, now, "avg:foo{app:web}.rollup(30),avg:unknown{*}.rollup(30),avg:foo{*} * 2,max:bar{app:web}.rollup(30)")
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+
+	assert.Equal(t, "foo", *series[0].Metric)
+	assert.Equal(t, "app:web", *series[0].Scope)
+	assert.Equal(t, 0, *series[0].QueryIndex)
+	require.Len(t, series[0].Points, 2)
+	assert.Equal(t, float64(9900000), *series[0].Points[0][0])
+	assert.Equal(t, float64(1), *series[0].Points[0][1])
+	assert.Equal(t, float64(9930000), *series[0].Points[1][0])
+	assert.Equal(t, float64(3), *series[0].Points[1][1])
+
+	assert.Equal(t, "bar", *series[1].Metric)
+	assert.Equal(t, 3, *series[1].QueryIndex)
+
+	_, err = c.QueryMetrics(now-300, now, "avg:foo{*} / avg:bar{*}")
+	assert.Error(t, err)
+
+	assert.Len(t, c.GetRateLimitStats(), 0)
+}
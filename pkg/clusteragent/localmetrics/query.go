@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localmetrics
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultRollup is the rollup interval in seconds used when the query doesn't specify one
+const defaultRollup = 20
+
+// queryRegexp matches the queries supported by the local backend, e.g.
+// `avg:nginx.net.request_per_s{kube_deployment:nginx,!env:dev}.rollup(max, 30)`
+var queryRegexp = regexp.MustCompile(`^(avg|sum|min|max):([A-Za-z0-9_.]+)\{([^{}]*)\}(?:\.rollup\(\s*(?:(avg|sum|min|max|count)\s*,\s*)?(\d+)\s*\))?$`)
+
+// Query is a metric query supported by the local backend: a space
+// aggregation over a metric with tag filters and a rollup window
+type Query struct {
+	SpaceAggregator string
+	Metric          string
+	// Tags must all be present on the matching series
+	Tags []string
+	// ExcludedTags must not be present on the matching series
+	ExcludedTags   []string
+	TimeAggregator string
+	Rollup         int64
+}
+
+// ParseQuery parses a query in the Datadog query syntax. Only a single
+// metric with tag filters, a space aggregator and an optional rollup is
+// supported: arithmetic and functions must be answered by the Datadog API.
+func ParseQuery(query string) (Query, error) {
+	matches := queryRegexp.FindStringSubmatch(strings.TrimSpace(query))
+	if matches == nil {
+		return Query{}, fmt.Errorf("unsupported query: %s", query)
+	}
+
+	q := Query{
+		SpaceAggregator: matches[1],
+		Metric:          matches[2],
+		TimeAggregator:  "avg",
+		Rollup:          defaultRollup,
+	}
+
+	for _, filter := range strings.Split(matches[3], ",") {
+		filter = strings.TrimSpace(filter)
+		switch {
+		case filter == "" || filter == "*":
+			continue
+		case strings.HasPrefix(filter, "!"):
+			q.ExcludedTags = append(q.ExcludedTags, filter[1:])
+		default:
+			q.Tags = append(q.Tags, filter)
+		}
+	}
+
+	if matches[4] != "" {
+		q.TimeAggregator = matches[4]
+	}
+	if matches[5] != "" {
+		rollup, err := strconv.ParseInt(matches[5], 10, 64)
+		if err != nil || rollup <= 0 {
+			return Query{}, fmt.Errorf("invalid rollup in query: %s", query)
+		}
+		q.Rollup = rollup
+	}
+
+	return q, nil
+}
+
+// Scope returns the tag filters of the query, as returned by the Datadog API
+func (q Query) Scope() string {
+	filters := make([]string, 0, len(q.Tags)+len(q.ExcludedTags))
+	filters = append(filters, q.Tags...)
+	for _, tag := range q.ExcludedTags {
+		filters = append(filters, "!"+tag)
+	}
+	if len(filters) == 0 {
+		return "*"
+	}
+	return strings.Join(filters, ",")
+}
+
+// matches returns whether a series with the given tags matches the query filters
+func (q Query) matches(tagSet map[string]struct{}) bool {
+	for _, tag := range q.Tags {
+		if _, found := tagSet[tag]; !found {
+			return false
+		}
+	}
+	for _, tag := range q.ExcludedTags {
+		if _, found := tagSet[tag]; found {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitQueries splits comma-separated queries, ignoring the
+// commas of the tag filters and of the function arguments
+func SplitQueries(queries string) []string {
+	var result []string
+	depth, start := 0, 0
+	for i, c := range queries {
+		switch c {
+		case '{', '(':
+			depth++
+		case '}', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, strings.TrimSpace(queries[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(queries[start:]); last != "" || len(result) > 0 {
+		result = append(result, last)
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  Query
+		scope string
+		err   bool
+	}{
+		{
+			query: "avg:nginx.net.request_per_s{kube_deployment:nginx,kube_namespace:default}.rollup(30)",
+			want: Query{
+				SpaceAggregator: "avg",
+				Metric:          "nginx.net.request_per_s",
+				Tags:            []string{"kube_deployment:nginx", "kube_namespace:default"},
+				TimeAggregator:  "avg",
+				Rollup:          30,
+			},
+			scope: "kube_deployment:nginx,kube_namespace:default",
+		},
+		{
+			query: "max:redis.net.clients{*}",
+			want: Query{
+				SpaceAggregator: "max",
+				Metric:          "redis.net.clients",
+				TimeAggregator:  "avg",
+				Rollup:          defaultRollup,
+			},
+			scope: "*",
+		},
+		{
+			query: " sum:foo{app:web, !env:dev}.rollup(max, 60) ",
+			want: Query{
+				SpaceAggregator: "sum",
+				Metric:          "foo",
+				Tags:            []string{"app:web"},
+				ExcludedTags:    []string{"env:dev"},
+				TimeAggregator:  "max",
+				Rollup:          60,
+			},
+			scope: "app:web,!env:dev",
+		},
+		{query: "avg:foo{*}.rollup(0)", err: true},
+		{query: "avg:foo{*} / avg:bar{*}", err: true},
+		{query: "top(avg:foo{*} by {host}, 10)", err: true},
+		{query: "p99:foo{*}", err: true},
+		{query: "avg:foo", err: true},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := ParseQuery(tc.query)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, q)
+			assert.Equal(t, tc.scope, q.Scope())
+		})
+	}
+}
+
+func TestSplitQueries(t *testing.T) {
+	assert.Nil(t, SplitQueries(""))
+	assert.Equal(t, []string{"avg:foo{*}"}, SplitQueries("avg:foo{*}"))
+	assert.Equal(t,
+		[]string{"avg:foo{a:1,b:2}.rollup(max, 30)", "sum:bar{*}.rollup(30)"},
+		SplitQueries("avg:foo{a:1,b:2}.rollup(max, 30),sum:bar{*}.rollup(30)"),
+	)
+}
@@ -0,0 +1,284 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localmetrics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+var (
+	globalStore     *Store
+	globalStoreOnce sync.Once
+)
+
+// Point is a timestamped value, timestamps are in seconds
+type Point struct {
+	Timestamp int64
+	Value     float64
+}
+
+// series holds the points of a metric context, sorted by timestamp
+type series struct {
+	name   string
+	tags   []string
+	tagSet map[string]struct{}
+	points []Point
+}
+
+// Store is an in-memory time-series store holding the metrics reported
+// by the node agents for a limited retention window
+type Store struct {
+	lock      sync.RWMutex
+	series    map[string]*series
+	retention int64
+	maxSeries int
+	now       func() int64
+}
+
+// GetStore returns the global Store, configured with
+// external_metrics_provider.local_backend.*
+func GetStore() *Store {
+	globalStoreOnce.Do(func() {
+		globalStore = NewStore(
+			config.Datadog.GetDuration("external_metrics_provider.local_backend.retention")*time.Second,
+			config.Datadog.GetInt("external_metrics_provider.local_backend.max_series"),
+		)
+	})
+	return globalStore
+}
+
+// NewStore returns a new Store keeping the points for the retention duration,
+// and at most maxSeries series. A zero maxSeries means no limit.
+func NewStore(retention time.Duration, maxSeries int) *Store {
+	return &Store{
+		series:    make(map[string]*series),
+		retention: int64(retention / time.Second),
+		maxSeries: maxSeries,
+		now:       func() int64 { return time.Now().Unix() },
+	}
+}
+
+// AddPoints adds the points of a metric context to the store. Points older than
+// the retention window are ignored. It returns false if the context could not
+// be added because the store holds too many series.
+func (s *Store) AddPoints(name string, tags []string, points []Point) bool {
+	tags = normalizeTags(tags)
+	key := contextKey(name, tags)
+	cutoff := s.now() - s.retention
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ser, found := s.series[key]
+	if !found {
+		if s.maxSeries > 0 && len(s.series) >= s.maxSeries {
+			s.expire(cutoff)
+			if len(s.series) >= s.maxSeries {
+				return false
+			}
+		}
+		ser = &series{
+			name:   name,
+			tags:   tags,
+			tagSet: make(map[string]struct{}, len(tags)),
+		}
+		for _, tag := range tags {
+			ser.tagSet[tag] = struct{}{}
+		}
+		s.series[key] = ser
+	}
+
+	for _, p := range points {
+		if p.Timestamp <= cutoff || math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		ser.insert(p)
+	}
+	ser.trim(cutoff)
+
+	return true
+}
+
+// Expire removes the points older than the retention window,
+// and the series left without points
+func (s *Store) Expire() {
+	cutoff := s.now() - s.retention
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.expire(cutoff)
+}
+
+// expire must be called with the lock held
+func (s *Store) expire(cutoff int64) {
+	for key, ser := range s.series {
+		ser.trim(cutoff)
+		if len(ser.points) == 0 {
+			delete(s.series, key)
+		}
+	}
+}
+
+// Len returns the number of series in the store
+func (s *Store) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.series)
+}
+
+// Query returns the points matching a query between from and to (inclusive):
+// the points of each matching series are aggregated in buckets of
+// q.Rollup seconds with q.TimeAggregator, then the series are aggregated
+// together with q.SpaceAggregator. It returns nil if no series matches.
+func (s *Store) Query(q Query, from, to int64) []Point {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	rollup := q.Rollup
+	if rollup <= 0 {
+		rollup = defaultRollup
+	}
+
+	// space aggregation buckets, indexed by timestamp
+	buckets := make(map[int64]*aggregate)
+	matched := false
+	for _, ser := range s.series {
+		if ser.name != q.Metric || !q.matches(ser.tagSet) {
+			continue
+		}
+		matched = true
+
+		var current *aggregate
+		var currentTs int64
+		flush := func() {
+			if current == nil {
+				return
+			}
+			bucket, found := buckets[currentTs]
+			if !found {
+				bucket = &aggregate{}
+				buckets[currentTs] = bucket
+			}
+			bucket.add(current.value(q.TimeAggregator))
+		}
+
+		// points are sorted, aggregate them bucket by bucket
+		for _, p := range ser.points {
+			if p.Timestamp < from || p.Timestamp > to {
+				continue
+			}
+			ts := p.Timestamp - p.Timestamp%rollup
+			if current == nil || ts != currentTs {
+				flush()
+				current = &aggregate{}
+				currentTs = ts
+			}
+			current.add(p.Value)
+		}
+		flush()
+	}
+
+	if !matched {
+		return nil
+	}
+
+	points := make([]Point, 0, len(buckets))
+	for ts, bucket := range buckets {
+		points = append(points, Point{Timestamp: ts, Value: bucket.value(q.SpaceAggregator)})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp < points[j].Timestamp })
+
+	return points
+}
+
+// insert adds a point to the series, keeping the points sorted.
+// A point with the same timestamp as an existing one replaces it.
+func (s *series) insert(p Point) {
+	n := len(s.points)
+	if n == 0 || s.points[n-1].Timestamp < p.Timestamp {
+		// fast path: points are mostly received in order
+		s.points = append(s.points, p)
+		return
+	}
+
+	i := sort.Search(n, func(i int) bool { return s.points[i].Timestamp >= p.Timestamp })
+	if s.points[i].Timestamp == p.Timestamp {
+		s.points[i] = p
+		return
+	}
+	s.points = append(s.points, Point{})
+	copy(s.points[i+1:], s.points[i:])
+	s.points[i] = p
+}
+
+// trim removes the points older than the cutoff
+func (s *series) trim(cutoff int64) {
+	i := sort.Search(len(s.points), func(i int) bool { return s.points[i].Timestamp > cutoff })
+	if i > 0 {
+		s.points = append(s.points[:0], s.points[i:]...)
+	}
+}
+
+// aggregate accumulates values to compute avg, sum, min, max and count
+type aggregate struct {
+	sum   float64
+	min   float64
+	max   float64
+	count int
+}
+
+func (a *aggregate) add(v float64) {
+	if a.count == 0 || v < a.min {
+		a.min = v
+	}
+	if a.count == 0 || v > a.max {
+		a.max = v
+	}
+	a.sum += v
+	a.count++
+}
+
+func (a *aggregate) value(aggregator string) float64 {
+	switch aggregator {
+	case "sum":
+		return a.sum
+	case "min":
+		return a.min
+	case "max":
+		return a.max
+	case "count":
+		return float64(a.count)
+	default:
+		return a.sum / float64(a.count)
+	}
+}
+
+// normalizeTags returns the sorted and deduplicated tags
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		if _, found := seen[tag]; found || tag == "" {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func contextKey(name string, tags []string) string {
+	return name + "|" + strings.Join(tags, ",")
+}
@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localmetrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore(now int64, maxSeries int) *Store {
+	s := NewStore(10*time.Minute, maxSeries)
+	s.now = func() int64 { return now }
+	return s
+}
+
+func TestStoreAddPoints(t *testing.T) {
+	now := int64(10000)
+	s := newTestStore(now, 0)
+
+	assert.True(t, s.AddPoints("foo", []string{"b:2", "a:1", "a:1"}, []Point{
+		{Timestamp: now - 10, Value: 3},
+		{Timestamp: now - 30, Value: 1},
+		{Timestamp: now - 20, Value: 2},
+		{Timestamp: now - 20, Value: 4},    // replaces the previous point
+		{Timestamp: now - 3600, Value: 10}, // too old
+		{Timestamp: now - 5, Value: math.NaN()},
+	}))
+	// same context, tags are normalized
+	assert.True(t, s.AddPoints("foo", []string{"a:1", "b:2"}, []Point{{Timestamp: now, Value: 5}}))
+	assert.Equal(t, 1, s.Len())
+
+	ser := s.series[contextKey("foo", []string{"a:1", "b:2"})]
+	assert.Equal(t, []Point{
+		{Timestamp: now - 30, Value: 1},
+		{Timestamp: now - 20, Value: 4},
+		{Timestamp: now - 10, Value: 3},
+		{Timestamp: now, Value: 5},
+	}, ser.points)
+}
+
+func TestStoreMaxSeries(t *testing.T) {
+	now := int64(10000)
+	s := newTestStore(now, 2)
+
+	assert.True(t, s.AddPoints("foo", []string{"a:1"}, []Point{{Timestamp: now - 500, Value: 1}}))
+	assert.True(t, s.AddPoints("foo", []string{"a:2"}, []Point{{Timestamp: now, Value: 1}}))
+	assert.False(t, s.AddPoints("foo", []string{"a:3"}, []Point{{Timestamp: now, Value: 1}}))
+	// existing series can still receive points
+	assert.True(t, s.AddPoints("foo", []string{"a:2"}, []Point{{Timestamp: now + 1, Value: 1}}))
+
+	// expired series free some room
+	s.now = func() int64 { return now + 200 }
+	assert.True(t, s.AddPoints("foo", []string{"a:3"}, []Point{{Timestamp: now + 200, Value: 1}}))
+	assert.Equal(t, 2, s.Len())
+}
+
+func TestStoreExpire(t *testing.T) {
+	now := int64(10000)
+	s := newTestStore(now, 0)
+
+	s.AddPoints("foo", nil, []Point{{Timestamp: now - 500, Value: 1}, {Timestamp: now, Value: 2}})
+	s.AddPoints("bar", nil, []Point{{Timestamp: now - 500, Value: 1}})
+
+	s.now = func() int64 { return now + 200 }
+	s.Expire()
+	assert.Equal(t, 1, s.Len())
+	assert.Equal(t, []Point{{Timestamp: now, Value: 2}}, s.series[contextKey("foo", nil)].points)
+}
+
+func TestStoreQuery(t *testing.T) {
+	now := int64(10000)
+	s := newTestStore(now, 0)
+
+	s.AddPoints("requests", []string{"app:web", "host:a"}, []Point{
+		{Timestamp: 9900, Value: 1},
+		{Timestamp: 9910, Value: 3},
+		{Timestamp: 9930, Value: 5},
+	})
+	s.AddPoints("requests", []string{"app:web", "host:b"}, []Point{
+		{Timestamp: 9905, Value: 10},
+		{Timestamp: 9935, Value: 20},
+	})
+	s.AddPoints("requests", []string{"app:api", "host:a"}, []Point{
+		{Timestamp: 9905, Value: 100},
+	})
+	s.AddPoints("errors", []string{"app:web", "host:a"}, []Point{
+		{Timestamp: 9905, Value: 1000},
+	})
+
+	for _, tc := range []struct {
+		name  string
+		query Query
+		from  int64
+		want  []Point
+	}{
+		{
+			name:  "avg of avg",
+			query: Query{SpaceAggregator: "avg", Metric: "requests", Tags: []string{"app:web"}, TimeAggregator: "avg", Rollup: 30},
+			want:  []Point{{Timestamp: 9900, Value: 6}, {Timestamp: 9930, Value: 12.5}},
+		},
+		{
+			name:  "sum of max",
+			query: Query{SpaceAggregator: "sum", Metric: "requests", Tags: []string{"app:web"}, TimeAggregator: "max", Rollup: 30},
+			want:  []Point{{Timestamp: 9900, Value: 13}, {Timestamp: 9930, Value: 25}},
+		},
+		{
+			name:  "max over all series",
+			query: Query{SpaceAggregator: "max", Metric: "requests", TimeAggregator: "avg", Rollup: 60},
+			want:  []Point{{Timestamp: 9900, Value: 100}},
+		},
+		{
+			name:  "excluded tags",
+			query: Query{SpaceAggregator: "min", Metric: "requests", ExcludedTags: []string{"host:b"}, TimeAggregator: "avg", Rollup: 60},
+			want:  []Point{{Timestamp: 9900, Value: 3}},
+		},
+		{
+			name:  "time window",
+			query: Query{SpaceAggregator: "sum", Metric: "requests", Tags: []string{"host:a"}, TimeAggregator: "count", Rollup: 10},
+			from:  9910,
+			want:  []Point{{Timestamp: 9910, Value: 1}, {Timestamp: 9930, Value: 1}},
+		},
+		{
+			name:  "no match",
+			query: Query{SpaceAggregator: "avg", Metric: "requests", Tags: []string{"app:unknown"}, TimeAggregator: "avg", Rollup: 30},
+			want:  nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			from := tc.from
+			if from == 0 {
+				from = now - 600
+			}
+			assert.Equal(t, tc.want, s.Query(tc.query, from, now))
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 	model "github.com/DataDog/agent-payload/process"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	appslisters "k8s.io/client-go/listers/apps/v1"
@@ -55,6 +56,7 @@ type Controller struct {
 	rsListerSync            cache.InformerSynced
 	serviceLister           corelisters.ServiceLister
 	serviceListerSync       cache.InformerSynced
+	collectors              []*resourceCollector
 	groupID                 int32
 	hostName                string
 	clusterName             string
@@ -65,6 +67,17 @@ type Controller struct {
 	isScrubbingEnabled      bool
 }
 
+// resourceCollector collects a kind of resources which may not be served by
+// the API server or not allowed to the cluster agent: its informer is synced
+// on its own, and the kind is skipped until it is.
+type resourceCollector struct {
+	name        apiserver.InformerName
+	payloadType string
+	informer    cache.SharedInformer
+	// process lists the resources and turns them into messages
+	process func(groupID int32) ([]model.MessageBody, error)
+}
+
 // StartController starts the orchestrator controller
 func StartController(ctx ControllerContext) error {
 	if !config.Datadog.GetBool("orchestrator_explorer.enabled") {
@@ -136,11 +149,149 @@ func newController(ctx ControllerContext) (*Controller, error) {
 		isLeaderFunc:            ctx.IsLeaderFunc,
 		isScrubbingEnabled:      config.Datadog.GetBool("orchestrator_explorer.container_scrubbing.enabled"),
 	}
+	oc.collectors = oc.newResourceCollectors(ctx.InformerFactory, ctx.Client.Discovery())
 
 	oc.processConfig = cfg
 	return oc, nil
 }
 
+// newResourceCollectors returns the collectors of the resources served by the
+// API server. The informers of the resources which are not served are not
+// created, so that the informer factory doesn't start them.
+func (o *Controller) newResourceCollectors(factory informers.SharedInformerFactory, client discovery.DiscoveryInterface) []*resourceCollector {
+	var collectors []*resourceCollector
+
+	if isResourceServed(client, "v1", "nodes") {
+		informer := factory.Core().V1().Nodes()
+		collectors = append(collectors, &resourceCollector{
+			name:        apiserver.NodesInformer,
+			payloadType: forwarder.PayloadTypeNode,
+			informer:    informer.Informer(),
+			process: func(groupID int32) ([]model.MessageBody, error) {
+				list, err := informer.Lister().List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				return processNodeList(list, groupID, o.processConfig, o.clusterName, o.clusterID)
+			},
+		})
+	}
+
+	if isResourceServed(client, "apps/v1", "daemonsets") {
+		informer := factory.Apps().V1().DaemonSets()
+		collectors = append(collectors, &resourceCollector{
+			name:        apiserver.DaemonSetsInformer,
+			payloadType: forwarder.PayloadTypeDaemonSet,
+			informer:    informer.Informer(),
+			process: func(groupID int32) ([]model.MessageBody, error) {
+				list, err := informer.Lister().List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				return processDaemonSetList(list, groupID, o.processConfig, o.clusterName, o.clusterID, o.isScrubbingEnabled)
+			},
+		})
+	}
+
+	if isResourceServed(client, "apps/v1", "statefulsets") {
+		informer := factory.Apps().V1().StatefulSets()
+		collectors = append(collectors, &resourceCollector{
+			name:        apiserver.StatefulSetsInformer,
+			payloadType: forwarder.PayloadTypeStatefulSet,
+			informer:    informer.Informer(),
+			process: func(groupID int32) ([]model.MessageBody, error) {
+				list, err := informer.Lister().List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				return processStatefulSetList(list, groupID, o.processConfig, o.clusterName, o.clusterID, o.isScrubbingEnabled)
+			},
+		})
+	}
+
+	if isResourceServed(client, "batch/v1", "jobs") {
+		informer := factory.Batch().V1().Jobs()
+		collectors = append(collectors, &resourceCollector{
+			name:        apiserver.JobsInformer,
+			payloadType: forwarder.PayloadTypeJob,
+			informer:    informer.Informer(),
+			process: func(groupID int32) ([]model.MessageBody, error) {
+				list, err := informer.Lister().List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				return processJobList(list, groupID, o.processConfig, o.clusterName, o.clusterID, o.isScrubbingEnabled)
+			},
+		})
+	}
+
+	if isResourceServed(client, "batch/v1beta1", "cronjobs") {
+		informer := factory.Batch().V1beta1().CronJobs()
+		collectors = append(collectors, &resourceCollector{
+			name:        apiserver.CronJobsInformer,
+			payloadType: forwarder.PayloadTypeCronJob,
+			informer:    informer.Informer(),
+			process: func(groupID int32) ([]model.MessageBody, error) {
+				list, err := informer.Lister().List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				return processCronJobList(list, groupID, o.processConfig, o.clusterName, o.clusterID, o.isScrubbingEnabled)
+			},
+		})
+	}
+
+	if isResourceServed(client, "v1", "persistentvolumes") {
+		informer := factory.Core().V1().PersistentVolumes()
+		collectors = append(collectors, &resourceCollector{
+			name:        apiserver.PersistentVolumesInformer,
+			payloadType: forwarder.PayloadTypePersistentVolume,
+			informer:    informer.Informer(),
+			process: func(groupID int32) ([]model.MessageBody, error) {
+				list, err := informer.Lister().List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				return processPersistentVolumeList(list, groupID, o.processConfig, o.clusterName, o.clusterID)
+			},
+		})
+	}
+
+	if isResourceServed(client, "v1", "persistentvolumeclaims") {
+		informer := factory.Core().V1().PersistentVolumeClaims()
+		collectors = append(collectors, &resourceCollector{
+			name:        apiserver.PersistentVolumeClaimsInformer,
+			payloadType: forwarder.PayloadTypePersistentVolumeClaim,
+			informer:    informer.Informer(),
+			process: func(groupID int32) ([]model.MessageBody, error) {
+				list, err := informer.Lister().List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				return processPersistentVolumeClaimList(list, groupID, o.processConfig, o.clusterName, o.clusterID)
+			},
+		})
+	}
+
+	return collectors
+}
+
+// isResourceServed returns whether the API server serves the given resource in the given group version
+func isResourceServed(client discovery.DiscoveryInterface, groupVersion, resource string) bool {
+	resources, err := client.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		log.Infof("Not collecting %s: unable to discover the resources of %s: %v", resource, groupVersion, err)
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource {
+			return true
+		}
+	}
+	log.Infof("Not collecting %s: not served in %s", resource, groupVersion)
+	return false
+}
+
 // Run starts the orchestrator controller
 func (o *Controller) Run(stopCh <-chan struct{}) {
 	log.Infof("Starting orchestrator controller")
@@ -161,6 +312,10 @@ func (o *Controller) Run(stopCh <-chan struct{}) {
 		o.processDeploys,
 		o.processServices,
 	}
+	for _, c := range o.collectors {
+		collector := c
+		processors = append(processors, func() { o.processResources(collector) })
+	}
 
 	spreadProcessors(processors, 2*time.Second, 10*time.Second, stopCh)
 
@@ -250,6 +405,26 @@ func (o *Controller) processServices() {
 	o.sendMessages(messages, forwarder.PayloadTypeService)
 }
 
+// processResources sends the resources of a collector once its informer is synced
+func (o *Controller) processResources(c *resourceCollector) {
+	if !o.isLeaderFunc() {
+		return
+	}
+
+	if !c.informer.HasSynced() {
+		log.Debugf("Informer %s not synced yet, skipping its resources", c.name)
+		return
+	}
+
+	msg, err := c.process(atomic.AddInt32(&o.groupID, 1))
+	if err != nil {
+		log.Errorf("Unable to process %s: %v", c.name, err)
+		return
+	}
+
+	o.sendMessages(msg, c.payloadType)
+}
+
 func (o *Controller) sendMessages(msg []model.MessageBody, payloadType string) {
 	for _, m := range msg {
 		extraHeaders := make(http.Header)
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver,orchestrator
+
+package orchestrator
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util/api"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// orchestratorForwarder records the orchestrator payloads submitted to it
+type orchestratorForwarder struct {
+	forwarder.MockedForwarder
+	payloadTypes []string
+	headers      []http.Header
+}
+
+func (f *orchestratorForwarder) SubmitOrchestratorChecks(payload forwarder.Payloads, extra http.Header, payloadType string) (chan forwarder.Response, error) {
+	f.payloadTypes = append(f.payloadTypes, payloadType)
+	f.headers = append(f.headers, extra)
+	responses := make(chan forwarder.Response)
+	close(responses)
+	return responses, nil
+}
+
+func newFakeClient(resources []*metav1.APIResourceList, objects ...runtime.Object) *fake.Clientset {
+	client := fake.NewSimpleClientset(objects...)
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = resources
+	return client
+}
+
+func TestNewResourceCollectors(t *testing.T) {
+	client := newFakeClient([]*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "nodes"}, {Name: "persistentvolumes"}}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments"}, {Name: "daemonsets"}}},
+	})
+	o := &Controller{}
+
+	collectors := o.newResourceCollectors(informers.NewSharedInformerFactory(client, 0), client.Discovery())
+
+	// the resources which are not served are not collected
+	var names []apiserver.InformerName
+	for _, c := range collectors {
+		names = append(names, c.name)
+	}
+	assert.Equal(t, []apiserver.InformerName{apiserver.NodesInformer, apiserver.DaemonSetsInformer, apiserver.PersistentVolumesInformer}, names)
+}
+
+func TestProcessResources(t *testing.T) {
+	client := newFakeClient([]*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "nodes"}}},
+		{GroupVersion: "batch/v1beta1", APIResources: []metav1.APIResource{{Name: "cronjobs"}}},
+	}, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "controller-test-node", ResourceVersion: "1"}})
+
+	isLeader := false
+	fwd := &orchestratorForwarder{}
+	o := &Controller{
+		hostName:      "cluster-agent",
+		clusterName:   "cluster",
+		clusterID:     "cluster-id",
+		forwarder:     fwd,
+		processConfig: &config.AgentConfig{MaxPerMessage: 10},
+		isLeaderFunc:  func() bool { return isLeader },
+	}
+	o.collectors = o.newResourceCollectors(informers.NewSharedInformerFactory(client, 0), client.Discovery())
+	require.Len(t, o.collectors, 2)
+
+	// only the nodes informer is started, as if the cron jobs couldn't be listed
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go o.collectors[0].informer.Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(stopCh, o.collectors[0].informer.HasSynced))
+
+	// only the leader collects the resources
+	for _, c := range o.collectors {
+		o.processResources(c)
+	}
+	assert.Len(t, fwd.payloadTypes, 0)
+
+	// the unsynced cron jobs don't block the nodes
+	isLeader = true
+	for _, c := range o.collectors {
+		o.processResources(c)
+	}
+	assert.Equal(t, []string{forwarder.PayloadTypeNode}, fwd.payloadTypes)
+	assert.Equal(t, "cluster-agent", fwd.headers[0].Get(api.HostHeader))
+	assert.Equal(t, "cluster-id", fwd.headers[0].Get(api.ClusterIDHeader))
+}
@@ -17,6 +17,8 @@ import (
 
 	jsoniter "github.com/json-iterator/go"
 	v1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -232,3 +234,498 @@ func chunkServices(services []*model.Service, chunkCount, chunkSize int) [][]*mo
 
 	return chunks
 }
+
+// processDaemonSetList process a daemon set list into process messages.
+func processDaemonSetList(daemonSetList []*v1.DaemonSet, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string, withScrubbing bool) ([]model.MessageBody, error) {
+	start := time.Now()
+	daemonSetMsgs := make([]*model.DaemonSet, 0, len(daemonSetList))
+
+	for i := 0; i < len(daemonSetList); i++ {
+		ds := daemonSetList[i]
+		if orchestrator.SkipKubernetesResource(ds.UID, ds.ResourceVersion) {
+			continue
+		}
+
+		daemonSetModel := extractDaemonSet(ds)
+
+		// scrub & generate YAML
+		if withScrubbing {
+			for c := 0; c < len(ds.Spec.Template.Spec.InitContainers); c++ {
+				orchestrator.ScrubContainer(&ds.Spec.Template.Spec.InitContainers[c], cfg)
+			}
+			for c := 0; c < len(ds.Spec.Template.Spec.Containers); c++ {
+				orchestrator.ScrubContainer(&ds.Spec.Template.Spec.Containers[c], cfg)
+			}
+		}
+
+		// k8s objects only have json "omitempty" annotations
+		// and marshalling is more performant than YAML
+		jsonDaemonSet, err := jsoniter.Marshal(ds)
+		if err != nil {
+			log.Debugf("Could not marshal daemon set to JSON: %s", err)
+			continue
+		}
+		daemonSetModel.Yaml = jsonDaemonSet
+
+		daemonSetMsgs = append(daemonSetMsgs, daemonSetModel)
+	}
+
+	groupSize := len(daemonSetMsgs) / cfg.MaxPerMessage
+	if len(daemonSetMsgs)%cfg.MaxPerMessage != 0 {
+		groupSize++
+	}
+	chunked := chunkDaemonSets(daemonSetMsgs, groupSize, cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		messages = append(messages, &model.CollectorDaemonSet{
+			ClusterName: clusterName,
+			DaemonSets:  chunked[i],
+			GroupId:     groupID,
+			GroupSize:   int32(groupSize),
+			ClusterId:   clusterID,
+		})
+	}
+
+	log.Debugf("Collected & enriched %d out of %d daemon sets in %s", len(daemonSetMsgs), len(daemonSetList), time.Now().Sub(start))
+	return messages, nil
+}
+
+// chunkDaemonSets chunks the given list of daemon sets, honoring the given chunk count and size.
+// The last chunk may be smaller than the others.
+func chunkDaemonSets(daemonSets []*model.DaemonSet, chunkCount, chunkSize int) [][]*model.DaemonSet {
+	chunks := make([][]*model.DaemonSet, 0, chunkCount)
+
+	for c := 1; c <= chunkCount; c++ {
+		var (
+			chunkStart = chunkSize * (c - 1)
+			chunkEnd   = chunkSize * (c)
+		)
+		// last chunk may be smaller than the chunk size
+		if c == chunkCount {
+			chunkEnd = len(daemonSets)
+		}
+		chunks = append(chunks, daemonSets[chunkStart:chunkEnd])
+	}
+
+	return chunks
+}
+
+// processStatefulSetList process a stateful set list into process messages.
+func processStatefulSetList(statefulSetList []*v1.StatefulSet, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string, withScrubbing bool) ([]model.MessageBody, error) {
+	start := time.Now()
+	statefulSetMsgs := make([]*model.StatefulSet, 0, len(statefulSetList))
+
+	for i := 0; i < len(statefulSetList); i++ {
+		sts := statefulSetList[i]
+		if orchestrator.SkipKubernetesResource(sts.UID, sts.ResourceVersion) {
+			continue
+		}
+
+		statefulSetModel := extractStatefulSet(sts)
+
+		// scrub & generate YAML
+		if withScrubbing {
+			for c := 0; c < len(sts.Spec.Template.Spec.InitContainers); c++ {
+				orchestrator.ScrubContainer(&sts.Spec.Template.Spec.InitContainers[c], cfg)
+			}
+			for c := 0; c < len(sts.Spec.Template.Spec.Containers); c++ {
+				orchestrator.ScrubContainer(&sts.Spec.Template.Spec.Containers[c], cfg)
+			}
+		}
+
+		// k8s objects only have json "omitempty" annotations
+		// and marshalling is more performant than YAML
+		jsonStatefulSet, err := jsoniter.Marshal(sts)
+		if err != nil {
+			log.Debugf("Could not marshal stateful set to JSON: %s", err)
+			continue
+		}
+		statefulSetModel.Yaml = jsonStatefulSet
+
+		statefulSetMsgs = append(statefulSetMsgs, statefulSetModel)
+	}
+
+	groupSize := len(statefulSetMsgs) / cfg.MaxPerMessage
+	if len(statefulSetMsgs)%cfg.MaxPerMessage != 0 {
+		groupSize++
+	}
+	chunked := chunkStatefulSets(statefulSetMsgs, groupSize, cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		messages = append(messages, &model.CollectorStatefulSet{
+			ClusterName:  clusterName,
+			StatefulSets: chunked[i],
+			GroupId:      groupID,
+			GroupSize:    int32(groupSize),
+			ClusterId:    clusterID,
+		})
+	}
+
+	log.Debugf("Collected & enriched %d out of %d stateful sets in %s", len(statefulSetMsgs), len(statefulSetList), time.Now().Sub(start))
+	return messages, nil
+}
+
+// chunkStatefulSets chunks the given list of stateful sets, honoring the given chunk count and size.
+// The last chunk may be smaller than the others.
+func chunkStatefulSets(statefulSets []*model.StatefulSet, chunkCount, chunkSize int) [][]*model.StatefulSet {
+	chunks := make([][]*model.StatefulSet, 0, chunkCount)
+
+	for c := 1; c <= chunkCount; c++ {
+		var (
+			chunkStart = chunkSize * (c - 1)
+			chunkEnd   = chunkSize * (c)
+		)
+		// last chunk may be smaller than the chunk size
+		if c == chunkCount {
+			chunkEnd = len(statefulSets)
+		}
+		chunks = append(chunks, statefulSets[chunkStart:chunkEnd])
+	}
+
+	return chunks
+}
+
+// processJobList process a job list into process messages.
+func processJobList(jobList []*batchv1.Job, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string, withScrubbing bool) ([]model.MessageBody, error) {
+	start := time.Now()
+	jobMsgs := make([]*model.Job, 0, len(jobList))
+
+	for i := 0; i < len(jobList); i++ {
+		j := jobList[i]
+		if orchestrator.SkipKubernetesResource(j.UID, j.ResourceVersion) {
+			continue
+		}
+
+		jobModel := extractJob(j)
+
+		// scrub & generate YAML
+		if withScrubbing {
+			for c := 0; c < len(j.Spec.Template.Spec.InitContainers); c++ {
+				orchestrator.ScrubContainer(&j.Spec.Template.Spec.InitContainers[c], cfg)
+			}
+			for c := 0; c < len(j.Spec.Template.Spec.Containers); c++ {
+				orchestrator.ScrubContainer(&j.Spec.Template.Spec.Containers[c], cfg)
+			}
+		}
+
+		// k8s objects only have json "omitempty" annotations
+		// and marshalling is more performant than YAML
+		jsonJob, err := jsoniter.Marshal(j)
+		if err != nil {
+			log.Debugf("Could not marshal job to JSON: %s", err)
+			continue
+		}
+		jobModel.Yaml = jsonJob
+
+		jobMsgs = append(jobMsgs, jobModel)
+	}
+
+	groupSize := len(jobMsgs) / cfg.MaxPerMessage
+	if len(jobMsgs)%cfg.MaxPerMessage != 0 {
+		groupSize++
+	}
+	chunked := chunkJobs(jobMsgs, groupSize, cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		messages = append(messages, &model.CollectorJob{
+			ClusterName: clusterName,
+			Jobs:        chunked[i],
+			GroupId:     groupID,
+			GroupSize:   int32(groupSize),
+			ClusterId:   clusterID,
+		})
+	}
+
+	log.Debugf("Collected & enriched %d out of %d jobs in %s", len(jobMsgs), len(jobList), time.Now().Sub(start))
+	return messages, nil
+}
+
+// chunkJobs chunks the given list of jobs, honoring the given chunk count and size.
+// The last chunk may be smaller than the others.
+func chunkJobs(jobs []*model.Job, chunkCount, chunkSize int) [][]*model.Job {
+	chunks := make([][]*model.Job, 0, chunkCount)
+
+	for c := 1; c <= chunkCount; c++ {
+		var (
+			chunkStart = chunkSize * (c - 1)
+			chunkEnd   = chunkSize * (c)
+		)
+		// last chunk may be smaller than the chunk size
+		if c == chunkCount {
+			chunkEnd = len(jobs)
+		}
+		chunks = append(chunks, jobs[chunkStart:chunkEnd])
+	}
+
+	return chunks
+}
+
+// processCronJobList process a cron job list into process messages.
+func processCronJobList(cronJobList []*batchv1beta1.CronJob, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string, withScrubbing bool) ([]model.MessageBody, error) {
+	start := time.Now()
+	cronJobMsgs := make([]*model.CronJob, 0, len(cronJobList))
+
+	for i := 0; i < len(cronJobList); i++ {
+		cj := cronJobList[i]
+		if orchestrator.SkipKubernetesResource(cj.UID, cj.ResourceVersion) {
+			continue
+		}
+
+		cronJobModel := extractCronJob(cj)
+
+		// scrub & generate YAML
+		if withScrubbing {
+			for c := 0; c < len(cj.Spec.JobTemplate.Spec.Template.Spec.InitContainers); c++ {
+				orchestrator.ScrubContainer(&cj.Spec.JobTemplate.Spec.Template.Spec.InitContainers[c], cfg)
+			}
+			for c := 0; c < len(cj.Spec.JobTemplate.Spec.Template.Spec.Containers); c++ {
+				orchestrator.ScrubContainer(&cj.Spec.JobTemplate.Spec.Template.Spec.Containers[c], cfg)
+			}
+		}
+
+		// k8s objects only have json "omitempty" annotations
+		// and marshalling is more performant than YAML
+		jsonCronJob, err := jsoniter.Marshal(cj)
+		if err != nil {
+			log.Debugf("Could not marshal cron job to JSON: %s", err)
+			continue
+		}
+		cronJobModel.Yaml = jsonCronJob
+
+		cronJobMsgs = append(cronJobMsgs, cronJobModel)
+	}
+
+	groupSize := len(cronJobMsgs) / cfg.MaxPerMessage
+	if len(cronJobMsgs)%cfg.MaxPerMessage != 0 {
+		groupSize++
+	}
+	chunked := chunkCronJobs(cronJobMsgs, groupSize, cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		messages = append(messages, &model.CollectorCronJob{
+			ClusterName: clusterName,
+			CronJobs:    chunked[i],
+			GroupId:     groupID,
+			GroupSize:   int32(groupSize),
+			ClusterId:   clusterID,
+		})
+	}
+
+	log.Debugf("Collected & enriched %d out of %d cron jobs in %s", len(cronJobMsgs), len(cronJobList), time.Now().Sub(start))
+	return messages, nil
+}
+
+// chunkCronJobs chunks the given list of cron jobs, honoring the given chunk count and size.
+// The last chunk may be smaller than the others.
+func chunkCronJobs(cronJobs []*model.CronJob, chunkCount, chunkSize int) [][]*model.CronJob {
+	chunks := make([][]*model.CronJob, 0, chunkCount)
+
+	for c := 1; c <= chunkCount; c++ {
+		var (
+			chunkStart = chunkSize * (c - 1)
+			chunkEnd   = chunkSize * (c)
+		)
+		// last chunk may be smaller than the chunk size
+		if c == chunkCount {
+			chunkEnd = len(cronJobs)
+		}
+		chunks = append(chunks, cronJobs[chunkStart:chunkEnd])
+	}
+
+	return chunks
+}
+
+// processNodeList process a node list into process messages.
+func processNodeList(nodeList []*corev1.Node, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string) ([]model.MessageBody, error) {
+	start := time.Now()
+	nodeMsgs := make([]*model.Node, 0, len(nodeList))
+
+	for i := 0; i < len(nodeList); i++ {
+		n := nodeList[i]
+		if orchestrator.SkipKubernetesResource(n.UID, n.ResourceVersion) {
+			continue
+		}
+
+		nodeModel := extractNode(n)
+
+		// k8s objects only have json "omitempty" annotations
+		// and marshalling is more performant than YAML
+		jsonNode, err := jsoniter.Marshal(n)
+		if err != nil {
+			log.Debugf("Could not marshal node to JSON: %s", err)
+			continue
+		}
+		nodeModel.Yaml = jsonNode
+
+		nodeMsgs = append(nodeMsgs, nodeModel)
+	}
+
+	groupSize := len(nodeMsgs) / cfg.MaxPerMessage
+	if len(nodeMsgs)%cfg.MaxPerMessage != 0 {
+		groupSize++
+	}
+	chunked := chunkNodes(nodeMsgs, groupSize, cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		messages = append(messages, &model.CollectorNode{
+			ClusterName: clusterName,
+			Nodes:       chunked[i],
+			GroupId:     groupID,
+			GroupSize:   int32(groupSize),
+			ClusterId:   clusterID,
+		})
+	}
+
+	log.Debugf("Collected & enriched %d out of %d nodes in %s", len(nodeMsgs), len(nodeList), time.Now().Sub(start))
+	return messages, nil
+}
+
+// chunkNodes chunks the given list of nodes, honoring the given chunk count and size.
+// The last chunk may be smaller than the others.
+func chunkNodes(nodes []*model.Node, chunkCount, chunkSize int) [][]*model.Node {
+	chunks := make([][]*model.Node, 0, chunkCount)
+
+	for c := 1; c <= chunkCount; c++ {
+		var (
+			chunkStart = chunkSize * (c - 1)
+			chunkEnd   = chunkSize * (c)
+		)
+		// last chunk may be smaller than the chunk size
+		if c == chunkCount {
+			chunkEnd = len(nodes)
+		}
+		chunks = append(chunks, nodes[chunkStart:chunkEnd])
+	}
+
+	return chunks
+}
+
+// processPersistentVolumeList process a persistent volume list into process messages.
+func processPersistentVolumeList(persistentVolumeList []*corev1.PersistentVolume, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string) ([]model.MessageBody, error) {
+	start := time.Now()
+	persistentVolumeMsgs := make([]*model.PersistentVolume, 0, len(persistentVolumeList))
+
+	for i := 0; i < len(persistentVolumeList); i++ {
+		pv := persistentVolumeList[i]
+		if orchestrator.SkipKubernetesResource(pv.UID, pv.ResourceVersion) {
+			continue
+		}
+
+		persistentVolumeModel := extractPersistentVolume(pv)
+
+		// k8s objects only have json "omitempty" annotations
+		// and marshalling is more performant than YAML
+		jsonPersistentVolume, err := jsoniter.Marshal(pv)
+		if err != nil {
+			log.Debugf("Could not marshal persistent volume to JSON: %s", err)
+			continue
+		}
+		persistentVolumeModel.Yaml = jsonPersistentVolume
+
+		persistentVolumeMsgs = append(persistentVolumeMsgs, persistentVolumeModel)
+	}
+
+	groupSize := len(persistentVolumeMsgs) / cfg.MaxPerMessage
+	if len(persistentVolumeMsgs)%cfg.MaxPerMessage != 0 {
+		groupSize++
+	}
+	chunked := chunkPersistentVolumes(persistentVolumeMsgs, groupSize, cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		messages = append(messages, &model.CollectorPersistentVolume{
+			ClusterName:       clusterName,
+			PersistentVolumes: chunked[i],
+			GroupId:           groupID,
+			GroupSize:         int32(groupSize),
+			ClusterId:         clusterID,
+		})
+	}
+
+	log.Debugf("Collected & enriched %d out of %d persistent volumes in %s", len(persistentVolumeMsgs), len(persistentVolumeList), time.Now().Sub(start))
+	return messages, nil
+}
+
+// chunkPersistentVolumes chunks the given list of persistent volumes, honoring the given chunk count and size.
+// The last chunk may be smaller than the others.
+func chunkPersistentVolumes(persistentVolumes []*model.PersistentVolume, chunkCount, chunkSize int) [][]*model.PersistentVolume {
+	chunks := make([][]*model.PersistentVolume, 0, chunkCount)
+
+	for c := 1; c <= chunkCount; c++ {
+		var (
+			chunkStart = chunkSize * (c - 1)
+			chunkEnd   = chunkSize * (c)
+		)
+		// last chunk may be smaller than the chunk size
+		if c == chunkCount {
+			chunkEnd = len(persistentVolumes)
+		}
+		chunks = append(chunks, persistentVolumes[chunkStart:chunkEnd])
+	}
+
+	return chunks
+}
+
+// processPersistentVolumeClaimList process a persistent volume claim list into process messages.
+func processPersistentVolumeClaimList(persistentVolumeClaimList []*corev1.PersistentVolumeClaim, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string) ([]model.MessageBody, error) {
+	start := time.Now()
+	persistentVolumeClaimMsgs := make([]*model.PersistentVolumeClaim, 0, len(persistentVolumeClaimList))
+
+	for i := 0; i < len(persistentVolumeClaimList); i++ {
+		pvc := persistentVolumeClaimList[i]
+		if orchestrator.SkipKubernetesResource(pvc.UID, pvc.ResourceVersion) {
+			continue
+		}
+
+		persistentVolumeClaimModel := extractPersistentVolumeClaim(pvc)
+
+		// k8s objects only have json "omitempty" annotations
+		// and marshalling is more performant than YAML
+		jsonPersistentVolumeClaim, err := jsoniter.Marshal(pvc)
+		if err != nil {
+			log.Debugf("Could not marshal persistent volume claim to JSON: %s", err)
+			continue
+		}
+		persistentVolumeClaimModel.Yaml = jsonPersistentVolumeClaim
+
+		persistentVolumeClaimMsgs = append(persistentVolumeClaimMsgs, persistentVolumeClaimModel)
+	}
+
+	groupSize := len(persistentVolumeClaimMsgs) / cfg.MaxPerMessage
+	if len(persistentVolumeClaimMsgs)%cfg.MaxPerMessage != 0 {
+		groupSize++
+	}
+	chunked := chunkPersistentVolumeClaims(persistentVolumeClaimMsgs, groupSize, cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		messages = append(messages, &model.CollectorPersistentVolumeClaim{
+			ClusterName:            clusterName,
+			PersistentVolumeClaims: chunked[i],
+			GroupId:                groupID,
+			GroupSize:              int32(groupSize),
+			ClusterId:              clusterID,
+		})
+	}
+
+	log.Debugf("Collected & enriched %d out of %d persistent volume claims in %s", len(persistentVolumeClaimMsgs), len(persistentVolumeClaimList), time.Now().Sub(start))
+	return messages, nil
+}
+
+// chunkPersistentVolumeClaims chunks the given list of persistent volume claims, honoring the given chunk count and size.
+// The last chunk may be smaller than the others.
+func chunkPersistentVolumeClaims(persistentVolumeClaims []*model.PersistentVolumeClaim, chunkCount, chunkSize int) [][]*model.PersistentVolumeClaim {
+	chunks := make([][]*model.PersistentVolumeClaim, 0, chunkCount)
+
+	for c := 1; c <= chunkCount; c++ {
+		var (
+			chunkStart = chunkSize * (c - 1)
+			chunkEnd   = chunkSize * (c)
+		)
+		// last chunk may be smaller than the chunk size
+		if c == chunkCount {
+			chunkEnd = len(persistentVolumeClaims)
+		}
+		chunks = append(chunks, persistentVolumeClaims[chunkStart:chunkEnd])
+	}
+
+	return chunks
+}
@@ -11,7 +11,16 @@ import (
 	"testing"
 
 	model "github.com/DataDog/agent-payload/process"
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestChunkDeployments(t *testing.T) {
@@ -131,3 +140,307 @@ func TestChunkReplicasets(t *testing.T) {
 	actual := chunkReplicaSets(rs, 3, 2)
 	assert.ElementsMatch(t, expected, actual)
 }
+
+func TestChunkNodes(t *testing.T) {
+	nodes := []*model.Node{
+		{Metadata: &model.Metadata{Uid: "1"}},
+		{Metadata: &model.Metadata{Uid: "2"}},
+		{Metadata: &model.Metadata{Uid: "3"}},
+	}
+	expected := [][]*model.Node{
+		{
+			{Metadata: &model.Metadata{Uid: "1"}},
+			{Metadata: &model.Metadata{Uid: "2"}},
+		},
+		{
+			{Metadata: &model.Metadata{Uid: "3"}},
+		},
+	}
+	actual := chunkNodes(nodes, 2, 2)
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func TestChunkCronJobs(t *testing.T) {
+	cronJobs := []*model.CronJob{
+		{Metadata: &model.Metadata{Uid: "1"}},
+		{Metadata: &model.Metadata{Uid: "2"}},
+	}
+	expected := [][]*model.CronJob{
+		{{Metadata: &model.Metadata{Uid: "1"}}},
+		{{Metadata: &model.Metadata{Uid: "2"}}},
+	}
+	actual := chunkCronJobs(cronJobs, 2, 1)
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func TestChunkDaemonSets(t *testing.T) {
+	daemonSets := []*model.DaemonSet{
+		{Metadata: &model.Metadata{Uid: "1"}},
+		{Metadata: &model.Metadata{Uid: "2"}},
+		{Metadata: &model.Metadata{Uid: "3"}},
+	}
+	expected := [][]*model.DaemonSet{
+		{
+			{Metadata: &model.Metadata{Uid: "1"}},
+			{Metadata: &model.Metadata{Uid: "2"}},
+		},
+		{
+			{Metadata: &model.Metadata{Uid: "3"}},
+		},
+	}
+	actual := chunkDaemonSets(daemonSets, 2, 2)
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func TestChunkStatefulSets(t *testing.T) {
+	statefulSets := []*model.StatefulSet{
+		{Metadata: &model.Metadata{Uid: "1"}},
+		{Metadata: &model.Metadata{Uid: "2"}},
+		{Metadata: &model.Metadata{Uid: "3"}},
+	}
+	expected := [][]*model.StatefulSet{
+		{
+			{Metadata: &model.Metadata{Uid: "1"}},
+			{Metadata: &model.Metadata{Uid: "2"}},
+		},
+		{
+			{Metadata: &model.Metadata{Uid: "3"}},
+		},
+	}
+	actual := chunkStatefulSets(statefulSets, 2, 2)
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func TestChunkJobs(t *testing.T) {
+	jobs := []*model.Job{
+		{Metadata: &model.Metadata{Uid: "1"}},
+		{Metadata: &model.Metadata{Uid: "2"}},
+	}
+	expected := [][]*model.Job{
+		{{Metadata: &model.Metadata{Uid: "1"}}},
+		{{Metadata: &model.Metadata{Uid: "2"}}},
+	}
+	actual := chunkJobs(jobs, 2, 1)
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func TestChunkPersistentVolumes(t *testing.T) {
+	persistentVolumes := []*model.PersistentVolume{
+		{Metadata: &model.Metadata{Uid: "1"}},
+		{Metadata: &model.Metadata{Uid: "2"}},
+		{Metadata: &model.Metadata{Uid: "3"}},
+	}
+	expected := [][]*model.PersistentVolume{
+		{
+			{Metadata: &model.Metadata{Uid: "1"}},
+			{Metadata: &model.Metadata{Uid: "2"}},
+		},
+		{
+			{Metadata: &model.Metadata{Uid: "3"}},
+		},
+	}
+	actual := chunkPersistentVolumes(persistentVolumes, 2, 2)
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func TestChunkPersistentVolumeClaims(t *testing.T) {
+	persistentVolumeClaims := []*model.PersistentVolumeClaim{
+		{Metadata: &model.Metadata{Uid: "1"}},
+		{Metadata: &model.Metadata{Uid: "2"}},
+	}
+	expected := [][]*model.PersistentVolumeClaim{
+		{{Metadata: &model.Metadata{Uid: "1"}}},
+		{{Metadata: &model.Metadata{Uid: "2"}}},
+	}
+	actual := chunkPersistentVolumeClaims(persistentVolumeClaims, 2, 1)
+	assert.ElementsMatch(t, expected, actual)
+}
+
+// collectedMessage holds the fields common to the collector messages of all the resource kinds
+type collectedMessage struct {
+	clusterName string
+	clusterID   string
+	groupID     int32
+	groupSize   int32
+	metadata    []*model.Metadata
+	yamls       [][]byte
+}
+
+func TestProcessResourceLists(t *testing.T) {
+	cfg := &config.AgentConfig{MaxPerMessage: 2, Scrubber: config.NewDefaultDataScrubber()}
+	objectMeta := func(uid, version string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: uid, UID: types.UID(uid), ResourceVersion: version}
+	}
+	// the secret in the pod template must be scrubbed from the workloads
+	podTemplate := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Env: []corev1.EnvVar{{Name: "password", Value: "hunter2"}}}},
+		},
+	}
+
+	tests := map[string]struct {
+		process func(uids []string, version string) ([]model.MessageBody, error)
+		read    func(msg model.MessageBody) collectedMessage
+	}{
+		"nodes": {
+			process: func(uids []string, version string) ([]model.MessageBody, error) {
+				var list []*corev1.Node
+				for _, uid := range uids {
+					list = append(list, &corev1.Node{ObjectMeta: objectMeta(uid, version)})
+				}
+				return processNodeList(list, 42, cfg, "cluster", "cluster-id")
+			},
+			read: func(msg model.MessageBody) collectedMessage {
+				m := msg.(*model.CollectorNode)
+				c := collectedMessage{clusterName: m.ClusterName, clusterID: m.ClusterId, groupID: m.GroupId, groupSize: m.GroupSize}
+				for _, r := range m.Nodes {
+					c.metadata, c.yamls = append(c.metadata, r.Metadata), append(c.yamls, r.Yaml)
+				}
+				return c
+			},
+		},
+		"daemon sets": {
+			process: func(uids []string, version string) ([]model.MessageBody, error) {
+				var list []*appsv1.DaemonSet
+				for _, uid := range uids {
+					list = append(list, &appsv1.DaemonSet{ObjectMeta: objectMeta(uid, version), Spec: appsv1.DaemonSetSpec{Template: *podTemplate.DeepCopy()}})
+				}
+				return processDaemonSetList(list, 42, cfg, "cluster", "cluster-id", true)
+			},
+			read: func(msg model.MessageBody) collectedMessage {
+				m := msg.(*model.CollectorDaemonSet)
+				c := collectedMessage{clusterName: m.ClusterName, clusterID: m.ClusterId, groupID: m.GroupId, groupSize: m.GroupSize}
+				for _, r := range m.DaemonSets {
+					c.metadata, c.yamls = append(c.metadata, r.Metadata), append(c.yamls, r.Yaml)
+				}
+				return c
+			},
+		},
+		"stateful sets": {
+			process: func(uids []string, version string) ([]model.MessageBody, error) {
+				var list []*appsv1.StatefulSet
+				for _, uid := range uids {
+					list = append(list, &appsv1.StatefulSet{ObjectMeta: objectMeta(uid, version), Spec: appsv1.StatefulSetSpec{Template: *podTemplate.DeepCopy()}})
+				}
+				return processStatefulSetList(list, 42, cfg, "cluster", "cluster-id", true)
+			},
+			read: func(msg model.MessageBody) collectedMessage {
+				m := msg.(*model.CollectorStatefulSet)
+				c := collectedMessage{clusterName: m.ClusterName, clusterID: m.ClusterId, groupID: m.GroupId, groupSize: m.GroupSize}
+				for _, r := range m.StatefulSets {
+					c.metadata, c.yamls = append(c.metadata, r.Metadata), append(c.yamls, r.Yaml)
+				}
+				return c
+			},
+		},
+		"jobs": {
+			process: func(uids []string, version string) ([]model.MessageBody, error) {
+				var list []*batchv1.Job
+				for _, uid := range uids {
+					list = append(list, &batchv1.Job{ObjectMeta: objectMeta(uid, version), Spec: batchv1.JobSpec{Template: *podTemplate.DeepCopy()}})
+				}
+				return processJobList(list, 42, cfg, "cluster", "cluster-id", true)
+			},
+			read: func(msg model.MessageBody) collectedMessage {
+				m := msg.(*model.CollectorJob)
+				c := collectedMessage{clusterName: m.ClusterName, clusterID: m.ClusterId, groupID: m.GroupId, groupSize: m.GroupSize}
+				for _, r := range m.Jobs {
+					c.metadata, c.yamls = append(c.metadata, r.Metadata), append(c.yamls, r.Yaml)
+				}
+				return c
+			},
+		},
+		"cron jobs": {
+			process: func(uids []string, version string) ([]model.MessageBody, error) {
+				var list []*batchv1beta1.CronJob
+				for _, uid := range uids {
+					list = append(list, &batchv1beta1.CronJob{
+						ObjectMeta: objectMeta(uid, version),
+						Spec: batchv1beta1.CronJobSpec{
+							JobTemplate: batchv1beta1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: *podTemplate.DeepCopy()}},
+						},
+					})
+				}
+				return processCronJobList(list, 42, cfg, "cluster", "cluster-id", true)
+			},
+			read: func(msg model.MessageBody) collectedMessage {
+				m := msg.(*model.CollectorCronJob)
+				c := collectedMessage{clusterName: m.ClusterName, clusterID: m.ClusterId, groupID: m.GroupId, groupSize: m.GroupSize}
+				for _, r := range m.CronJobs {
+					c.metadata, c.yamls = append(c.metadata, r.Metadata), append(c.yamls, r.Yaml)
+				}
+				return c
+			},
+		},
+		"persistent volumes": {
+			process: func(uids []string, version string) ([]model.MessageBody, error) {
+				var list []*corev1.PersistentVolume
+				for _, uid := range uids {
+					list = append(list, &corev1.PersistentVolume{ObjectMeta: objectMeta(uid, version)})
+				}
+				return processPersistentVolumeList(list, 42, cfg, "cluster", "cluster-id")
+			},
+			read: func(msg model.MessageBody) collectedMessage {
+				m := msg.(*model.CollectorPersistentVolume)
+				c := collectedMessage{clusterName: m.ClusterName, clusterID: m.ClusterId, groupID: m.GroupId, groupSize: m.GroupSize}
+				for _, r := range m.PersistentVolumes {
+					c.metadata, c.yamls = append(c.metadata, r.Metadata), append(c.yamls, r.Yaml)
+				}
+				return c
+			},
+		},
+		"persistent volume claims": {
+			process: func(uids []string, version string) ([]model.MessageBody, error) {
+				var list []*corev1.PersistentVolumeClaim
+				for _, uid := range uids {
+					list = append(list, &corev1.PersistentVolumeClaim{ObjectMeta: objectMeta(uid, version)})
+				}
+				return processPersistentVolumeClaimList(list, 42, cfg, "cluster", "cluster-id")
+			},
+			read: func(msg model.MessageBody) collectedMessage {
+				m := msg.(*model.CollectorPersistentVolumeClaim)
+				c := collectedMessage{clusterName: m.ClusterName, clusterID: m.ClusterId, groupID: m.GroupId, groupSize: m.GroupSize}
+				for _, r := range m.PersistentVolumeClaims {
+					c.metadata, c.yamls = append(c.metadata, r.Metadata), append(c.yamls, r.Yaml)
+				}
+				return c
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// the resources are cached by UID, they must be unique across the test cases
+			uids := []string{name + "-1", name + "-2", name + "-3"}
+
+			messages, err := tc.process(uids, "1")
+			require.NoError(t, err)
+			require.Len(t, messages, 2)
+			var collectedUIDs []string
+			for _, msg := range messages {
+				c := tc.read(msg)
+				assert.Equal(t, "cluster", c.clusterName)
+				assert.Equal(t, "cluster-id", c.clusterID)
+				assert.Equal(t, int32(42), c.groupID)
+				assert.Equal(t, int32(2), c.groupSize)
+				for i, metadata := range c.metadata {
+					collectedUIDs = append(collectedUIDs, metadata.Uid)
+					assert.Equal(t, "1", metadata.ResourceVersion)
+					assert.Contains(t, string(c.yamls[i]), metadata.Uid)
+					assert.NotContains(t, string(c.yamls[i]), "hunter2")
+				}
+			}
+			assert.Equal(t, uids, collectedUIDs)
+
+			// the resources which didn't change since they were sent are skipped
+			messages, err = tc.process(uids, "1")
+			require.NoError(t, err)
+			assert.Len(t, messages, 0)
+
+			messages, err = tc.process(uids[:1], "2")
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+			assert.Equal(t, int32(1), tc.read(messages[0]).groupSize)
+			assert.Equal(t, uids[0], tc.read(messages[0]).metadata[0].Uid)
+		})
+	}
+}
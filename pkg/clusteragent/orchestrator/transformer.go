@@ -8,14 +8,26 @@
 package orchestrator
 
 import (
+	"sort"
+	"strings"
+
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/process/util/orchestrator"
 
 	v1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// nodeRoleLabelPrefix is the prefix of the labels holding the node roles, e.g. node-role.kubernetes.io/master
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+	// nodeRoleLabel is the legacy label holding the node role
+	nodeRoleLabel = "kubernetes.io/role"
+)
+
 func extractDeployment(d *v1.Deployment) *model.Deployment {
 	deploy := model.Deployment{
 		Metadata: orchestrator.ExtractMetadata(&d.ObjectMeta),
@@ -194,3 +206,437 @@ func extractDeploymentConditionMessage(conditions []v1.DeploymentCondition) stri
 	}
 	return ""
 }
+
+func extractDaemonSet(ds *v1.DaemonSet) *model.DaemonSet {
+	daemonSet := model.DaemonSet{
+		Metadata: orchestrator.ExtractMetadata(&ds.ObjectMeta),
+		Spec: &model.DaemonSetSpec{
+			MinReadySeconds:    ds.Spec.MinReadySeconds,
+			DeploymentStrategy: string(ds.Spec.UpdateStrategy.Type),
+		},
+		Status: &model.DaemonSetStatus{
+			CurrentNumberScheduled: ds.Status.CurrentNumberScheduled,
+			NumberMisscheduled:     ds.Status.NumberMisscheduled,
+			DesiredNumberScheduled: ds.Status.DesiredNumberScheduled,
+			NumberReady:            ds.Status.NumberReady,
+			UpdatedNumberScheduled: ds.Status.UpdatedNumberScheduled,
+			NumberAvailable:        ds.Status.NumberAvailable,
+			NumberUnavailable:      ds.Status.NumberUnavailable,
+		},
+	}
+	// spec
+	if ds.Spec.UpdateStrategy.Type == v1.RollingUpdateDaemonSetStrategyType && ds.Spec.UpdateStrategy.RollingUpdate != nil {
+		if ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable != nil {
+			daemonSet.Spec.MaxUnavailable = ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable.String()
+		}
+	}
+	if ds.Spec.RevisionHistoryLimit != nil {
+		daemonSet.Spec.RevisionHistoryLimit = *ds.Spec.RevisionHistoryLimit
+	}
+	if ds.Spec.Selector != nil {
+		daemonSet.Spec.Selectors = extractLabelSelector(ds.Spec.Selector)
+	}
+
+	return &daemonSet
+}
+
+func extractStatefulSet(sts *v1.StatefulSet) *model.StatefulSet {
+	statefulSet := model.StatefulSet{
+		Metadata: orchestrator.ExtractMetadata(&sts.ObjectMeta),
+		Spec: &model.StatefulSetSpec{
+			DesiredReplicas:     1, // default
+			ServiceName:         sts.Spec.ServiceName,
+			PodManagementPolicy: string(sts.Spec.PodManagementPolicy),
+			UpdateStrategy:      string(sts.Spec.UpdateStrategy.Type),
+		},
+		Status: &model.StatefulSetStatus{
+			Replicas:        sts.Status.Replicas,
+			ReadyReplicas:   sts.Status.ReadyReplicas,
+			CurrentReplicas: sts.Status.CurrentReplicas,
+			UpdatedReplicas: sts.Status.UpdatedReplicas,
+		},
+	}
+	// spec
+	if sts.Spec.Replicas != nil {
+		statefulSet.Spec.DesiredReplicas = *sts.Spec.Replicas
+	}
+	if sts.Spec.UpdateStrategy.RollingUpdate != nil && sts.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+		statefulSet.Spec.Partition = *sts.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+	if sts.Spec.Selector != nil {
+		statefulSet.Spec.Selectors = extractLabelSelector(sts.Spec.Selector)
+	}
+
+	return &statefulSet
+}
+
+func extractJob(j *batchv1.Job) *model.Job {
+	job := model.Job{
+		Metadata: orchestrator.ExtractMetadata(&j.ObjectMeta),
+		Spec:     &model.JobSpec{},
+		Status: &model.JobStatus{
+			Active:           j.Status.Active,
+			Succeeded:        j.Status.Succeeded,
+			Failed:           j.Status.Failed,
+			ConditionMessage: extractJobConditionMessage(j.Status.Conditions),
+		},
+	}
+	// spec
+	if j.Spec.Parallelism != nil {
+		job.Spec.Parallelism = *j.Spec.Parallelism
+	}
+	if j.Spec.Completions != nil {
+		job.Spec.Completions = *j.Spec.Completions
+	}
+	if j.Spec.ActiveDeadlineSeconds != nil {
+		job.Spec.ActiveDeadlineSeconds = *j.Spec.ActiveDeadlineSeconds
+	}
+	if j.Spec.BackoffLimit != nil {
+		job.Spec.BackoffLimit = *j.Spec.BackoffLimit
+	}
+	if j.Spec.ManualSelector != nil {
+		job.Spec.ManualSelector = *j.Spec.ManualSelector
+	}
+	if j.Spec.Selector != nil {
+		job.Spec.Selectors = extractLabelSelector(j.Spec.Selector)
+	}
+
+	// status
+	if j.Status.StartTime != nil {
+		job.Status.StartTime = j.Status.StartTime.Unix()
+	}
+	if j.Status.CompletionTime != nil {
+		job.Status.CompletionTime = j.Status.CompletionTime.Unix()
+	}
+
+	return &job
+}
+
+// extractJobConditionMessage returns the message of the failure condition of a job, if any.
+func extractJobConditionMessage(conditions []batchv1.JobCondition) string {
+	for _, c := range conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue && c.Message != "" {
+			return c.Message
+		}
+	}
+	return ""
+}
+
+func extractCronJob(cj *batchv1beta1.CronJob) *model.CronJob {
+	cronJob := model.CronJob{
+		Metadata: orchestrator.ExtractMetadata(&cj.ObjectMeta),
+		Spec: &model.CronJobSpec{
+			Schedule:          cj.Spec.Schedule,
+			ConcurrencyPolicy: string(cj.Spec.ConcurrencyPolicy),
+		},
+		Status: &model.CronJobStatus{},
+	}
+	// spec
+	if cj.Spec.StartingDeadlineSeconds != nil {
+		cronJob.Spec.StartingDeadlineSeconds = *cj.Spec.StartingDeadlineSeconds
+	}
+	if cj.Spec.Suspend != nil {
+		cronJob.Spec.Suspend = *cj.Spec.Suspend
+	}
+	if cj.Spec.SuccessfulJobsHistoryLimit != nil {
+		cronJob.Spec.SuccessfulJobsHistoryLimit = *cj.Spec.SuccessfulJobsHistoryLimit
+	}
+	if cj.Spec.FailedJobsHistoryLimit != nil {
+		cronJob.Spec.FailedJobsHistoryLimit = *cj.Spec.FailedJobsHistoryLimit
+	}
+
+	// status
+	for _, job := range cj.Status.Active {
+		cronJob.Status.Active = append(cronJob.Status.Active, extractObjectReference(&job))
+	}
+	if cj.Status.LastScheduleTime != nil {
+		cronJob.Status.LastScheduleTime = cj.Status.LastScheduleTime.Unix()
+	}
+
+	return &cronJob
+}
+
+func extractObjectReference(ref *corev1.ObjectReference) *model.ObjectReference {
+	return &model.ObjectReference{
+		Kind:            ref.Kind,
+		Namespace:       ref.Namespace,
+		Name:            ref.Name,
+		Uid:             string(ref.UID),
+		ApiVersion:      ref.APIVersion,
+		ResourceVersion: ref.ResourceVersion,
+		FieldPath:       ref.FieldPath,
+	}
+}
+
+func extractNode(n *corev1.Node) *model.Node {
+	node := model.Node{
+		Metadata:      orchestrator.ExtractMetadata(&n.ObjectMeta),
+		PodCIDR:       n.Spec.PodCIDR,
+		PodCIDRs:      n.Spec.PodCIDRs,
+		Unschedulable: n.Spec.Unschedulable,
+		ProviderID:    n.Spec.ProviderID,
+		Roles:         findNodeRoles(n.Labels),
+		Status: &model.NodeStatus{
+			Capacity:                extractResourceList(n.Status.Capacity),
+			Allocatable:             extractResourceList(n.Status.Allocatable),
+			Status:                  computeNodeStatus(n),
+			KubeletVersion:          n.Status.NodeInfo.KubeletVersion,
+			KubeProxyVersion:        n.Status.NodeInfo.KubeProxyVersion,
+			OperatingSystem:         n.Status.NodeInfo.OperatingSystem,
+			Architecture:            n.Status.NodeInfo.Architecture,
+			KernelVersion:           n.Status.NodeInfo.KernelVersion,
+			OsImage:                 n.Status.NodeInfo.OSImage,
+			ContainerRuntimeVersion: n.Status.NodeInfo.ContainerRuntimeVersion,
+		},
+	}
+
+	// spec
+	for _, taint := range n.Spec.Taints {
+		t := &model.Taint{
+			Key:    taint.Key,
+			Value:  taint.Value,
+			Effect: string(taint.Effect),
+		}
+		if taint.TimeAdded != nil {
+			t.TimeAdded = taint.TimeAdded.Unix()
+		}
+		node.Taints = append(node.Taints, t)
+	}
+
+	// status
+	if len(n.Status.Addresses) > 0 {
+		node.Status.NodeAddresses = make(map[string]string, len(n.Status.Addresses))
+		for _, address := range n.Status.Addresses {
+			node.Status.NodeAddresses[string(address.Type)] = address.Address
+		}
+	}
+	for _, condition := range n.Status.Conditions {
+		c := &model.NodeCondition{
+			Type:    string(condition.Type),
+			Status:  string(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		}
+		if !condition.LastTransitionTime.IsZero() {
+			c.LastTransitionTime = condition.LastTransitionTime.Unix()
+		}
+		node.Status.Conditions = append(node.Status.Conditions, c)
+	}
+
+	return &node
+}
+
+// computeNodeStatus returns the status of a node the way kubectl displays it,
+// e.g. "Ready,SchedulingDisabled".
+func computeNodeStatus(n *corev1.Node) string {
+	var status []string
+	for _, condition := range n.Status.Conditions {
+		if condition.Type != corev1.NodeReady {
+			continue
+		}
+		if condition.Status == corev1.ConditionTrue {
+			status = append(status, string(condition.Type))
+		} else {
+			status = append(status, "Not"+string(condition.Type))
+		}
+	}
+	if len(status) == 0 {
+		status = append(status, "Unknown")
+	}
+	if n.Spec.Unschedulable {
+		status = append(status, "SchedulingDisabled")
+	}
+	return strings.Join(status, ",")
+}
+
+// findNodeRoles returns the roles of a node from its node-role.kubernetes.io/<role>
+// and kubernetes.io/role labels.
+func findNodeRoles(nodeLabels map[string]string) []string {
+	roles := map[string]struct{}{}
+	for k, v := range nodeLabels {
+		switch {
+		case strings.HasPrefix(k, nodeRoleLabelPrefix):
+			if role := strings.TrimPrefix(k, nodeRoleLabelPrefix); role != "" {
+				roles[role] = struct{}{}
+			}
+		case k == nodeRoleLabel && v != "":
+			roles[v] = struct{}{}
+		}
+	}
+	if len(roles) == 0 {
+		return nil
+	}
+	roleList := make([]string, 0, len(roles))
+	for role := range roles {
+		roleList = append(roleList, role)
+	}
+	sort.Strings(roleList)
+	return roleList
+}
+
+// extractResourceList converts a list of resource quantities, CPU is
+// expressed in millicores, the other resources in their base unit.
+func extractResourceList(rl corev1.ResourceList) map[string]int64 {
+	if len(rl) == 0 {
+		return nil
+	}
+	resources := make(map[string]int64, len(rl))
+	for name, quantity := range rl {
+		if name == corev1.ResourceCPU {
+			resources[name.String()] = quantity.MilliValue()
+		} else {
+			resources[name.String()] = quantity.Value()
+		}
+	}
+	return resources
+}
+
+func extractPersistentVolume(pv *corev1.PersistentVolume) *model.PersistentVolume {
+	persistentVolume := model.PersistentVolume{
+		Metadata: orchestrator.ExtractMetadata(&pv.ObjectMeta),
+		Spec: &model.PersistentVolumeSpec{
+			Capacity:                      extractResourceList(pv.Spec.Capacity),
+			PersistentVolumeType:          extractPersistentVolumeType(&pv.Spec.PersistentVolumeSource),
+			AccessModes:                   extractAccessModes(pv.Spec.AccessModes),
+			PersistentVolumeReclaimPolicy: string(pv.Spec.PersistentVolumeReclaimPolicy),
+			StorageClassName:              pv.Spec.StorageClassName,
+			MountOptions:                  pv.Spec.MountOptions,
+		},
+		Status: &model.PersistentVolumeStatus{
+			Phase:   string(pv.Status.Phase),
+			Message: pv.Status.Message,
+			Reason:  pv.Status.Reason,
+		},
+	}
+
+	if pv.Spec.ClaimRef != nil {
+		persistentVolume.Spec.ClaimRef = extractObjectReference(pv.Spec.ClaimRef)
+	}
+	if pv.Spec.VolumeMode != nil {
+		persistentVolume.Spec.VolumeMode = string(*pv.Spec.VolumeMode)
+	}
+
+	return &persistentVolume
+}
+
+// extractPersistentVolumeType returns the name of the volume source backing a persistent volume.
+func extractPersistentVolumeType(src *corev1.PersistentVolumeSource) string {
+	switch {
+	case src.GCEPersistentDisk != nil:
+		return "GCEPersistentDisk"
+	case src.AWSElasticBlockStore != nil:
+		return "AWSElasticBlockStore"
+	case src.AzureDisk != nil:
+		return "AzureDisk"
+	case src.AzureFile != nil:
+		return "AzureFile"
+	case src.CSI != nil:
+		return "CSI"
+	case src.HostPath != nil:
+		return "HostPath"
+	case src.Local != nil:
+		return "Local"
+	case src.NFS != nil:
+		return "NFS"
+	case src.ISCSI != nil:
+		return "ISCSI"
+	case src.FC != nil:
+		return "FC"
+	case src.RBD != nil:
+		return "RBD"
+	case src.CephFS != nil:
+		return "CephFS"
+	case src.Cinder != nil:
+		return "Cinder"
+	case src.Glusterfs != nil:
+		return "Glusterfs"
+	case src.FlexVolume != nil:
+		return "FlexVolume"
+	case src.VsphereVolume != nil:
+		return "VsphereVolume"
+	case src.PortworxVolume != nil:
+		return "PortworxVolume"
+	case src.ScaleIO != nil:
+		return "ScaleIO"
+	case src.StorageOS != nil:
+		return "StorageOS"
+	case src.Quobyte != nil:
+		return "Quobyte"
+	case src.PhotonPersistentDisk != nil:
+		return "PhotonPersistentDisk"
+	case src.Flocker != nil:
+		return "Flocker"
+	}
+	return ""
+}
+
+func extractAccessModes(modes []corev1.PersistentVolumeAccessMode) []string {
+	if len(modes) == 0 {
+		return nil
+	}
+	accessModes := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		accessModes = append(accessModes, string(mode))
+	}
+	return accessModes
+}
+
+func extractPersistentVolumeClaim(pvc *corev1.PersistentVolumeClaim) *model.PersistentVolumeClaim {
+	persistentVolumeClaim := model.PersistentVolumeClaim{
+		Metadata: orchestrator.ExtractMetadata(&pvc.ObjectMeta),
+		Spec: &model.PersistentVolumeClaimSpec{
+			AccessModes: extractAccessModes(pvc.Spec.AccessModes),
+			VolumeName:  pvc.Spec.VolumeName,
+		},
+		Status: &model.PersistentVolumeClaimStatus{
+			Phase:       string(pvc.Status.Phase),
+			AccessModes: extractAccessModes(pvc.Status.AccessModes),
+			Capacity:    extractResourceList(pvc.Status.Capacity),
+		},
+	}
+
+	// spec
+	if len(pvc.Spec.Resources.Limits) > 0 || len(pvc.Spec.Resources.Requests) > 0 {
+		persistentVolumeClaim.Spec.Resources = &model.ResourceRequirements{
+			Limits:   extractResourceList(pvc.Spec.Resources.Limits),
+			Requests: extractResourceList(pvc.Spec.Resources.Requests),
+		}
+	}
+	if pvc.Spec.Selector != nil {
+		persistentVolumeClaim.Spec.Selector = extractLabelSelector(pvc.Spec.Selector)
+	}
+	if pvc.Spec.StorageClassName != nil {
+		persistentVolumeClaim.Spec.StorageClassName = *pvc.Spec.StorageClassName
+	}
+	if pvc.Spec.VolumeMode != nil {
+		persistentVolumeClaim.Spec.VolumeMode = string(*pvc.Spec.VolumeMode)
+	}
+	if pvc.Spec.DataSource != nil {
+		persistentVolumeClaim.Spec.DataSource = &model.TypedLocalObjectReference{
+			Kind: pvc.Spec.DataSource.Kind,
+			Name: pvc.Spec.DataSource.Name,
+		}
+		if pvc.Spec.DataSource.APIGroup != nil {
+			persistentVolumeClaim.Spec.DataSource.ApiGroup = *pvc.Spec.DataSource.APIGroup
+		}
+	}
+
+	// status
+	for _, condition := range pvc.Status.Conditions {
+		c := &model.PersistentVolumeClaimCondition{
+			Type:    string(condition.Type),
+			Status:  string(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		}
+		if !condition.LastProbeTime.IsZero() {
+			c.LastProbeTime = condition.LastProbeTime.Unix()
+		}
+		if !condition.LastTransitionTime.IsZero() {
+			c.LastTransitionTime = condition.LastTransitionTime.Unix()
+		}
+		persistentVolumeClaim.Status.Conditions = append(persistentVolumeClaim.Status.Conditions, c)
+	}
+
+	return &persistentVolumeClaim
+}
@@ -16,7 +16,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -563,3 +566,622 @@ func TestExtractService(t *testing.T) {
 		assert.Equal(t, &test.expected, extractService(&test.input))
 	}
 }
+
+func TestExtractDaemonSet(t *testing.T) {
+	timestamp := metav1.NewTime(time.Date(2014, time.January, 15, 0, 0, 0, 0, time.UTC)) // 1389744000
+	testInt32 := int32(2)
+	testIntorStr := intstr.FromString("1%")
+	tests := map[string]struct {
+		input    v1.DaemonSet
+		expected model.DaemonSet
+	}{
+		"full ds": {
+			input: v1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{
+					UID:               types.UID("e42e5adc-0749-11e8-a2b8-000c29dea4f6"),
+					Name:              "daemonset",
+					Namespace:         "namespace",
+					CreationTimestamp: timestamp,
+					ResourceVersion:   "1234",
+				},
+				Spec: v1.DaemonSetSpec{
+					MinReadySeconds:      10,
+					RevisionHistoryLimit: &testInt32,
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app": "test-ds",
+						},
+					},
+					UpdateStrategy: v1.DaemonSetUpdateStrategy{
+						Type: v1.RollingUpdateDaemonSetStrategyType,
+						RollingUpdate: &v1.RollingUpdateDaemonSet{
+							MaxUnavailable: &testIntorStr,
+						},
+					},
+				},
+				Status: v1.DaemonSetStatus{
+					CurrentNumberScheduled: 3,
+					NumberMisscheduled:     1,
+					DesiredNumberScheduled: 3,
+					NumberReady:            2,
+					UpdatedNumberScheduled: 3,
+					NumberAvailable:        2,
+					NumberUnavailable:      1,
+				},
+			}, expected: model.DaemonSet{
+				Metadata: &model.Metadata{
+					Name:              "daemonset",
+					Namespace:         "namespace",
+					Uid:               "e42e5adc-0749-11e8-a2b8-000c29dea4f6",
+					CreationTimestamp: 1389744000,
+					ResourceVersion:   "1234",
+				},
+				Spec: &model.DaemonSetSpec{
+					Selectors: []*model.LabelSelectorRequirement{
+						{
+							Key:      "app",
+							Operator: "In",
+							Values:   []string{"test-ds"},
+						},
+					},
+					DeploymentStrategy:   "RollingUpdate",
+					MaxUnavailable:       "1%",
+					MinReadySeconds:      10,
+					RevisionHistoryLimit: 2,
+				},
+				Status: &model.DaemonSetStatus{
+					CurrentNumberScheduled: 3,
+					NumberMisscheduled:     1,
+					DesiredNumberScheduled: 3,
+					NumberReady:            2,
+					UpdatedNumberScheduled: 3,
+					NumberAvailable:        2,
+					NumberUnavailable:      1,
+				},
+			},
+		},
+		"empty ds": {input: v1.DaemonSet{}, expected: model.DaemonSet{Metadata: &model.Metadata{}, Spec: &model.DaemonSetSpec{}, Status: &model.DaemonSetStatus{}}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, &tc.expected, extractDaemonSet(&tc.input))
+		})
+	}
+}
+
+func TestExtractStatefulSet(t *testing.T) {
+	testInt32 := int32(2)
+	tests := map[string]struct {
+		input    v1.StatefulSet
+		expected model.StatefulSet
+	}{
+		"full sts": {
+			input: v1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "sts",
+					Namespace: "namespace",
+				},
+				Spec: v1.StatefulSetSpec{
+					Replicas:            &testInt32,
+					ServiceName:         "service",
+					PodManagementPolicy: v1.ParallelPodManagement,
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app": "test-sts",
+						},
+					},
+					UpdateStrategy: v1.StatefulSetUpdateStrategy{
+						Type: v1.RollingUpdateStatefulSetStrategyType,
+						RollingUpdate: &v1.RollingUpdateStatefulSetStrategy{
+							Partition: &testInt32,
+						},
+					},
+				},
+				Status: v1.StatefulSetStatus{
+					Replicas:        2,
+					ReadyReplicas:   1,
+					CurrentReplicas: 2,
+					UpdatedReplicas: 1,
+				},
+			}, expected: model.StatefulSet{
+				Metadata: &model.Metadata{
+					Name:      "sts",
+					Namespace: "namespace",
+				},
+				Spec: &model.StatefulSetSpec{
+					DesiredReplicas: 2,
+					Selectors: []*model.LabelSelectorRequirement{
+						{
+							Key:      "app",
+							Operator: "In",
+							Values:   []string{"test-sts"},
+						},
+					},
+					ServiceName:         "service",
+					PodManagementPolicy: "Parallel",
+					UpdateStrategy:      "RollingUpdate",
+					Partition:           2,
+				},
+				Status: &model.StatefulSetStatus{
+					Replicas:        2,
+					ReadyReplicas:   1,
+					CurrentReplicas: 2,
+					UpdatedReplicas: 1,
+				},
+			},
+		},
+		"empty sts": {input: v1.StatefulSet{}, expected: model.StatefulSet{Metadata: &model.Metadata{}, Spec: &model.StatefulSetSpec{DesiredReplicas: 1}, Status: &model.StatefulSetStatus{}}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, &tc.expected, extractStatefulSet(&tc.input))
+		})
+	}
+}
+
+func TestExtractJob(t *testing.T) {
+	timestamp := metav1.NewTime(time.Date(2014, time.January, 15, 0, 0, 0, 0, time.UTC)) // 1389744000
+	testInt32 := int32(2)
+	testInt64 := int64(300)
+	tests := map[string]struct {
+		input    batchv1.Job
+		expected model.Job
+	}{
+		"failed job": {
+			input: batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "job",
+					Namespace: "namespace",
+				},
+				Spec: batchv1.JobSpec{
+					Parallelism:           &testInt32,
+					Completions:           &testInt32,
+					ActiveDeadlineSeconds: &testInt64,
+					BackoffLimit:          &testInt32,
+				},
+				Status: batchv1.JobStatus{
+					StartTime: &timestamp,
+					Failed:    1,
+					Conditions: []batchv1.JobCondition{
+						{
+							Type:    batchv1.JobComplete,
+							Status:  corev1.ConditionFalse,
+							Message: "foo",
+						},
+						{
+							Type:    batchv1.JobFailed,
+							Status:  corev1.ConditionTrue,
+							Reason:  "BackoffLimitExceeded",
+							Message: "Job has reached the specified backoff limit",
+						},
+					},
+				},
+			}, expected: model.Job{
+				Metadata: &model.Metadata{
+					Name:      "job",
+					Namespace: "namespace",
+				},
+				Spec: &model.JobSpec{
+					Parallelism:           2,
+					Completions:           2,
+					ActiveDeadlineSeconds: 300,
+					BackoffLimit:          2,
+				},
+				Status: &model.JobStatus{
+					ConditionMessage: "Job has reached the specified backoff limit",
+					StartTime:        1389744000,
+					Failed:           1,
+				},
+			},
+		},
+		"completed job": {
+			input: batchv1.Job{
+				Status: batchv1.JobStatus{
+					StartTime:      &timestamp,
+					CompletionTime: &timestamp,
+					Succeeded:      1,
+					Conditions: []batchv1.JobCondition{
+						{
+							Type:   batchv1.JobComplete,
+							Status: corev1.ConditionTrue,
+						},
+					},
+				},
+			}, expected: model.Job{
+				Metadata: &model.Metadata{},
+				Spec:     &model.JobSpec{},
+				Status: &model.JobStatus{
+					StartTime:      1389744000,
+					CompletionTime: 1389744000,
+					Succeeded:      1,
+				},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, &tc.expected, extractJob(&tc.input))
+		})
+	}
+}
+
+func TestExtractCronJob(t *testing.T) {
+	timestamp := metav1.NewTime(time.Date(2014, time.January, 15, 0, 0, 0, 0, time.UTC)) // 1389744000
+	testInt32 := int32(3)
+	testInt64 := int64(120)
+	suspend := true
+	tests := map[string]struct {
+		input    batchv1beta1.CronJob
+		expected model.CronJob
+	}{
+		"full cron job": {
+			input: batchv1beta1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cronjob",
+					Namespace: "namespace",
+				},
+				Spec: batchv1beta1.CronJobSpec{
+					Schedule:                   "*/5 * * * *",
+					StartingDeadlineSeconds:    &testInt64,
+					ConcurrencyPolicy:          batchv1beta1.ForbidConcurrent,
+					Suspend:                    &suspend,
+					SuccessfulJobsHistoryLimit: &testInt32,
+					FailedJobsHistoryLimit:     &testInt32,
+				},
+				Status: batchv1beta1.CronJobStatus{
+					Active: []corev1.ObjectReference{
+						{
+							Kind:            "Job",
+							Namespace:       "namespace",
+							Name:            "cronjob-1389744000",
+							UID:             types.UID("b2c6e9a5-0749-11e8-a2b8-000c29dea4f6"),
+							APIVersion:      "batch/v1",
+							ResourceVersion: "220021511",
+						},
+					},
+					LastScheduleTime: &timestamp,
+				},
+			}, expected: model.CronJob{
+				Metadata: &model.Metadata{
+					Name:      "cronjob",
+					Namespace: "namespace",
+				},
+				Spec: &model.CronJobSpec{
+					Schedule:                   "*/5 * * * *",
+					StartingDeadlineSeconds:    120,
+					ConcurrencyPolicy:          "Forbid",
+					Suspend:                    true,
+					SuccessfulJobsHistoryLimit: 3,
+					FailedJobsHistoryLimit:     3,
+				},
+				Status: &model.CronJobStatus{
+					Active: []*model.ObjectReference{
+						{
+							Kind:            "Job",
+							Namespace:       "namespace",
+							Name:            "cronjob-1389744000",
+							Uid:             "b2c6e9a5-0749-11e8-a2b8-000c29dea4f6",
+							ApiVersion:      "batch/v1",
+							ResourceVersion: "220021511",
+						},
+					},
+					LastScheduleTime: 1389744000,
+				},
+			},
+		},
+		"empty cron job": {input: batchv1beta1.CronJob{}, expected: model.CronJob{Metadata: &model.Metadata{}, Spec: &model.CronJobSpec{}, Status: &model.CronJobStatus{}}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, &tc.expected, extractCronJob(&tc.input))
+		})
+	}
+}
+
+func TestExtractNode(t *testing.T) {
+	timestamp := metav1.NewTime(time.Date(2014, time.January, 15, 0, 0, 0, 0, time.UTC)) // 1389744000
+	tests := map[string]struct {
+		input    corev1.Node
+		expected model.Node
+	}{
+		"full node": {
+			input: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "node",
+					Labels: map[string]string{
+						"node-role.kubernetes.io/master": "",
+					},
+				},
+				Spec: corev1.NodeSpec{
+					PodCIDR:       "10.0.0.0/24",
+					PodCIDRs:      []string{"10.0.0.0/24"},
+					ProviderID:    "aws:///us-east-1b/i-0123456789abcdef0",
+					Unschedulable: true,
+					Taints: []corev1.Taint{
+						{
+							Key:       "node-role.kubernetes.io/master",
+							Effect:    corev1.TaintEffectNoSchedule,
+							TimeAdded: &timestamp,
+						},
+					},
+				},
+				Status: corev1.NodeStatus{
+					Capacity: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("64M"),
+						corev1.ResourcePods:   resource.MustParse("110"),
+					},
+					Allocatable: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1500m"),
+						corev1.ResourceMemory: resource.MustParse("32M"),
+						corev1.ResourcePods:   resource.MustParse("110"),
+					},
+					Addresses: []corev1.NodeAddress{
+						{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+						{Type: corev1.NodeHostName, Address: "node"},
+					},
+					Conditions: []corev1.NodeCondition{
+						{
+							Type:               corev1.NodeReady,
+							Status:             corev1.ConditionTrue,
+							LastTransitionTime: timestamp,
+							Reason:             "KubeletReady",
+							Message:            "kubelet is posting ready status",
+						},
+					},
+					NodeInfo: corev1.NodeSystemInfo{
+						KernelVersion:           "4.19.0",
+						OSImage:                 "Container-Optimized OS",
+						ContainerRuntimeVersion: "docker://19.3.1",
+						KubeletVersion:          "v1.16.2",
+						KubeProxyVersion:        "v1.16.2",
+						OperatingSystem:         "linux",
+						Architecture:            "amd64",
+					},
+				},
+			}, expected: model.Node{
+				Metadata: &model.Metadata{
+					Name:   "node",
+					Labels: []string{"node-role.kubernetes.io/master:"},
+				},
+				PodCIDR:       "10.0.0.0/24",
+				PodCIDRs:      []string{"10.0.0.0/24"},
+				ProviderID:    "aws:///us-east-1b/i-0123456789abcdef0",
+				Unschedulable: true,
+				Roles:         []string{"master"},
+				Taints: []*model.Taint{
+					{
+						Key:       "node-role.kubernetes.io/master",
+						Effect:    "NoSchedule",
+						TimeAdded: 1389744000,
+					},
+				},
+				Status: &model.NodeStatus{
+					Capacity: map[string]int64{
+						"cpu":    2000,
+						"memory": 64000000,
+						"pods":   110,
+					},
+					Allocatable: map[string]int64{
+						"cpu":    1500,
+						"memory": 32000000,
+						"pods":   110,
+					},
+					NodeAddresses: map[string]string{
+						"InternalIP": "10.0.0.1",
+						"Hostname":   "node",
+					},
+					Status: "Ready,SchedulingDisabled",
+					Conditions: []*model.NodeCondition{
+						{
+							Type:               "Ready",
+							Status:             "True",
+							LastTransitionTime: 1389744000,
+							Reason:             "KubeletReady",
+							Message:            "kubelet is posting ready status",
+						},
+					},
+					KubeletVersion:          "v1.16.2",
+					KubeProxyVersion:        "v1.16.2",
+					OperatingSystem:         "linux",
+					Architecture:            "amd64",
+					KernelVersion:           "4.19.0",
+					OsImage:                 "Container-Optimized OS",
+					ContainerRuntimeVersion: "docker://19.3.1",
+				},
+			},
+		},
+		"empty node": {input: corev1.Node{}, expected: model.Node{Metadata: &model.Metadata{}, Status: &model.NodeStatus{Status: "Unknown"}}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, &tc.expected, extractNode(&tc.input))
+		})
+	}
+}
+
+func TestComputeNodeStatus(t *testing.T) {
+	for nb, tc := range []struct {
+		node   corev1.Node
+		status string
+	}{
+		{
+			node:   corev1.Node{},
+			status: "Unknown",
+		}, {
+			node: corev1.Node{
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{
+						{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+						{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+					},
+				},
+			},
+			status: "Ready",
+		}, {
+			node: corev1.Node{
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{
+						{Type: corev1.NodeReady, Status: corev1.ConditionUnknown},
+					},
+				},
+			},
+			status: "NotReady",
+		}, {
+			node: corev1.Node{
+				Spec: corev1.NodeSpec{Unschedulable: true},
+			},
+			status: "Unknown,SchedulingDisabled",
+		},
+	} {
+		t.Run(fmt.Sprintf("case %d", nb), func(t *testing.T) {
+			assert.Equal(t, tc.status, computeNodeStatus(&tc.node))
+		})
+	}
+}
+
+func TestFindNodeRoles(t *testing.T) {
+	assert.Nil(t, findNodeRoles(map[string]string{"foo": "bar"}))
+	assert.Equal(t, []string{"master"}, findNodeRoles(map[string]string{"node-role.kubernetes.io/master": ""}))
+	assert.Equal(t, []string{"ingress", "master", "worker"}, findNodeRoles(map[string]string{
+		"node-role.kubernetes.io/master":  "",
+		"node-role.kubernetes.io/ingress": "",
+		"node-role.kubernetes.io/":        "",
+		"kubernetes.io/role":              "worker",
+	}))
+}
+
+func TestExtractPersistentVolume(t *testing.T) {
+	filesystem := corev1.PersistentVolumeFilesystem
+	tests := map[string]struct {
+		input    corev1.PersistentVolume
+		expected model.PersistentVolume
+	}{
+		"full pv": {
+			input: corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "pv",
+				},
+				Spec: corev1.PersistentVolumeSpec{
+					Capacity: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("2Gi"),
+					},
+					PersistentVolumeSource: corev1.PersistentVolumeSource{
+						GCEPersistentDisk: &corev1.GCEPersistentDiskVolumeSource{
+							PDName: "disk",
+						},
+					},
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					ClaimRef: &corev1.ObjectReference{
+						Kind:      "PersistentVolumeClaim",
+						Namespace: "namespace",
+						Name:      "pvc",
+					},
+					PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+					StorageClassName:              "standard",
+					MountOptions:                  []string{"ro"},
+					VolumeMode:                    &filesystem,
+				},
+				Status: corev1.PersistentVolumeStatus{
+					Phase: corev1.VolumeBound,
+				},
+			}, expected: model.PersistentVolume{
+				Metadata: &model.Metadata{
+					Name: "pv",
+				},
+				Spec: &model.PersistentVolumeSpec{
+					Capacity:             map[string]int64{"storage": 2147483648},
+					PersistentVolumeType: "GCEPersistentDisk",
+					AccessModes:          []string{"ReadWriteOnce"},
+					ClaimRef: &model.ObjectReference{
+						Kind:      "PersistentVolumeClaim",
+						Namespace: "namespace",
+						Name:      "pvc",
+					},
+					PersistentVolumeReclaimPolicy: "Retain",
+					StorageClassName:              "standard",
+					MountOptions:                  []string{"ro"},
+					VolumeMode:                    "Filesystem",
+				},
+				Status: &model.PersistentVolumeStatus{
+					Phase: "Bound",
+				},
+			},
+		},
+		"empty pv": {input: corev1.PersistentVolume{}, expected: model.PersistentVolume{Metadata: &model.Metadata{}, Spec: &model.PersistentVolumeSpec{}, Status: &model.PersistentVolumeStatus{}}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, &tc.expected, extractPersistentVolume(&tc.input))
+		})
+	}
+}
+
+func TestExtractPersistentVolumeClaim(t *testing.T) {
+	storageClass := "standard"
+	apiGroup := "snapshot.storage.k8s.io"
+	tests := map[string]struct {
+		input    corev1.PersistentVolumeClaim
+		expected model.PersistentVolumeClaim
+	}{
+		"full pvc": {
+			input: corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pvc",
+					Namespace: "namespace",
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse("2Gi"),
+						},
+					},
+					VolumeName:       "pv",
+					StorageClassName: &storageClass,
+					DataSource: &corev1.TypedLocalObjectReference{
+						APIGroup: &apiGroup,
+						Kind:     "VolumeSnapshot",
+						Name:     "snapshot",
+					},
+				},
+				Status: corev1.PersistentVolumeClaimStatus{
+					Phase:       corev1.ClaimBound,
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Capacity: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("2Gi"),
+					},
+				},
+			}, expected: model.PersistentVolumeClaim{
+				Metadata: &model.Metadata{
+					Name:      "pvc",
+					Namespace: "namespace",
+				},
+				Spec: &model.PersistentVolumeClaimSpec{
+					AccessModes: []string{"ReadWriteOnce"},
+					Resources: &model.ResourceRequirements{
+						Requests: map[string]int64{"storage": 2147483648},
+					},
+					VolumeName:       "pv",
+					StorageClassName: "standard",
+					DataSource: &model.TypedLocalObjectReference{
+						ApiGroup: "snapshot.storage.k8s.io",
+						Kind:     "VolumeSnapshot",
+						Name:     "snapshot",
+					},
+				},
+				Status: &model.PersistentVolumeClaimStatus{
+					Phase:       "Bound",
+					AccessModes: []string{"ReadWriteOnce"},
+					Capacity:    map[string]int64{"storage": 2147483648},
+				},
+			},
+		},
+		"empty pvc": {input: corev1.PersistentVolumeClaim{}, expected: model.PersistentVolumeClaim{Metadata: &model.Metadata{}, Spec: &model.PersistentVolumeClaimSpec{}, Status: &model.PersistentVolumeClaimStatus{}}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, &tc.expected, extractPersistentVolumeClaim(&tc.input))
+		})
+	}
+}
@@ -51,6 +51,10 @@ type KubeASConfig struct {
 	MaxEventCollection       int      `yaml:"max_events_per_run"`
 	LeaderSkip               bool     `yaml:"skip_leader_election"`
 	ResyncPeriodEvents       int      `yaml:"kubernetes_event_resync_period_s"`
+	// Send each event as a log, in addition to the bundled Datadog events
+	CollectEventsAsLogs bool        `yaml:"collect_events_as_logs"`
+	EventsAsLogsInclude eventFilter `yaml:"events_as_logs_include"`
+	EventsAsLogsExclude eventFilter `yaml:"events_as_logs_exclude"`
 }
 
 // EventC holds the information pertaining to which event we collected last and when we last re-synced.
@@ -68,11 +72,17 @@ type KubeASCheck struct {
 	ac              *apiserver.APIClient
 	oshiftAPILevel  apiserver.OpenShiftAPILevel
 	providerIDCache *cache.Cache
+	// resourceVersion of the last event sent as a log
+	eventLogsResVer       uint64
+	eventLogsResVerLoaded bool
+	// events left to send as logs because the logs-agent queue was full
+	eventLogsPending []*v1.Event
 }
 
 func (c *KubeASConfig) parse(data []byte) error {
 	// default values
 	c.CollectEvent = config.Datadog.GetBool("collect_kubernetes_events")
+	c.CollectEventsAsLogs = config.Datadog.GetBool("collect_kubernetes_events_as_logs")
 	c.CollectOShiftQuotas = true
 	c.ResyncPeriodEvents = defaultResyncPeriodInSecond
 
@@ -195,7 +205,7 @@ func (k *KubeASCheck) Run() error {
 	}
 
 	// Running the event collection.
-	if !k.instance.CollectEvent {
+	if !k.instance.CollectEvent && !k.instance.CollectEventsAsLogs {
 		return nil
 	}
 
@@ -206,9 +216,16 @@ func (k *KubeASCheck) Run() error {
 	}
 
 	// Process the events to have a Datadog format.
-	err = k.processEvents(sender, events)
-	if err != nil {
-		k.Warnf("Could not submit new event %s", err.Error()) //nolint:errcheck
+	if k.instance.CollectEvent {
+		err = k.processEvents(sender, events)
+		if err != nil {
+			k.Warnf("Could not submit new event %s", err.Error()) //nolint:errcheck
+		}
+	}
+
+	// Forward each event as a log.
+	if k.instance.CollectEventsAsLogs {
+		k.sendEventsAsLogs(events, clustername.GetClusterName())
 	}
 	return nil
 }
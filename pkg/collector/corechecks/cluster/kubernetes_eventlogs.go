@@ -0,0 +1,218 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/eventlogs"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// eventLogsTokenKey stores the resourceVersion of the last event sent as a log
+const eventLogsTokenKey = "eventlogs"
+
+// eventFilter matches Kubernetes events, empty fields match all the events
+type eventFilter struct {
+	Namespaces []string `yaml:"namespaces"`
+	Kinds      []string `yaml:"kinds"`
+	Reasons    []string `yaml:"reasons"`
+	Types      []string `yaml:"types"`
+}
+
+// isEmpty returns whether the filter doesn't set any field
+func (f eventFilter) isEmpty() bool {
+	return len(f.Namespaces) == 0 && len(f.Kinds) == 0 && len(f.Reasons) == 0 && len(f.Types) == 0
+}
+
+// matches returns whether an event matches all the fields set in the filter
+func (f eventFilter) matches(event *v1.Event) bool {
+	return matchesAny(f.Namespaces, event.InvolvedObject.Namespace) &&
+		matchesAny(f.Kinds, event.InvolvedObject.Kind) &&
+		matchesAny(f.Reasons, event.Reason) &&
+		matchesAny(f.Types, event.Type)
+}
+
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldSendEventAsLog returns whether an event matches the include filter
+// and doesn't match the exclude filter
+func (k *KubeASCheck) shouldSendEventAsLog(event *v1.Event) bool {
+	if !k.instance.EventsAsLogsInclude.matches(event) {
+		return false
+	}
+	exclude := k.instance.EventsAsLogsExclude
+	return exclude.isEmpty() || !exclude.matches(event)
+}
+
+// sendEventsAsLogs sends the events as logs to the logs-agent, in resourceVersion order. It stops at
+// the first event dropped because the logs-agent queue is full, the events left are retried at the
+// next run. The resourceVersion of the last event sent is stored in the ConfigMap, so that a new
+// leader doesn't send them again. The events are kept until the logs-agent forwards them.
+func (k *KubeASCheck) sendEventsAsLogs(events []*v1.Event, clusterName string) {
+	if !eventlogs.IsRunning() {
+		k.Warnf("Kubernetes events not sent as logs: the logs-agent isn't running, enable logs_enabled and collect_kubernetes_events_as_logs") //nolint:errcheck
+		k.eventLogsPending = k.capEventLogsPending(append(k.eventLogsPending, events...))
+		return
+	}
+
+	if !k.eventLogsResVerLoaded {
+		resVer, _, err := k.ac.GetTokenFromConfigmap(eventLogsTokenKey)
+		if err != nil {
+			k.Warnf("Could not retrieve the last event sent as a log from the ConfigMap: %s", err.Error()) //nolint:errcheck
+			return
+		}
+		k.eventLogsResVer = parseResourceVersion(resVer)
+		k.eventLogsResVerLoaded = true
+	}
+
+	toSend := k.filterEventsAsLogs(append(k.eventLogsPending, events...), k.eventLogsResVer)
+	lastResVer, unsent := k.queueEventsAsLogs(toSend, clusterName, k.eventLogsResVer)
+	if len(unsent) > 0 {
+		log.Debugf("The logs-agent queue is full, %d Kubernetes events will be sent at the next run", len(unsent))
+	}
+	k.eventLogsPending = k.capEventLogsPending(unsent)
+
+	if lastResVer == k.eventLogsResVer {
+		return
+	}
+	k.eventLogsResVer = lastResVer
+	if err := k.ac.UpdateTokenInConfigmap(eventLogsTokenKey, strconv.FormatUint(lastResVer, 10), time.Now()); err != nil {
+		k.Warnf("Could not store the last event sent as a log in the ConfigMap: %s", err.Error()) //nolint:errcheck
+	}
+}
+
+// capEventLogsPending drops the events over max_events_per_run from the events to send at the next run
+func (k *KubeASCheck) capEventLogsPending(pending []*v1.Event) []*v1.Event {
+	if len(pending) > k.instance.MaxEventCollection {
+		k.Warnf("Dropped %d Kubernetes events not sent as logs", len(pending)-k.instance.MaxEventCollection) //nolint:errcheck
+		pending = pending[:k.instance.MaxEventCollection]
+	}
+	return pending
+}
+
+// filterEventsAsLogs returns the events newer than lastResVer, without duplicates,
+// sorted by resourceVersion. Events without a numeric resourceVersion come first.
+func (k *KubeASCheck) filterEventsAsLogs(events []*v1.Event, lastResVer uint64) []*v1.Event {
+	var toSend []*v1.Event
+	seen := make(map[uint64]struct{}, len(events))
+	for _, event := range events {
+		resVer := parseResourceVersion(event.ResourceVersion)
+		if resVer != 0 && resVer <= lastResVer {
+			log.Tracef("Event %s/%s with resourceVersion %d already sent as a log", event.Namespace, event.Name, resVer)
+			continue
+		}
+		if _, found := seen[resVer]; found && resVer != 0 {
+			continue
+		}
+		seen[resVer] = struct{}{}
+		toSend = append(toSend, event)
+	}
+	sort.SliceStable(toSend, func(i, j int) bool {
+		return parseResourceVersion(toSend[i].ResourceVersion) < parseResourceVersion(toSend[j].ResourceVersion)
+	})
+	return toSend
+}
+
+// queueEventsAsLogs queues the events matching the filters to the logs-agent, until one is dropped
+// because the queue is full. It returns the resourceVersion of the last event handled, queued or
+// filtered out, and the events left from the dropped one.
+func (k *KubeASCheck) queueEventsAsLogs(events []*v1.Event, clusterName string, lastResVer uint64) (uint64, []*v1.Event) {
+	for i, event := range events {
+		if k.shouldSendEventAsLog(event) && !eventlogs.Send(toEventLog(event, clusterName)) {
+			return lastResVer, events[i:]
+		}
+		if resVer := parseResourceVersion(event.ResourceVersion); resVer > lastResVer {
+			lastResVer = resVer
+		}
+	}
+	return lastResVer, nil
+}
+
+// parseResourceVersion parses a resourceVersion, they are opaque strings but are
+// etcd revisions in practice. It returns 0 if the resourceVersion isn't a number.
+func parseResourceVersion(resVer string) uint64 {
+	if resVer == "" {
+		return 0
+	}
+	v, err := strconv.ParseUint(resVer, 10, 64)
+	if err != nil {
+		log.Debugf("Cannot parse resourceVersion %q: %v", resVer, err)
+		return 0
+	}
+	return v
+}
+
+// toEventLog converts a Kubernetes event to a structured log
+func toEventLog(event *v1.Event, clusterName string) *eventlogs.EventLog {
+	obj := event.InvolvedObject
+	eventLog := &eventlogs.EventLog{
+		Message: event.Message,
+		Reason:  event.Reason,
+		Type:    event.Type,
+		Count:   event.Count,
+		InvolvedObject: eventlogs.ObjectReference{
+			Kind:            obj.Kind,
+			Namespace:       obj.Namespace,
+			Name:            obj.Name,
+			UID:             string(obj.UID),
+			APIVersion:      obj.APIVersion,
+			ResourceVersion: obj.ResourceVersion,
+			FieldPath:       obj.FieldPath,
+		},
+		Source: eventlogs.EventSource{
+			Component: event.Source.Component,
+			Host:      event.Source.Host,
+		},
+		Name:            event.Name,
+		Namespace:       event.Namespace,
+		UID:             string(event.UID),
+		ResourceVersion: event.ResourceVersion,
+	}
+	if !event.FirstTimestamp.IsZero() {
+		eventLog.FirstTimestamp = event.FirstTimestamp.Unix()
+	}
+	if !event.LastTimestamp.IsZero() {
+		eventLog.LastTimestamp = event.LastTimestamp.Unix()
+	}
+
+	tags := []string{
+		fmt.Sprintf("source_component:%s", event.Source.Component),
+		fmt.Sprintf("kubernetes_kind:%s", obj.Kind),
+		fmt.Sprintf("name:%s", obj.Name),
+		fmt.Sprintf("event_reason:%s", event.Reason),
+	}
+	if kindTag := addKindRelatedTag(obj.Kind, obj.Name); kindTag != "" {
+		tags = append(tags, kindTag)
+	}
+	if obj.Namespace != "" {
+		tags = append(tags, fmt.Sprintf("kube_namespace:%s", obj.Namespace))
+	}
+	if clusterName != "" {
+		tags = append(tags, fmt.Sprintf("kube_cluster_name:%s", clusterName))
+	}
+	eventLog.Tags = tags
+
+	return eventLog
+}
@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/eventlogs"
+)
+
+func createEventWithResVer(resVer, namespace, objname, objkind, reason, typ string) *v1.Event {
+	ev := createEvent(1, namespace, objname, objkind, "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "default-scheduler", "machine-blue", reason, "message", typ, 709662600)
+	ev.ResourceVersion = resVer
+	return ev
+}
+
+func TestShouldSendEventAsLog(t *testing.T) {
+	pulled := createEventWithResVer("1", "default", "dca-789976f5d7-2ljx6", "Pod", "Pulled", "Normal")
+	backOff := createEventWithResVer("2", "default", "dca-789976f5d7-2ljx6", "Pod", "BackOff", "Warning")
+	system := createEventWithResVer("3", "kube-system", "coredns", "Deployment", "ScalingReplicaSet", "Normal")
+
+	for _, tc := range []struct {
+		name     string
+		include  eventFilter
+		exclude  eventFilter
+		expected []bool
+	}{
+		{
+			name:     "no filter",
+			expected: []bool{true, true, true},
+		},
+		{
+			name:     "include warnings",
+			include:  eventFilter{Types: []string{"warning"}},
+			expected: []bool{false, true, false},
+		},
+		{
+			name:     "exclude namespace",
+			exclude:  eventFilter{Namespaces: []string{"kube-system"}},
+			expected: []bool{true, true, false},
+		},
+		{
+			name:     "include kind and exclude reason",
+			include:  eventFilter{Kinds: []string{"Pod"}},
+			exclude:  eventFilter{Reasons: []string{"Pulled"}},
+			expected: []bool{false, true, false},
+		},
+		{
+			name:     "exclude matches all the fields",
+			exclude:  eventFilter{Namespaces: []string{"default"}, Reasons: []string{"ScalingReplicaSet"}},
+			expected: []bool{true, true, true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeASCheck := &KubeASCheck{
+				instance: &KubeASConfig{
+					EventsAsLogsInclude: tc.include,
+					EventsAsLogsExclude: tc.exclude,
+				},
+			}
+			for i, ev := range []*v1.Event{pulled, backOff, system} {
+				assert.Equal(t, tc.expected[i], kubeASCheck.shouldSendEventAsLog(ev), "event %d", i)
+			}
+		})
+	}
+}
+
+func TestFilterEventsAsLogs(t *testing.T) {
+	ev1 := createEventWithResVer("100", "default", "dca-789976f5d7-2ljx6", "Pod", "Pulled", "Normal")
+	ev2 := createEventWithResVer("102", "default", "dca-789976f5d7-2ljx6", "Pod", "BackOff", "Warning")
+	ev3 := createEventWithResVer("104", "kube-system", "coredns", "Deployment", "ScalingReplicaSet", "Normal")
+
+	kubeASCheck := &KubeASCheck{instance: &KubeASConfig{}}
+
+	// Events are sorted by resourceVersion, without duplicates
+	toSend := kubeASCheck.filterEventsAsLogs([]*v1.Event{ev3, ev1, ev2, ev3}, 0)
+	assert.Equal(t, []*v1.Event{ev1, ev2, ev3}, toSend)
+
+	// Events already sent are skipped
+	toSend = kubeASCheck.filterEventsAsLogs([]*v1.Event{ev1, ev2, ev3}, 101)
+	assert.Equal(t, []*v1.Event{ev2, ev3}, toSend)
+
+	toSend = kubeASCheck.filterEventsAsLogs([]*v1.Event{ev1, ev2, ev3}, 104)
+	assert.Len(t, toSend, 0)
+
+	// Events without a numeric resourceVersion are always sent
+	ev4 := createEventWithResVer("", "default", "dca-789976f5d7-2ljx6", "Pod", "Started", "Normal")
+	toSend = kubeASCheck.filterEventsAsLogs([]*v1.Event{ev4, ev4}, 104)
+	assert.Equal(t, []*v1.Event{ev4, ev4}, toSend)
+}
+
+func TestQueueEventsAsLogs(t *testing.T) {
+	ev1 := createEventWithResVer("100", "default", "dca-789976f5d7-2ljx6", "Pod", "Pulled", "Normal")
+	ev2 := createEventWithResVer("102", "kube-system", "coredns", "Deployment", "ScalingReplicaSet", "Normal")
+	ev3 := createEventWithResVer("104", "default", "dca-789976f5d7-2ljx6", "Pod", "BackOff", "Warning")
+	ev4 := createEventWithResVer("106", "default", "dca-789976f5d7-2ljx6", "Pod", "Started", "Normal")
+
+	kubeASCheck := &KubeASCheck{
+		instance: &KubeASConfig{
+			EventsAsLogsExclude: eventFilter{Namespaces: []string{"kube-system"}},
+		},
+	}
+
+	// leave room for a single event in the logs-agent queue
+	logs := eventlogs.GetChannel()
+	defer func() {
+		for len(logs) > 0 {
+			<-logs
+		}
+	}()
+	for len(logs) < cap(logs)-1 {
+		logs <- &eventlogs.EventLog{}
+	}
+
+	// Excluded events move the resourceVersion forward, it stops at the first event dropped
+	resVer, unsent := kubeASCheck.queueEventsAsLogs([]*v1.Event{ev1, ev2, ev3, ev4}, "my-cluster", 0)
+	assert.Equal(t, uint64(102), resVer)
+	assert.Equal(t, []*v1.Event{ev3, ev4}, unsent)
+
+	// The events left are sent once the queue is drained
+	for len(logs) > 0 {
+		<-logs
+	}
+	resVer, unsent = kubeASCheck.queueEventsAsLogs(unsent, "my-cluster", resVer)
+	assert.Equal(t, uint64(106), resVer)
+	assert.Len(t, unsent, 0)
+	assert.Len(t, logs, 2)
+}
+
+func TestSendEventsAsLogsNotRunning(t *testing.T) {
+	ev1 := createEventWithResVer("100", "default", "dca-789976f5d7-2ljx6", "Pod", "Pulled", "Normal")
+	ev2 := createEventWithResVer("102", "default", "dca-789976f5d7-2ljx6", "Pod", "BackOff", "Warning")
+	ev3 := createEventWithResVer("104", "default", "dca-789976f5d7-2ljx6", "Pod", "Started", "Normal")
+
+	kubeASCheck := &KubeASCheck{
+		instance: &KubeASConfig{MaxEventCollection: 2},
+	}
+
+	// Without a logs-agent forwarding them, the events are kept and the ConfigMap isn't updated
+	kubeASCheck.sendEventsAsLogs([]*v1.Event{ev1, ev2, ev3}, "my-cluster")
+	assert.Equal(t, []*v1.Event{ev1, ev2}, kubeASCheck.eventLogsPending)
+	assert.False(t, kubeASCheck.eventLogsResVerLoaded)
+	assert.Len(t, eventlogs.GetChannel(), 0)
+}
+
+func TestToEventLog(t *testing.T) {
+	ev := createEvent(3, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "BackOff", "Back-off restarting failed container", "Warning", 709662600)
+	ev.Name = "dca-789976f5d7-2ljx6.15f4d1d5c8f0c3b2"
+	ev.Namespace = "default"
+	ev.ResourceVersion = "1234"
+
+	eventLog := toEventLog(ev, "my-cluster")
+
+	assert.Equal(t, "Back-off restarting failed container", eventLog.Message)
+	assert.Equal(t, "BackOff", eventLog.Reason)
+	assert.Equal(t, "Warning", eventLog.Type)
+	assert.Equal(t, int32(3), eventLog.Count)
+	assert.Equal(t, "Pod", eventLog.InvolvedObject.Kind)
+	assert.Equal(t, "dca-789976f5d7-2ljx6", eventLog.InvolvedObject.Name)
+	assert.Equal(t, "e6417a7f-f566-11e7-9749-0e4863e1cbf4", eventLog.InvolvedObject.UID)
+	assert.Equal(t, "kubelet", eventLog.Source.Component)
+	assert.Equal(t, "machine-blue", eventLog.Source.Host)
+	assert.Equal(t, int64(709662600), eventLog.FirstTimestamp)
+	assert.Equal(t, int64(709662600), eventLog.LastTimestamp)
+	assert.Equal(t, "1234", eventLog.ResourceVersion)
+	assert.ElementsMatch(t, []string{
+		"source_component:kubelet",
+		"kubernetes_kind:Pod",
+		"name:dca-789976f5d7-2ljx6",
+		"event_reason:BackOff",
+		"pod_name:dca-789976f5d7-2ljx6",
+		"kube_namespace:default",
+		"kube_cluster_name:my-cluster",
+	}, eventLog.Tags)
+}
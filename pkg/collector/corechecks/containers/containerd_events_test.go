@@ -34,6 +34,7 @@ type mockItf struct {
 	mockTaskMetrics func(ctn containerd.Container) (*types.Metric, error)
 	mockTaskPids    func(ctn containerd.Container) ([]containerd.ProcessInfo, error)
 	mockInfo        func(ctn containerd.Container) (containers.Container, error)
+	mockLoad        func(id string) (containerd.Container, error)
 	mockNamespace   func() string
 	mockSpec        func(ctn containerd.Container) (*oci.Spec, error)
 }
@@ -46,6 +47,10 @@ func (m *mockItf) Info(ctn containerd.Container) (containers.Container, error) {
 	return m.mockInfo(ctn)
 }
 
+func (m *mockItf) LoadContainer(id string) (containerd.Container, error) {
+	return m.mockLoad(id)
+}
+
 func (m *mockItf) TaskMetrics(ctn containerd.Container) (*types.Metric, error) {
 	return m.mockTaskMetrics(ctn)
 }
@@ -235,6 +235,14 @@ func InitConfig(config Config) {
 
 	// Yaml keys which values are stripped from flare
 	config.BindEnvAndSetDefault("flare_stripped_keys", []string{})
+	// Flares created automatically, an empty path means they are kept in `run_path`/flares.
+	config.BindEnvAndSetDefault("auto_flare_enabled", false)
+	config.BindEnvAndSetDefault("auto_flare_path", "")
+	config.BindEnvAndSetDefault("auto_flare_max_archives", 5)
+	config.BindEnvAndSetDefault("auto_flare_unhealthy_duration", 10) // value in minutes
+	config.BindEnvAndSetDefault("auto_flare_on_restart", true)
+	config.BindEnvAndSetDefault("auto_flare_schedule", "")
+	config.BindEnvAndSetDefault("auto_flare_min_interval", 60) // value in minutes
 
 	// Agent GUI access port
 	config.BindEnvAndSetDefault("GUI_port", defaultGuiPort)
@@ -281,6 +289,8 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("histogram_percentiles", []string{"0.95"})
 	config.BindEnvAndSetDefault("aggregator_stop_timeout", 2)
 	config.BindEnvAndSetDefault("aggregator_buffer_size", 100)
+	config.BindEnvAndSetDefault("aggregator_max_contexts_per_metric", 0) // Notice: 0 means no limit
+	config.SetKnown("aggregator_excluded_tags")
 	// Serializer
 	config.BindEnvAndSetDefault("enable_stream_payload_serialization", true)
 	config.BindEnvAndSetDefault("enable_service_checks_stream_payload_serialization", true)
@@ -331,10 +341,19 @@ func InitConfig(config Config) {
 
 	config.BindEnvAndSetDefault("dogstatsd_non_local_traffic", false)
 	config.BindEnvAndSetDefault("dogstatsd_socket", "") // Notice: empty means feature disabled
+	// Stream listeners, messages are newline delimited.
+	config.BindEnvAndSetDefault("dogstatsd_tcp_port", 0)       // Notice: 0 means TCP port closed
+	config.BindEnvAndSetDefault("dogstatsd_stream_socket", "") // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_stream_max_connections", 1024)
+	config.BindEnvAndSetDefault("dogstatsd_stream_idle_timeout", 5*time.Minute)
+	// Traffic captures, an empty path means captures are written in `run_path`.
+	config.BindEnvAndSetDefault("dogstatsd_capture_path", "")
+	config.BindEnvAndSetDefault("dogstatsd_capture_max_duration", time.Hour)
 	config.BindEnvAndSetDefault("dogstatsd_stats_port", 5000)
 	config.BindEnvAndSetDefault("dogstatsd_stats_enable", false)
 	config.BindEnvAndSetDefault("dogstatsd_stats_buffer", 10)
 	config.BindEnvAndSetDefault("dogstatsd_expiry_seconds", 300)
+	config.BindEnvAndSetDefault("dogstatsd_timestamp_max_age", time.Hour)
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection", false) // Only supported for socket traffic
 	config.BindEnvAndSetDefault("dogstatsd_so_rcvbuf", 0)
 	config.BindEnvAndSetDefault("dogstatsd_metrics_stats_enable", false)
@@ -383,6 +402,8 @@ func InitConfig(config Config) {
 	// Containerd
 	// We only support containerd in Kubernetes. By default containerd cri uses `k8s.io` https://github.com/containerd/cri/blob/release/1.2/pkg/constants/constants.go#L22-L23
 	config.BindEnvAndSetDefault("containerd_namespace", "k8s.io")
+	config.BindEnvAndSetDefault("containerd_labels_as_tags", map[string]string{})
+	config.BindEnvAndSetDefault("containerd_env_as_tags", map[string]string{})
 
 	// Kubernetes
 	config.BindEnvAndSetDefault("kubernetes_kubelet_host", "")
@@ -393,6 +414,7 @@ func InitConfig(config Config) {
 
 	config.BindEnvAndSetDefault("kubelet_tls_verify", true)
 	config.BindEnvAndSetDefault("collect_kubernetes_events", false)
+	config.BindEnvAndSetDefault("collect_kubernetes_events_as_logs", false)
 	config.BindEnvAndSetDefault("kubelet_client_ca", "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt")
 
 	config.BindEnvAndSetDefault("kubelet_auth_token_path", "")
@@ -434,6 +456,7 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("cluster_agent.url", "")
 	config.BindEnvAndSetDefault("cluster_agent.kubernetes_service_name", "datadog-cluster-agent")
 	config.BindEnvAndSetDefault("cluster_agent.tagging_fallback", false)
+	config.BindEnvAndSetDefault("cluster_agent.forwarded_metrics", []string{}) // Metrics submitted to the local external metrics backend of the cluster agent
 	config.BindEnvAndSetDefault("metrics_port", "5000")
 
 	// Metadata endpoints
@@ -459,6 +482,11 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("exclude_gce_tags", []string{"kube-env", "kubelet-config", "containerd-configure-sh", "startup-script", "shutdown-script", "configure-sh", "sshKeys", "ssh-keys", "user-data", "cli-cert", "ipsec-cert", "ssl-cert", "google-container-manifest", "bosh_settings", "windows-startup-script-ps1", "common-psm1", "k8s-node-setup-psm1", "serial-port-logging-enable", "enable-oslogin", "disable-address-manager", "disable-legacy-endpoints", "windows-keys"})
 	config.BindEnvAndSetDefault("gce_metadata_timeout", 1000) // value in milliseconds
 
+	// Oracle Cloud, OpenStack and DigitalOcean
+	config.BindEnvAndSetDefault("collect_oracle_tags", true)
+	config.BindEnvAndSetDefault("collect_openstack_tags", true)
+	config.BindEnvAndSetDefault("collect_digitalocean_tags", true)
+
 	// Cloud Foundry
 	config.BindEnvAndSetDefault("cloud_foundry", false)
 	config.BindEnvAndSetDefault("bosh_id", "")
@@ -596,6 +624,12 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("kubernetes_event_collection_timeout", 100)               // timeout between two successful event collections in milliseconds.
 	config.BindEnvAndSetDefault("kubernetes_informers_resync_period", 60*5)               // value in seconds. Default to 5 minutes
 	config.BindEnvAndSetDefault("external_metrics_provider.local_copy_refresh_rate", 30)  // value in seconds
+
+	// Answer the external metrics queries from the metrics reported by the node agents instead of Datadog
+	config.BindEnvAndSetDefault("external_metrics_provider.local_backend.enabled", false)
+	config.BindEnvAndSetDefault("external_metrics_provider.local_backend.retention", 60*15)  // value in seconds. How long the reported points are kept
+	config.BindEnvAndSetDefault("external_metrics_provider.local_backend.max_series", 10000) // Maximum number of series kept by the local backend
+
 	// Cluster check Autodiscovery
 	config.BindEnvAndSetDefault("cluster_checks.enabled", false)
 	config.BindEnvAndSetDefault("cluster_checks.node_expiration_timeout", 30) // value in seconds
@@ -603,6 +637,9 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("cluster_checks.cluster_tag_name", "cluster_name")
 	config.BindEnvAndSetDefault("cluster_checks.extra_tags", []string{})
 	config.BindEnvAndSetDefault("cluster_checks.advanced_dispatching_enabled", false)
+	config.BindEnvAndSetDefault("cluster_checks.placement_strategy", "busyness")
+	config.BindEnvAndSetDefault("cluster_checks.node_capacity", 0)
+	config.BindEnvAndSetDefault("cluster_checks.rebalance_cooldown", 1800) // value in seconds
 	config.BindEnvAndSetDefault("cluster_checks.clc_runners_port", 5005)
 	// Cluster check runner
 	config.BindEnvAndSetDefault("clc_runner_enabled", false)
@@ -623,6 +660,13 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("admission_controller.inject_config.endpoint", "/injectconfig")
 	config.BindEnvAndSetDefault("admission_controller.inject_tags.enabled", true)
 	config.BindEnvAndSetDefault("admission_controller.inject_tags.endpoint", "/injecttags")
+	config.BindEnvAndSetDefault("admission_controller.auto_instrumentation.enabled", false)
+	config.BindEnvAndSetDefault("admission_controller.auto_instrumentation.endpoint", "/injectlib")
+	config.BindEnvAndSetDefault("admission_controller.auto_instrumentation.container_registry", "gcr.io/datadoghq")
+	config.BindEnvAndSetDefault("admission_controller.inject_dogstatsd_socket.enabled", false)
+	config.BindEnvAndSetDefault("admission_controller.inject_dogstatsd_socket.endpoint", "/injectsocket")
+	// path of the node agent's dogstatsd_socket on the host
+	config.BindEnvAndSetDefault("admission_controller.inject_dogstatsd_socket.socket_path", "/var/run/datadog/dsd.socket")
 	config.BindEnvAndSetDefault("admission_controller.pod_owners_cache_validity", 10) // in minutes
 
 	// Telemetry
@@ -718,7 +762,14 @@ func InitConfig(config Config) {
 	config.SetKnown("apm_config.max_cpu_percent")
 	config.SetKnown("apm_config.receiver_port")
 	config.SetKnown("apm_config.receiver_socket")
+	config.SetKnown("apm_config.receiver_socket_permissions")
+	config.SetKnown("apm_config.receiver_socket_origin_detection")
+	config.SetKnown("apm_config.debug_traces_enabled")
 	config.SetKnown("apm_config.connection_limit")
+	config.SetKnown("apm_config.service_rate_limit.max_traces_per_second")
+	config.SetKnown("apm_config.service_rate_limit.service_traces_per_second")
+	config.SetKnown("apm_config.service_rate_limit.by_env")
+	config.SetKnown("apm_config.service_rate_limit.overrides")
 	config.SetKnown("apm_config.ignore_resources")
 	config.SetKnown("apm_config.replace_tags")
 	config.SetKnown("apm_config.obfuscation.elasticsearch.enabled")
@@ -730,6 +781,12 @@ func InitConfig(config Config) {
 	config.SetKnown("apm_config.obfuscation.remove_stack_traces")
 	config.SetKnown("apm_config.obfuscation.redis.enabled")
 	config.SetKnown("apm_config.obfuscation.memcached.enabled")
+	config.SetKnown("apm_config.obfuscation.sensitive_data.enabled")
+	config.SetKnown("apm_config.obfuscation.sensitive_data.keep_values")
+	config.SetKnown("apm_config.obfuscation.graphql.enabled")
+	config.SetKnown("apm_config.obfuscation.cassandra.enabled")
+	config.SetKnown("apm_config.obfuscation.aws.enabled")
+	config.SetKnown("apm_config.obfuscation.aws.keep_values")
 	config.SetKnown("apm_config.extra_sample_rate")
 	config.SetKnown("apm_config.dd_agent_bin")
 	config.SetKnown("apm_config.max_events_per_second")
@@ -744,6 +801,7 @@ func InitConfig(config Config) {
 	config.SetKnown("apm_config.analyzed_spans.*")
 	config.SetKnown("apm_config.log_throttling")
 	config.SetKnown("apm_config.bucket_size_seconds")
+	config.SetKnown("apm_config.infer_peer_services")
 	config.SetKnown("apm_config.receiver_timeout")
 	config.SetKnown("apm_config.watchdog_check_delay")
 	config.SetKnown("apm_config.max_payload_size")
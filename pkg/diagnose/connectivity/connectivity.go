@@ -0,0 +1,352 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package connectivity registers diagnoses checking the connectivity to the
+// Datadog intakes: DNS resolution, TCP connection, TLS handshake, proxy usage
+// and API key validation.
+package connectivity
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	checkTimeout = 10 * time.Second
+
+	validateEndpoint = "/api/v1/validate"
+
+	// certificateExpiryWarning is how long before its expiration a certificate is reported
+	certificateExpiryWarning = 30 * 24 * time.Hour
+)
+
+// apiDomainRe matches the Datadog sites, like the forwarder does to validate the API keys
+var apiDomainRe = regexp.MustCompile(`((us|eu)\d\.)?datadoghq.[a-z]+$`)
+
+func init() {
+	diagnosis.Register("connectivity-datadog-core-endpoints", func() error { return diagnoseEndpoints(coreEndpoints) })
+	diagnosis.Register("connectivity-datadog-logs-endpoints", func() error { return diagnoseEndpoints(logsEndpoints) })
+	diagnosis.Register("connectivity-datadog-apm-endpoints", func() error { return diagnoseEndpoints(apmEndpoints) })
+	diagnosis.Register("connectivity-datadog-process-endpoints", func() error { return diagnoseEndpoints(processEndpoints) })
+}
+
+// diagnoseEndpoints checks all the endpoints and fails if one of them fails
+func diagnoseEndpoints(getEndpoints func() ([]endpoint, error)) error {
+	endpoints, err := getEndpoints()
+	if err != nil {
+		return log.Errorf("Could not build the endpoints: %v", err)
+	}
+
+	c := newChecker()
+	failed := 0
+	for _, e := range endpoints {
+		if err := c.check(e); err != nil {
+			log.Errorf("%s %s: %v", e.name, e, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d out of %d endpoints failed", failed, len(endpoints))
+	}
+	return nil
+}
+
+// checker runs the connectivity checks of an endpoint
+type checker struct {
+	timeout   time.Duration
+	resolver  *net.Resolver
+	tlsConfig *tls.Config
+	transport *http.Transport
+}
+
+// newChecker returns a checker using the proxy and TLS settings of the Agent
+func newChecker() *checker {
+	transport := httputils.CreateHTTPTransport()
+	return &checker{
+		timeout:   checkTimeout,
+		resolver:  net.DefaultResolver,
+		tlsConfig: transport.TLSClientConfig,
+		transport: transport,
+	}
+}
+
+// check runs all the connectivity checks of an endpoint
+func (c *checker) check(e endpoint) error {
+	log.Infof("Checking the %s %s", e.name, e)
+
+	proxyURL, err := c.proxyURL(e)
+	if err != nil {
+		return fmt.Errorf("proxy configuration: %v", err)
+	}
+	if proxyURL != nil {
+		log.Infof("Using the proxy %s", redactURL(proxyURL))
+	} else if e.socks5Proxy != "" {
+		log.Infof("Using the SOCKS5 proxy %s", e.socks5Proxy)
+	} else {
+		log.Info("Not using any proxy")
+	}
+	throughProxy := proxyURL != nil || e.socks5Proxy != ""
+
+	if err := c.checkDNS(e.host); err != nil {
+		// The proxy resolves the host, the Agent may not be able to
+		if !throughProxy {
+			return fmt.Errorf("DNS resolution: %v", err)
+		}
+		log.Warnf("DNS resolution of %s: %v, relying on the proxy to resolve it", e.host, err)
+	}
+
+	if proxyURL != nil {
+		// The TCP connection is established with the proxy, the TLS session through the proxy
+		if err := c.checkTCP(proxy.Direct, proxyAddress(proxyURL)); err != nil {
+			return fmt.Errorf("TCP connection to the proxy: %v", err)
+		}
+		if e.useTLS {
+			if err := c.checkTLSThroughProxy(e); err != nil {
+				return fmt.Errorf("TLS handshake through the proxy: %v", err)
+			}
+		}
+	} else {
+		dialer, err := c.dialer(e)
+		if err != nil {
+			return err
+		}
+		if err := c.checkTCP(dialer, e.address()); err != nil {
+			return fmt.Errorf("TCP connection: %v", err)
+		}
+		if e.useTLS {
+			if err := c.checkTLS(dialer, e); err != nil {
+				return fmt.Errorf("TLS handshake: %v", err)
+			}
+		}
+	}
+
+	return c.checkAPIKeys(e)
+}
+
+// proxyURL returns the HTTP proxy the Agent uses to reach the endpoint, if any
+func (c *checker) proxyURL(e endpoint) (*url.URL, error) {
+	if e.url == nil || c.transport.Proxy == nil {
+		return nil, nil
+	}
+	return c.transport.Proxy(&http.Request{URL: e.url})
+}
+
+// dialer returns the dialer connecting directly, or through the SOCKS5 proxy of the endpoint
+func (c *checker) dialer(e endpoint) (proxy.Dialer, error) {
+	direct := &net.Dialer{Timeout: c.timeout}
+	if e.socks5Proxy == "" {
+		return direct, nil
+	}
+	dialer, err := proxy.SOCKS5("tcp", e.socks5Proxy, nil, direct)
+	if err != nil {
+		return nil, fmt.Errorf("SOCKS5 proxy %s: %v", e.socks5Proxy, err)
+	}
+	return dialer, nil
+}
+
+// checkDNS resolves the host
+func (c *checker) checkDNS(host string) error {
+	if net.ParseIP(host) != nil {
+		log.Debugf("%s is an IP address, nothing to resolve", host)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	addrs, err := c.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return err
+	}
+	log.Infof("%s resolves to %s", host, strings.Join(addrs, ", "))
+	return nil
+}
+
+// checkTCP opens a TCP connection to the address
+func (c *checker) checkTCP(dialer proxy.Dialer, address string) error {
+	start := time.Now()
+	conn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	log.Infof("Connected to %s in %s", address, time.Since(start))
+	return nil
+}
+
+// checkTLS runs a TLS handshake with the endpoint
+func (c *checker) checkTLS(dialer proxy.Dialer, e endpoint) error {
+	conn, err := dialer.Dial("tcp", e.address())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return err
+	}
+
+	tlsConfig := c.tlsConfig.Clone()
+	tlsConfig.ServerName = e.host
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	logTLSState(tlsConn.ConnectionState())
+	return nil
+}
+
+// checkTLSThroughProxy sends a request to the endpoint through the proxy, the TLS
+// handshake is run through the tunnel opened by the proxy
+func (c *checker) checkTLSThroughProxy(e endpoint) error {
+	client := &http.Client{Transport: c.transport, Timeout: c.timeout}
+	req, err := http.NewRequest("HEAD", (&url.URL{Scheme: e.url.Scheme, Host: e.url.Host}).String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.TLS == nil {
+		return fmt.Errorf("no TLS session established")
+	}
+	logTLSState(*resp.TLS)
+	return nil
+}
+
+// checkAPIKeys validates the API keys of the endpoint with the API of its Datadog site
+func (c *checker) checkAPIKeys(e endpoint) error {
+	domain := apiDomain(e)
+	if domain == "" {
+		log.Debugf("Cannot validate the API keys of the %s %s", e.name, e)
+		return nil
+	}
+
+	invalid := 0
+	for _, apiKey := range e.apiKeys {
+		valid, err := c.validateAPIKey(domain, apiKey)
+		switch {
+		case err != nil:
+			log.Warnf("Unable to validate the %s: %v", obfuscateAPIKey(apiKey), err)
+		case valid:
+			log.Infof("The %s is valid", obfuscateAPIKey(apiKey))
+		default:
+			log.Errorf("The %s is invalid", obfuscateAPIKey(apiKey))
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid API keys", invalid)
+	}
+	return nil
+}
+
+// validateAPIKey returns whether the API key is valid, the API returns 200 if it is, 403 if it isn't
+func (c *checker) validateAPIKey(domain, apiKey string) (bool, error) {
+	client := &http.Client{Transport: c.transport, Timeout: c.timeout}
+	req, err := http.NewRequest("GET", domain+validateEndpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("DD-API-KEY", apiKey)
+	req.Header.Set("User-Agent", fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusForbidden:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected response code from the API key validation endpoint: %d", resp.StatusCode)
+	}
+}
+
+// apiDomain returns the API domain validating the API keys of the endpoint: the API of the
+// Datadog site of the endpoint, or the endpoint itself if it isn't a Datadog site (e.g. a proxy).
+// The TCP intakes don't serve the API.
+func apiDomain(e endpoint) string {
+	if apiDomainRe.MatchString(e.host) {
+		return "https://api." + apiDomainRe.FindString(e.host)
+	}
+	if e.url == nil {
+		return ""
+	}
+	return (&url.URL{Scheme: e.url.Scheme, Host: e.url.Host}).String()
+}
+
+// logTLSState logs the TLS version and the certificate of the server
+func logTLSState(state tls.ConnectionState) {
+	log.Infof("TLS handshake succeeded, version %s, cipher suite %#04x", tlsVersionName(state.Version), state.CipherSuite)
+	if len(state.PeerCertificates) == 0 {
+		return
+	}
+	cert := state.PeerCertificates[0]
+	log.Infof("Certificate: subject %q, issuer %q, DNS names %s, valid from %s to %s",
+		cert.Subject.CommonName, cert.Issuer.CommonName, strings.Join(cert.DNSNames, ", "),
+		cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+	if time.Until(cert.NotAfter) < certificateExpiryWarning {
+		log.Warnf("The certificate expires on %s", cert.NotAfter.Format(time.RFC3339))
+	}
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "1.0"
+	case tls.VersionTLS11:
+		return "1.1"
+	case tls.VersionTLS12:
+		return "1.2"
+	case tls.VersionTLS13:
+		return "1.3"
+	}
+	return fmt.Sprintf("%#04x", version)
+}
+
+// proxyAddress returns the host:port address of a proxy URL
+func proxyAddress(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443")
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
+}
+
+// redactURL hides the credentials of a proxy URL
+func redactURL(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+	return fmt.Sprintf("%s://*****:*****@%s", u.Scheme, u.Host)
+}
+
+// obfuscateAPIKey only keeps the last characters of an API key, like the forwarder
+func obfuscateAPIKey(apiKey string) string {
+	if len(apiKey) > 5 {
+		apiKey = apiKey[len(apiKey)-5:]
+	}
+	return fmt.Sprintf("API key ending with %s", apiKey)
+}
@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package connectivity
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	logshttp "github.com/DataDog/datadog-agent/pkg/logs/client/http"
+	logsconfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	apmEndpointPrefix     = "https://trace.agent."
+	processEndpointPrefix = "https://process."
+)
+
+// endpoint is an intake the Agent sends data to
+type endpoint struct {
+	// name describes the intake in the diagnosis output
+	name string
+	// url is set for the HTTP intakes
+	url *url.URL
+	// host and port are always set, they are taken from url for the HTTP intakes
+	host string
+	port int
+	// useTLS is whether the connection is encrypted
+	useTLS bool
+	// socks5Proxy is the address of the SOCKS5 proxy used by the logs TCP intakes
+	socks5Proxy string
+	// apiKeys are the API keys sent to the intake
+	apiKeys []string
+}
+
+// address returns the host:port address of the endpoint
+func (e endpoint) address() string {
+	return net.JoinHostPort(e.host, strconv.Itoa(e.port))
+}
+
+// String returns the URL of the HTTP intakes and the address of the TCP intakes
+func (e endpoint) String() string {
+	if e.url != nil {
+		return e.url.String()
+	}
+	return e.address()
+}
+
+// newHTTPEndpoint returns the endpoint of an HTTP intake
+func newHTTPEndpoint(name, rawURL string, apiKeys []string) (endpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return endpoint{}, fmt.Errorf("could not parse the URL of the %s %q: %v", name, rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return endpoint{}, fmt.Errorf("unsupported scheme for the %s %q", name, rawURL)
+	}
+
+	e := endpoint{
+		name:    name,
+		url:     u,
+		host:    u.Hostname(),
+		useTLS:  u.Scheme == "https",
+		apiKeys: apiKeys,
+	}
+	if port := u.Port(); port != "" {
+		if e.port, err = strconv.Atoi(port); err != nil {
+			return endpoint{}, fmt.Errorf("invalid port for the %s %q: %v", name, rawURL, err)
+		}
+	} else if e.useTLS {
+		e.port = 443
+	} else {
+		e.port = 80
+	}
+	return e, nil
+}
+
+// newHTTPEndpoints returns the endpoints of the HTTP intakes from a map of URLs to API keys
+func newHTTPEndpoints(name string, keysPerURL map[string][]string) ([]endpoint, error) {
+	var endpoints []endpoint
+	for rawURL, apiKeys := range keysPerURL {
+		e, err := newHTTPEndpoint(name, rawURL, apiKeys)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, nil
+}
+
+// coreEndpoints returns the forwarder intakes, including the `additional_endpoints`
+func coreEndpoints() ([]endpoint, error) {
+	keysPerDomain, err := config.GetMultipleEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	return newHTTPEndpoints("metrics intake", keysPerDomain)
+}
+
+// logsEndpoints returns the logs intakes. Like the logs-agent, it checks the HTTP
+// connectivity first to pick between the HTTP and the TCP intakes.
+func logsEndpoints() ([]endpoint, error) {
+	if !config.Datadog.GetBool("logs_enabled") {
+		log.Info("The logs-agent is disabled, not checking the logs intakes")
+		return nil, nil
+	}
+
+	httpConnectivity := logsconfig.HTTPConnectivityFailure
+	if httpEndpoints, err := logsconfig.BuildHTTPEndpoints(); err == nil {
+		httpConnectivity = logshttp.CheckConnectivity(httpEndpoints.Main)
+	}
+	logsEndpoints, err := logsconfig.BuildEndpoints(httpConnectivity)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []endpoint
+	for _, e := range append([]logsconfig.Endpoint{logsEndpoints.Main}, logsEndpoints.Additionals...) {
+		endpoints = append(endpoints, newLogsEndpoint(e, logsEndpoints.UseHTTP))
+	}
+	return endpoints, nil
+}
+
+// newLogsEndpoint returns the endpoint of a logs intake
+func newLogsEndpoint(e logsconfig.Endpoint, useHTTP bool) endpoint {
+	if !useHTTP {
+		return endpoint{
+			name:        "logs TCP intake",
+			host:        e.Host,
+			port:        e.Port,
+			useTLS:      e.UseSSL,
+			socks5Proxy: e.ProxyAddress,
+			apiKeys:     []string{e.APIKey},
+		}
+	}
+
+	scheme := "http"
+	if e.UseSSL {
+		scheme = "https"
+	}
+	host := e.Host
+	if e.Port != 0 {
+		host = net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	}
+	u := &url.URL{Scheme: scheme, Host: host, Path: "/v1/input"}
+
+	// The URL is built from valid fields, it can't fail to parse
+	httpEndpoint, _ := newHTTPEndpoint("logs HTTP intake", u.String(), []string{e.APIKey})
+	return httpEndpoint
+}
+
+// apmEndpoints returns the APM intakes, including the `apm_config.additional_endpoints`
+func apmEndpoints() ([]endpoint, error) {
+	if !config.Datadog.GetBool("apm_config.enabled") {
+		log.Info("APM is disabled, not checking the APM intakes")
+		return nil, nil
+	}
+	keysPerURL := additionalEndpoints("apm_config.additional_endpoints")
+	main := config.GetMainEndpoint(apmEndpointPrefix, "apm_config.apm_dd_url")
+	keysPerURL[main] = append(keysPerURL[main], mainAPIKey("apm_config.api_key"))
+	return newHTTPEndpoints("APM intake", keysPerURL)
+}
+
+// processEndpoints returns the process intakes, including the `process_config.additional_endpoints`
+func processEndpoints() ([]endpoint, error) {
+	if config.Datadog.GetString("process_config.enabled") == "disabled" {
+		log.Info("The process-agent is disabled, not checking the process intakes")
+		return nil, nil
+	}
+	keysPerURL := additionalEndpoints("process_config.additional_endpoints")
+	main := config.GetMainEndpoint(processEndpointPrefix, "process_config.process_dd_url")
+	keysPerURL[main] = append(keysPerURL[main], mainAPIKey(""))
+	return newHTTPEndpoints("process intake", keysPerURL)
+}
+
+// additionalEndpoints returns the sanitized API keys per URL set in the configKey map
+func additionalEndpoints(configKey string) map[string][]string {
+	keysPerURL := make(map[string][]string)
+	for u, keys := range config.Datadog.GetStringMapStringSlice(configKey) {
+		for _, key := range keys {
+			keysPerURL[u] = append(keysPerURL[u], config.SanitizeAPIKey(key))
+		}
+	}
+	return keysPerURL
+}
+
+// mainAPIKey returns the API key set in configKey, defaulting to `api_key`
+func mainAPIKey(configKey string) string {
+	if configKey != "" && config.Datadog.GetString(configKey) != "" {
+		return config.SanitizeAPIKey(config.Datadog.GetString(configKey))
+	}
+	return config.SanitizeAPIKey(config.Datadog.GetString("api_key"))
+}
@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const captureQueueSize = 1024
+
+var (
+	tlmCapturedPackets = telemetry.NewCounter("dogstatsd", "captured_packets",
+		[]string{"state"}, "Count of packets captured by dogstatsd")
+
+	// errCaptureInProgress is returned when starting a capture while another one is running
+	errCaptureInProgress = errors.New("a traffic capture is already in progress")
+)
+
+// trafficCapture records the packets received by the server listeners in a capture file
+type trafficCapture struct {
+	path    string
+	file    *os.File
+	writer  *replay.Writer
+	records chan *replay.Record
+	done    chan struct{}
+}
+
+// Capture starts recording the packets received by the listeners for the given
+// duration, it returns the path of the capture file.
+func (s *Server) Capture(duration time.Duration) (string, error) {
+	maxDuration := config.Datadog.GetDuration("dogstatsd_capture_max_duration")
+	if duration <= 0 || (maxDuration > 0 && duration > maxDuration) {
+		return "", fmt.Errorf("invalid capture duration %s, it must be positive and at most %s", duration, maxDuration)
+	}
+
+	s.captureMutex.Lock()
+	defer s.captureMutex.Unlock()
+	if s.capture != nil {
+		return "", errCaptureInProgress
+	}
+
+	capture, err := newTrafficCapture(getCaptureDir())
+	if err != nil {
+		return "", err
+	}
+	s.capture = capture
+	atomic.StoreUint32(&s.capturing, 1)
+	time.AfterFunc(duration, s.stopCapture)
+
+	log.Infof("Dogstatsd: capturing the traffic for %s in %s", duration, capture.path)
+	return capture.path, nil
+}
+
+// stopCapture stops the current capture if any and waits until the capture file is written
+func (s *Server) stopCapture() {
+	s.captureMutex.Lock()
+	capture := s.capture
+	s.capture = nil
+	atomic.StoreUint32(&s.capturing, 0)
+	s.captureMutex.Unlock()
+
+	if capture == nil {
+		return
+	}
+	close(capture.records)
+	<-capture.done
+	log.Infof("Dogstatsd: traffic capture written in %s", capture.path)
+}
+
+// capturePackets enqueues a copy of the packets to the current capture,
+// packets are dropped if the capture can't keep up.
+func (s *Server) capturePackets(packets listeners.Packets) {
+	s.captureMutex.RLock()
+	defer s.captureMutex.RUnlock()
+	if s.capture == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, packet := range packets {
+		// packets are reused once processed, the contents have to be copied
+		payload := make([]byte, len(packet.Contents))
+		copy(payload, packet.Contents)
+		select {
+		case s.capture.records <- &replay.Record{Timestamp: now, Origin: packet.Origin, Payload: payload}:
+			tlmCapturedPackets.Inc("ok")
+		default:
+			tlmCapturedPackets.Inc("dropped")
+		}
+	}
+}
+
+func getCaptureDir() string {
+	if dir := config.Datadog.GetString("dogstatsd_capture_path"); dir != "" {
+		return dir
+	}
+	return filepath.Join(config.Datadog.GetString("run_path"), "dsd_capture")
+}
+
+func newTrafficCapture(dir string) (*trafficCapture, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create the capture directory: %v", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("datadog-capture-%d.gz", time.Now().UnixNano()))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the capture file: %v", err)
+	}
+	writer, err := replay.NewWriter(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("unable to write the capture file: %v", err)
+	}
+
+	capture := &trafficCapture{
+		path:    path,
+		file:    file,
+		writer:  writer,
+		records: make(chan *replay.Record, captureQueueSize),
+		done:    make(chan struct{}),
+	}
+	go capture.run()
+	return capture, nil
+}
+
+// run writes the records to the capture file until the records channel is closed
+func (c *trafficCapture) run() {
+	defer close(c.done)
+	failed := false
+	for record := range c.records {
+		if failed {
+			continue
+		}
+		if err := c.writer.Write(record); err != nil {
+			log.Errorf("Dogstatsd: error writing the capture file, stopping the capture: %v", err)
+			failed = true
+		}
+	}
+	if err := c.writer.Close(); err != nil {
+		log.Errorf("Dogstatsd: error writing the capture file: %v", err)
+	}
+	if err := c.file.Close(); err != nil {
+		log.Errorf("Dogstatsd: error closing the capture file: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+)
+
+func TestCapture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dsd-capture-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	port, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	config.Datadog.Set("dogstatsd_capture_path", dir)
+	defer config.Datadog.Set("dogstatsd_capture_path", "")
+
+	agg := mockAggregator()
+	metricOut, _, _ := agg.GetBufferedChannels()
+	s, err := NewServer(agg)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	_, err = s.Capture(0)
+	assert.Error(t, err)
+
+	path, err := s.Capture(time.Hour)
+	require.NoError(t, err)
+	_, err = s.Capture(time.Hour)
+	assert.Equal(t, errCaptureInProgress, err)
+
+	conn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err, "cannot connect to DSD socket")
+	defer conn.Close()
+	conn.Write([]byte("daemon:666|g|#sometag1:somevalue1"))
+	select {
+	case <-metricOut:
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+
+	s.stopCapture()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	reader, err := replay.NewReader(f)
+	require.NoError(t, err)
+	record, err := reader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("daemon:666|g|#sometag1:somevalue1"), record.Payload)
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+
+	// a new capture can be started once the previous one is over
+	_, err = s.Capture(time.Hour)
+	assert.NoError(t, err)
+}
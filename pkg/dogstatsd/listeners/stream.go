@@ -0,0 +1,299 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package listeners
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	tcpTelemetry       = newListenerTelemetry("tcp", "TCP")
+	udsStreamTelemetry = newListenerTelemetry("uds_stream", "UDS stream")
+
+	tlmStreamConnections = telemetry.NewCounter("dogstatsd", "stream_connections",
+		[]string{"listener", "state"}, "Dogstatsd stream listeners connections count")
+	tlmStreamActiveConnections = telemetry.NewGauge("dogstatsd", "stream_active_connections",
+		[]string{"listener"}, "Dogstatsd stream listeners active connections")
+)
+
+// StreamListener implements the StatsdListener interface for stream
+// protocols (TCP and UDS stream). Messages are newline delimited, each
+// connection reads into packets of the shared packet pool and only
+// complete messages are forwarded to be processed.
+// Origin detection is only implemented for UDS stream sockets.
+type StreamListener struct {
+	name             string
+	tlmName          string
+	listener         net.Listener
+	packetsBuffer    *packetsBuffer
+	sharedPacketPool *PacketPool
+	telemetry        *listenerTelemetry
+	originDetection  bool
+	maxConnections   int
+	idleTimeout      time.Duration
+	socketPath       string
+
+	connsMutex sync.Mutex
+	conns      map[net.Conn]struct{}
+	connsWg    sync.WaitGroup
+	stopped    bool
+}
+
+// NewTCPListener returns an idle TCP Statsd listener
+func NewTCPListener(packetOut chan Packets, sharedPacketPool *PacketPool) (*StreamListener, error) {
+	var url string
+
+	if config.Datadog.GetBool("dogstatsd_non_local_traffic") == true {
+		// Listen to all network interfaces
+		url = fmt.Sprintf(":%d", config.Datadog.GetInt("dogstatsd_tcp_port"))
+	} else {
+		url = net.JoinHostPort(config.Datadog.GetString("bind_host"), config.Datadog.GetString("dogstatsd_tcp_port"))
+	}
+
+	listener, err := net.Listen("tcp", url)
+	if err != nil {
+		return nil, fmt.Errorf("dogstatsd-tcp: can't listen: %s", err)
+	}
+
+	l := newStreamListener("dogstatsd-tcp", "tcp", listener, packetOut, sharedPacketPool, tcpTelemetry)
+	log.Debugf("dogstatsd-tcp: %s successfully initialized", listener.Addr())
+	return l, nil
+}
+
+// NewUDSStreamListener returns an idle UDS stream Statsd listener
+func NewUDSStreamListener(packetOut chan Packets, sharedPacketPool *PacketPool) (*StreamListener, error) {
+	socketPath := config.Datadog.GetString("dogstatsd_stream_socket")
+
+	address, addrErr := net.ResolveUnixAddr("unix", socketPath)
+	if addrErr != nil {
+		return nil, fmt.Errorf("dogstatsd-uds-stream: can't ResolveUnixAddr: %v", addrErr)
+	}
+	fileInfo, err := os.Stat(socketPath)
+	// Socket file already exists
+	if err == nil {
+		// Make sure it's a UNIX socket
+		if fileInfo.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("dogstatsd-uds-stream: cannot reuse %s socket path: path already exists and is not a UNIX socket", socketPath)
+		}
+		err = os.Remove(socketPath)
+		if err != nil {
+			return nil, fmt.Errorf("dogstatsd-uds-stream: cannot remove stale UNIX socket: %v", err)
+		}
+	}
+
+	listener, err := net.ListenUnix("unix", address)
+	if err != nil {
+		return nil, fmt.Errorf("dogstatsd-uds-stream: can't listen: %s", err)
+	}
+	// The socket file is removed by Stop
+	listener.SetUnlinkOnClose(false)
+	err = os.Chmod(socketPath, 0722)
+	if err != nil {
+		return nil, fmt.Errorf("dogstatsd-uds-stream: can't set the socket at write only: %s", err)
+	}
+
+	l := newStreamListener("dogstatsd-uds-stream", "uds_stream", listener, packetOut, sharedPacketPool, udsStreamTelemetry)
+	l.originDetection = config.Datadog.GetBool("dogstatsd_origin_detection")
+	l.socketPath = socketPath
+	log.Debugf("dogstatsd-uds-stream: %s successfully initialized", listener.Addr())
+	return l, nil
+}
+
+func newStreamListener(name, tlmName string, listener net.Listener, packetOut chan Packets, sharedPacketPool *PacketPool, telemetry *listenerTelemetry) *StreamListener {
+	return &StreamListener{
+		name:     name,
+		tlmName:  tlmName,
+		listener: listener,
+		packetsBuffer: newPacketsBuffer(uint(config.Datadog.GetInt("dogstatsd_packet_buffer_size")),
+			config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout"), packetOut),
+		sharedPacketPool: sharedPacketPool,
+		telemetry:        telemetry,
+		maxConnections:   config.Datadog.GetInt("dogstatsd_stream_max_connections"),
+		idleTimeout:      config.Datadog.GetDuration("dogstatsd_stream_idle_timeout"),
+		conns:            make(map[net.Conn]struct{}),
+	}
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *StreamListener) Listen() {
+	log.Infof("%s: starting to listen on %s", l.name, l.listener.Addr())
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			// listener has been closed
+			if strings.HasSuffix(err.Error(), " use of closed network connection") {
+				return
+			}
+			log.Errorf("%s: error accepting connection: %v", l.name, err)
+			continue
+		}
+
+		if !l.trackConnection(conn) {
+			log.Debugf("%s: too many connections, closing connection from %s", l.name, conn.RemoteAddr())
+			tlmStreamConnections.Inc(l.tlmName, "rejected")
+			conn.Close()
+			continue
+		}
+		tlmStreamConnections.Inc(l.tlmName, "accepted")
+
+		go l.handleConnection(conn)
+	}
+}
+
+// trackConnection registers a new connection, it returns false if the
+// maximum number of connections is reached or if the listener is stopped.
+func (l *StreamListener) trackConnection(conn net.Conn) bool {
+	l.connsMutex.Lock()
+	defer l.connsMutex.Unlock()
+	if l.stopped || (l.maxConnections > 0 && len(l.conns) >= l.maxConnections) {
+		return false
+	}
+	l.conns[conn] = struct{}{}
+	l.connsWg.Add(1)
+	tlmStreamActiveConnections.Inc(l.tlmName)
+	return true
+}
+
+func (l *StreamListener) untrackConnection(conn net.Conn) {
+	l.connsMutex.Lock()
+	defer l.connsMutex.Unlock()
+	delete(l.conns, conn)
+	l.connsWg.Done()
+	tlmStreamActiveConnections.Dec(l.tlmName)
+}
+
+// handleConnection reads newline delimited messages from a connection until it is
+// closed, idle for too long or the listener is stopped.
+func (l *StreamListener) handleConnection(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		l.untrackConnection(conn)
+	}()
+
+	origin := NoOrigin
+	if unixConn, ok := conn.(*net.UnixConn); ok && l.originDetection {
+		var err error
+		origin, err = processUDSStreamOrigin(unixConn)
+		if err != nil {
+			log.Warnf("%s: error processing origin, data will not be tagged : %v", l.name, err)
+			udsOriginDetectionErrors.Add(1)
+			tlmUDSOriginDetectionError.Inc()
+		}
+	}
+
+	// retrieve an available packet from the packet pool,
+	// which will be pushed back by the server when processed.
+	packet := l.sharedPacketPool.Get()
+	// number of bytes of a partial message at the beginning of the packet buffer
+	pending := 0
+	// set when dropping a message bigger than the buffer, until its end is read
+	discarding := false
+	for {
+		if l.idleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(l.idleTimeout)) //nolint:errcheck
+		}
+		n, err := conn.Read(packet.buffer[pending:])
+		if err != nil {
+			if err == io.EOF {
+				// the last message is not required to be newline terminated
+				if pending > 0 {
+					l.forward(packet, pending, origin)
+				} else {
+					l.sharedPacketPool.Put(packet)
+				}
+				return
+			}
+			l.sharedPacketPool.Put(packet)
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				log.Debugf("%s: closing idle connection from %s", l.name, conn.RemoteAddr())
+				tlmStreamConnections.Inc(l.tlmName, "idle_closed")
+				return
+			}
+			// connection has been closed
+			if !strings.HasSuffix(err.Error(), " use of closed network connection") {
+				log.Errorf("%s: error reading packet: %v", l.name, err)
+				l.telemetry.onReadError()
+			}
+			return
+		}
+
+		end := pending + n
+		if discarding {
+			dropEnd := bytes.IndexByte(packet.buffer[:end], messageSeparator)
+			if dropEnd < 0 {
+				continue
+			}
+			discarding = false
+			end = copy(packet.buffer, packet.buffer[dropEnd+1:end])
+		}
+
+		// Only forward complete messages, the trailing partial message is
+		// carried over to the next packet.
+		messagesEnd := bytes.LastIndexByte(packet.buffer[:end], messageSeparator)
+		if messagesEnd == 0 {
+			pending = copy(packet.buffer, packet.buffer[1:end])
+			continue
+		}
+		if messagesEnd < 0 {
+			pending = end
+			if pending == len(packet.buffer) {
+				log.Debugf("%s: dropping message bigger than the buffer size (%d bytes)", l.name, len(packet.buffer))
+				l.telemetry.onReadError()
+				pending = 0
+				discarding = true
+			}
+			continue
+		}
+
+		next := l.sharedPacketPool.Get()
+		pending = copy(next.buffer, packet.buffer[messagesEnd+1:end])
+		l.forward(packet, messagesEnd, origin)
+		packet = next
+	}
+}
+
+// forward sends the first n bytes of the packet to be processed
+func (l *StreamListener) forward(packet *Packet, n int, origin string) {
+	l.telemetry.onReadSuccess(n)
+	packet.Contents = packet.buffer[:n]
+	packet.Origin = origin
+	// packetsBuffer handles the forwarding of the packets to the dogstatsd server intake channel
+	l.packetsBuffer.append(packet)
+}
+
+// Stop closes the listener and all the active connections
+func (l *StreamListener) Stop() {
+	l.listener.Close()
+
+	l.connsMutex.Lock()
+	l.stopped = true
+	for conn := range l.conns {
+		conn.Close()
+	}
+	l.connsMutex.Unlock()
+	l.connsWg.Wait()
+
+	l.packetsBuffer.close()
+
+	// Socket cleanup on exit
+	if len(l.socketPath) > 0 {
+		err := os.Remove(l.socketPath)
+		if err != nil {
+			log.Infof("%s: error removing socket file: %s", l.name, err)
+		}
+	}
+}
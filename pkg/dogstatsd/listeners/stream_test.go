@@ -0,0 +1,197 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !windows
+// UDS won't work in windows
+
+package listeners
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+var packetPoolStream = NewPacketPool(config.Datadog.GetInt("dogstatsd_buffer_size"))
+
+func newTestTCPListener(t *testing.T, packetChannel chan Packets) (*StreamListener, int) {
+	port, err := getAvailableTCPPort()
+	require.Nil(t, err)
+	mockConfig := config.Mock()
+	mockConfig.Set("dogstatsd_tcp_port", port)
+
+	s, err := NewTCPListener(packetChannel, packetPoolStream)
+	require.Nil(t, err)
+	require.NotNil(t, s)
+	return s, port
+}
+
+func receivePacket(t *testing.T, packetChannel chan Packets) *Packet {
+	select {
+	case packets := <-packetChannel:
+		require.Equal(t, 1, len(packets))
+		return packets[0]
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Timeout on receive channel")
+	}
+	return nil
+}
+
+func TestStartStopTCPListener(t *testing.T) {
+	s, port := newTestTCPListener(t, nil)
+
+	go s.Listen()
+	// Local port should be unavailable
+	_, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	assert.NotNil(t, err)
+
+	s.Stop()
+
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err, "port is not available, it should be")
+	l.Close()
+}
+
+func TestTCPReceive(t *testing.T) {
+	packetChannel := make(chan Packets)
+	s, port := newTestTCPListener(t, packetChannel)
+
+	go s.Listen()
+	defer s.Stop()
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	defer conn.Close()
+
+	// the partial message is only forwarded once it is complete
+	conn.Write([]byte("daemon:666|g|#sometag1:somevalue1\ndaemon:777|"))
+	packet := receivePacket(t, packetChannel)
+	assert.Equal(t, []byte("daemon:666|g|#sometag1:somevalue1"), packet.Contents)
+	assert.Equal(t, NoOrigin, packet.Origin)
+
+	conn.Write([]byte("g\n"))
+	packet = receivePacket(t, packetChannel)
+	assert.Equal(t, []byte("daemon:777|g"), packet.Contents)
+
+	// the last message is forwarded when the connection is closed
+	conn.Write([]byte("daemon:888|g"))
+	conn.Close()
+	packet = receivePacket(t, packetChannel)
+	assert.Equal(t, []byte("daemon:888|g"), packet.Contents)
+}
+
+func TestTCPMaxConnections(t *testing.T) {
+	packetChannel := make(chan Packets)
+	port, err := getAvailableTCPPort()
+	require.Nil(t, err)
+	mockConfig := config.Mock()
+	mockConfig.Set("dogstatsd_tcp_port", port)
+	mockConfig.Set("dogstatsd_stream_max_connections", 1)
+	defer mockConfig.Set("dogstatsd_stream_max_connections", 1024)
+
+	s, err := NewTCPListener(packetChannel, packetPoolStream)
+	require.Nil(t, err)
+	go s.Listen()
+	defer s.Stop()
+
+	conn1, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	defer conn1.Close()
+	conn1.Write([]byte("daemon:666|g\n"))
+	receivePacket(t, packetChannel)
+
+	// the second connection is closed by the listener
+	conn2, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	defer conn2.Close()
+	conn2.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn2.Read(make([]byte, 1))
+	assert.Error(t, err)
+	if netErr, ok := err.(net.Error); ok {
+		assert.False(t, netErr.Timeout())
+	}
+}
+
+func TestTCPIdleTimeout(t *testing.T) {
+	port, err := getAvailableTCPPort()
+	require.Nil(t, err)
+	mockConfig := config.Mock()
+	mockConfig.Set("dogstatsd_tcp_port", port)
+	mockConfig.Set("dogstatsd_stream_idle_timeout", 10*time.Millisecond)
+	defer mockConfig.Set("dogstatsd_stream_idle_timeout", 5*time.Minute)
+
+	s, err := NewTCPListener(nil, packetPoolStream)
+	require.Nil(t, err)
+	go s.Listen()
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+	if netErr, ok := err.(net.Error); ok {
+		assert.False(t, netErr.Timeout())
+	}
+}
+
+func TestUDSStreamReceive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dd-test-")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir) // clean up
+	socketPath := filepath.Join(dir, "dsd_stream.socket")
+	mockConfig := config.Mock()
+	mockConfig.Set("dogstatsd_stream_socket", socketPath)
+
+	packetChannel := make(chan Packets)
+	s, err := NewUDSStreamListener(packetChannel, packetPoolStream)
+	require.Nil(t, err)
+	fi, err := os.Stat(socketPath)
+	require.Nil(t, err)
+	assert.Equal(t, "Srwx-w--w-", fi.Mode().String())
+
+	go s.Listen()
+	conn, err := net.Dial("unix", socketPath)
+	require.Nil(t, err)
+	defer conn.Close()
+	conn.Write([]byte("daemon:666|g\ndaemon:777|g\n"))
+
+	packet := receivePacket(t, packetChannel)
+	assert.Equal(t, []byte("daemon:666|g\ndaemon:777|g"), packet.Contents)
+
+	s.Stop()
+	_, err = os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// getAvailableTCPPort requests a random port number and makes sure it is available
+func getAvailableTCPPort() (int, error) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return -1, fmt.Errorf("can't find an available tcp port: %s", err)
+	}
+	defer l.Close()
+
+	_, portString, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		return -1, fmt.Errorf("can't find an available tcp port: %s", err)
+	}
+	portInt, err := strconv.Atoi(portString)
+	if err != nil {
+		return -1, fmt.Errorf("can't convert tcp port: %s", err)
+	}
+
+	return portInt, nil
+}
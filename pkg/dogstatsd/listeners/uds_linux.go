@@ -76,6 +76,36 @@ func processUDSOrigin(ancillary []byte) (string, error) {
 	return entity, nil
 }
 
+// processUDSStreamOrigin reads the credentials of the peer of a stream
+// connection to determine its origin, it returns a string identifying the source.
+// Credentials are attached to stream connections by the Linux kernel at connect time,
+// so the origin only needs to be resolved once per connection.
+func processUDSStreamOrigin(conn *net.UnixConn) (string, error) {
+	rawconn, err := conn.SyscallConn()
+	if err != nil {
+		return NoOrigin, err
+	}
+
+	var cred *unix.Ucred
+	var credErr error
+	err = rawconn.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return NoOrigin, err
+	}
+	if credErr != nil {
+		return NoOrigin, credErr
+	}
+
+	if cred.Pid == 0 {
+		return NoOrigin, fmt.Errorf("matched PID for the process is 0, it belongs " +
+			"probably to another namespace. Is the agent in host PID mode?")
+	}
+
+	return getEntityForPID(cred.Pid)
+}
+
 // getEntityForPID returns the container entity name and caches the value for future lookups
 // As the result is cached and the lookup is really fast (parsing local files), it can be
 // called from the intake goroutine.
@@ -29,3 +29,8 @@ func enableUDSPassCred(conn *net.UnixConn) error {
 func processUDSOrigin(oob []byte) (string, error) {
 	return NoOrigin, ErrLinuxOnly
 }
+
+// processUDSStreamOrigin returns a "not implemented" error on non-linux hosts
+func processUDSStreamOrigin(conn *net.UnixConn) (string, error) {
+	return NoOrigin, ErrLinuxOnly
+}
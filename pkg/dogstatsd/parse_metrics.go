@@ -26,6 +26,7 @@ var (
 
 	tagsFieldPrefix       = []byte("#")
 	sampleRateFieldPrefix = []byte("@")
+	timestampFieldPrefix  = []byte("T")
 )
 
 type dogstatsdMetricSample struct {
@@ -35,6 +36,11 @@ type dogstatsdMetricSample struct {
 	metricType metricType
 	sampleRate float64
 	tags       []string
+	// values is only set when a message packs several values for a
+	// histogram, distribution or timing, value is then left empty
+	values []float64
+	// timestamp is the unix timestamp sent by the client, 0 if none was sent
+	timestamp int64
 }
 
 // sanity checks a given message against the metric sample format
@@ -43,7 +49,7 @@ func hasMetricSampleFormat(message []byte) bool {
 		return false
 	}
 	separatorCount := bytes.Count(message, fieldSeparator)
-	if separatorCount < 1 || separatorCount > 3 {
+	if separatorCount < 1 || separatorCount > 4 {
 		return false
 	}
 	return true
@@ -80,6 +86,40 @@ func parseMetricSampleMetricType(rawMetricType []byte) (metricType, error) {
 	return 0, fmt.Errorf("invalid metric type: %q", rawMetricType)
 }
 
+// supportsMultipleValues returns whether several colon separated values can
+// be sent in a single message for the given metric type
+func supportsMultipleValues(metricType metricType) bool {
+	return metricType == histogramType || metricType == distributionType || metricType == timingType
+}
+
+func parseMetricSampleValues(rawValue []byte) ([]float64, error) {
+	valuesCount := bytes.Count(rawValue, colonSeparator) + 1
+	values := make([]float64, valuesCount)
+	for i := 0; i < valuesCount; i++ {
+		rawSingleValue := rawValue
+		if sepIndex := bytes.Index(rawValue, colonSeparator); sepIndex != -1 {
+			rawSingleValue, rawValue = rawValue[:sepIndex], rawValue[sepIndex+1:]
+		}
+		value, err := parseFloat64(rawSingleValue)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func parseMetricSampleTimestamp(rawTimestamp []byte) (int64, error) {
+	timestamp, err := parseInt64(rawTimestamp)
+	if err != nil {
+		return 0, err
+	}
+	if timestamp <= 0 {
+		return 0, fmt.Errorf("timestamp must be positive")
+	}
+	return timestamp, nil
+}
+
 func parseMetricSampleSampleRate(rawSampleRate []byte) (float64, error) {
 	return parseFloat64(rawSampleRate)
 }
@@ -106,8 +146,17 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 
 	var setValue []byte
 	var value float64
+	var values []float64
 	if metricType == setType {
 		setValue = rawValue
+	} else if bytes.Contains(rawValue, colonSeparator) {
+		if !supportsMultipleValues(metricType) {
+			return dogstatsdMetricSample{}, fmt.Errorf("multiple values are only supported for histograms, distributions and timings: %q", rawValue)
+		}
+		values, err = parseMetricSampleValues(rawValue)
+		if err != nil {
+			return dogstatsdMetricSample{}, fmt.Errorf("could not parse dogstatsd metric values: %v", err)
+		}
 	} else {
 		value, err = parseFloat64(rawValue)
 		if err != nil {
@@ -117,6 +166,7 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 
 	sampleRate := 1.0
 	var tags []string
+	var timestamp int64
 	var optionalField []byte
 	for message != nil {
 		optionalField, message = nextField(message)
@@ -127,15 +177,22 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 			if err != nil {
 				return dogstatsdMetricSample{}, fmt.Errorf("could not parse dogstatsd sample rate %q", optionalField)
 			}
+		} else if bytes.HasPrefix(optionalField, timestampFieldPrefix) {
+			timestamp, err = parseMetricSampleTimestamp(optionalField[1:])
+			if err != nil {
+				return dogstatsdMetricSample{}, fmt.Errorf("could not parse dogstatsd timestamp %q: %v", optionalField, err)
+			}
 		}
 	}
 
 	return dogstatsdMetricSample{
 		name:       p.interner.LoadOrStore(name),
 		value:      value,
+		values:     values,
 		setValue:   string(setValue),
 		metricType: metricType,
 		sampleRate: sampleRate,
 		tags:       tags,
+		timestamp:  timestamp,
 	}, nil
 }
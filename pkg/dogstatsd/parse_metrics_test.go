@@ -209,4 +209,74 @@ func TestParseMetricError(t *testing.T) {
 	// invalid sample rate
 	_, err = parseMetricSample([]byte("daemon:666|g|@abc"))
 	assert.Error(t, err)
+
+	// invalid timestamp
+	_, err = parseMetricSample([]byte("daemon:666|g|Tabc"))
+	assert.Error(t, err)
+	_, err = parseMetricSample([]byte("daemon:666|g|T-1"))
+	assert.Error(t, err)
+	_, err = parseMetricSample([]byte("daemon:666|g|T1.5"))
+	assert.Error(t, err)
+
+	// multiple values are only supported for histograms, distributions and timings
+	_, err = parseMetricSample([]byte("daemon:666:777|c"))
+	assert.Error(t, err)
+	_, err = parseMetricSample([]byte("daemon:666:777|s"))
+	assert.NoError(t, err)
+
+	// invalid multiple values
+	_, err = parseMetricSample([]byte("daemon:666:|h"))
+	assert.Error(t, err)
+	_, err = parseMetricSample([]byte("daemon:666::777|d"))
+	assert.Error(t, err)
+	_, err = parseMetricSample([]byte("daemon:666:abc|ms"))
+	assert.Error(t, err)
+}
+
+func TestParseGaugeWithTimestamp(t *testing.T) {
+	sample, err := parseMetricSample([]byte("daemon:666|g|#sometag1:somevalue1|T1600000000"))
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "daemon", sample.name)
+	assert.InEpsilon(t, 666.0, sample.value, epsilon)
+	assert.Equal(t, gaugeType, sample.metricType)
+	assert.Equal(t, []string{"sometag1:somevalue1"}, sample.tags)
+	assert.Equal(t, int64(1600000000), sample.timestamp)
+}
+
+func TestParseWithoutTimestamp(t *testing.T) {
+	sample, err := parseMetricSample([]byte("daemon:666|g|@0.5|#sometag1:somevalue1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), sample.timestamp)
+}
+
+func TestParseCounterWithAllFields(t *testing.T) {
+	sample, err := parseMetricSample([]byte("daemon:21|c|@0.5|#sometag1:somevalue1|T1600000000"))
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "daemon", sample.name)
+	assert.Equal(t, 21.0, sample.value)
+	assert.Equal(t, countType, sample.metricType)
+	assert.InEpsilon(t, 0.5, sample.sampleRate, epsilon)
+	assert.Equal(t, []string{"sometag1:somevalue1"}, sample.tags)
+	assert.Equal(t, int64(1600000000), sample.timestamp)
+}
+
+func TestParseMultipleValues(t *testing.T) {
+	for _, message := range []string{"daemon:1:2.5:3|h", "daemon:1:2.5:3|d", "daemon:1:2.5:3|ms"} {
+		sample, err := parseMetricSample([]byte(message + "|#sometag1:somevalue1"))
+
+		assert.NoError(t, err, message)
+		assert.Equal(t, "daemon", sample.name)
+		assert.Equal(t, []float64{1, 2.5, 3}, sample.values)
+		assert.Equal(t, []string{"sometag1:somevalue1"}, sample.tags)
+	}
+
+	sample, err := parseMetricSample([]byte("daemon:42|h"))
+	assert.NoError(t, err)
+	assert.Nil(t, sample.values)
+	assert.Equal(t, 42.0, sample.value)
 }
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package replay implements the file format used to capture the traffic
+// received by dogstatsd and to replay it later on.
+//
+// A capture file is gzip compressed. It starts with a header made of the
+// `dsdcapture` magic and a format version byte, followed by records. Each
+// record holds the reception time of a packet, its origin and its raw contents,
+// each field being prefixed by its length encoded as a varint.
+package replay
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// fileMagic is written at the beginning of each capture file
+	fileMagic = "dsdcapture"
+	// fileVersion is the version of the capture format
+	fileVersion = byte(1)
+	// maxFieldSize is the maximum size of a record field, it protects
+	// the reader against corrupted files
+	maxFieldSize = 1 << 20
+)
+
+var (
+	// ErrInvalidHeader is returned when reading a file which is not a capture file
+	ErrInvalidHeader = errors.New("invalid capture file header")
+	// ErrUnsupportedVersion is returned when reading a capture file with an unknown version
+	ErrUnsupportedVersion = errors.New("unsupported capture file version")
+)
+
+// Record is a packet captured by dogstatsd
+type Record struct {
+	// Timestamp is the time at which the packet was received
+	Timestamp time.Time
+	// Origin is the container that sent the packet, if identified
+	Origin string
+	// Payload holds the raw contents of the packet
+	Payload []byte
+}
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package replay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Reader reads the records of a capture file
+type Reader struct {
+	gz *gzip.Reader
+	r  *bufio.Reader
+}
+
+// NewReader returns a Reader for the capture read from r, it
+// returns an error if the capture header is invalid.
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrInvalidHeader, err)
+	}
+	reader := &Reader{gz: gz, r: bufio.NewReader(gz)}
+
+	header := make([]byte, len(fileMagic)+1)
+	if _, err := io.ReadFull(reader.r, header); err != nil {
+		return nil, ErrInvalidHeader
+	}
+	if string(header[:len(fileMagic)]) != fileMagic {
+		return nil, ErrInvalidHeader
+	}
+	if header[len(fileMagic)] != fileVersion {
+		return nil, ErrUnsupportedVersion
+	}
+	return reader, nil
+}
+
+// Read returns the next record of the capture, it returns io.EOF
+// once all the records have been read.
+func (r *Reader) Read() (*Record, error) {
+	timestamp, err := binary.ReadVarint(r.r)
+	if err != nil {
+		// io.EOF is only expected at a record boundary
+		return nil, err
+	}
+	origin, err := r.readField()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := r.readField()
+	if err != nil {
+		return nil, err
+	}
+	return &Record{
+		Timestamp: time.Unix(0, timestamp),
+		Origin:    string(origin),
+		Payload:   payload,
+	}, nil
+}
+
+// Close releases the resources of the reader, it does not close the underlying reader
+func (r *Reader) Close() error {
+	return r.gz.Close()
+}
+
+func (r *Reader) readField() ([]byte, error) {
+	size, err := binary.ReadVarint(r.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if size < 0 || size > maxFieldSize {
+		return nil, fmt.Errorf("invalid record field size %d", size)
+	}
+	field := make([]byte, size)
+	if _, err := io.ReadFull(r.r, field); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return field, nil
+}
+
+// unexpectedEOF converts io.EOF errors read in the middle of a record
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
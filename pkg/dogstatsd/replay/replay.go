@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package replay
+
+import (
+	"io"
+	"time"
+)
+
+// Replay writes the payload of all the records of the capture to w, one write
+// per record. The original pace of the capture is divided by speed, a speed
+// lower or equal to 0 replays the capture as fast as possible.
+// It returns the number of records replayed.
+// The origin of the records is not replayed as it is detected from the client.
+func Replay(r *Reader, w io.Writer, speed float64) (int, error) {
+	return replay(r, w, speed, time.Sleep)
+}
+
+func replay(r *Reader, w io.Writer, speed float64, sleep func(time.Duration)) (int, error) {
+	var previous time.Time
+	count := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if speed > 0 && !previous.IsZero() {
+			if wait := record.Timestamp.Sub(previous); wait > 0 {
+				sleep(time.Duration(float64(wait) / speed))
+			}
+		}
+		previous = record.Timestamp
+
+		if _, err := w.Write(record.Payload); err != nil {
+			return count, err
+		}
+		count++
+	}
+}
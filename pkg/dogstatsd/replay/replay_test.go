@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	records := []*Record{
+		{Timestamp: time.Unix(1600000000, 42), Origin: "", Payload: []byte("daemon:666|g")},
+		{Timestamp: time.Unix(1600000001, 0), Origin: "container_id://abcdef", Payload: []byte("daemon:1|c\ndaemon:2|c")},
+		{Timestamp: time.Unix(1600000002, 0), Origin: "", Payload: []byte{}},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.NoError(t, err)
+	for _, record := range records {
+		require.NoError(t, w.Write(record))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(&buf)
+	require.NoError(t, err)
+	defer r.Close()
+	for _, expected := range records {
+		record, err := r.Read()
+		require.NoError(t, err)
+		assert.True(t, expected.Timestamp.Equal(record.Timestamp))
+		assert.Equal(t, expected.Origin, record.Origin)
+		assert.Equal(t, expected.Payload, record.Payload)
+	}
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(&Record{Timestamp: time.Now(), Payload: []byte("daemon:666|g")}))
+	require.NoError(t, w.Close())
+
+	// decompress and truncate the last record
+	gz, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	var raw bytes.Buffer
+	_, err = io.Copy(&raw, gz)
+	require.NoError(t, err)
+
+	var truncated bytes.Buffer
+	gzw := gzip.NewWriter(&truncated)
+	gzw.Write(raw.Bytes()[:raw.Len()-3])
+	gzw.Close()
+
+	r, err := NewReader(&truncated)
+	require.NoError(t, err)
+	_, err = r.Read()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestReadInvalidHeader(t *testing.T) {
+	_, err := NewReader(bytes.NewReader([]byte("daemon:666|g")))
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	gzw.Write([]byte("notacapture"))
+	gzw.Close()
+	_, err = NewReader(&buf)
+	assert.Equal(t, ErrInvalidHeader, err)
+
+	buf.Reset()
+	gzw = gzip.NewWriter(&buf)
+	gzw.Write(append([]byte(fileMagic), fileVersion+1))
+	gzw.Close()
+	_, err = NewReader(&buf)
+	assert.Equal(t, ErrUnsupportedVersion, err)
+}
+
+type recordingWriter struct {
+	writes [][]byte
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(&Record{Timestamp: start, Payload: []byte("daemon:1|c")}))
+	require.NoError(t, w.Write(&Record{Timestamp: start.Add(2 * time.Second), Payload: []byte("daemon:2|c")}))
+	require.NoError(t, w.Write(&Record{Timestamp: start.Add(3 * time.Second), Payload: []byte("daemon:3|c")}))
+	require.NoError(t, w.Close())
+	capture := buf.Bytes()
+
+	for _, tc := range []struct {
+		speed  float64
+		sleeps []time.Duration
+	}{
+		{1, []time.Duration{2 * time.Second, time.Second}},
+		{2, []time.Duration{time.Second, 500 * time.Millisecond}},
+		{0, nil},
+	} {
+		t.Run(fmt.Sprintf("speed %v", tc.speed), func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(capture))
+			require.NoError(t, err)
+
+			var sleeps []time.Duration
+			out := &recordingWriter{}
+			count, err := replay(r, out, tc.speed, func(d time.Duration) { sleeps = append(sleeps, d) })
+			require.NoError(t, err)
+			assert.Equal(t, 3, count)
+			assert.Equal(t, [][]byte{[]byte("daemon:1|c"), []byte("daemon:2|c"), []byte("daemon:3|c")}, out.writes)
+			assert.Equal(t, tc.sleeps, sleeps)
+		})
+	}
+}
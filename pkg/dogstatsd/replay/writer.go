@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package replay
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+)
+
+// Writer writes records to a capture file
+type Writer struct {
+	gz     *gzip.Writer
+	varint [binary.MaxVarintLen64]byte
+}
+
+// NewWriter returns a Writer writing the capture to w, the header is
+// written right away.
+func NewWriter(w io.Writer) (*Writer, error) {
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(append([]byte(fileMagic), fileVersion)); err != nil {
+		return nil, err
+	}
+	return &Writer{gz: gz}, nil
+}
+
+// Write appends a record to the capture
+func (w *Writer) Write(record *Record) error {
+	if err := w.writeVarint(record.Timestamp.UnixNano()); err != nil {
+		return err
+	}
+	if err := w.writeField([]byte(record.Origin)); err != nil {
+		return err
+	}
+	return w.writeField(record.Payload)
+}
+
+// Close flushes the pending records, it does not close the underlying writer
+func (w *Writer) Close() error {
+	return w.gz.Close()
+}
+
+func (w *Writer) writeVarint(v int64) error {
+	n := binary.PutVarint(w.varint[:], v)
+	_, err := w.gz.Write(w.varint[:n])
+	return err
+}
+
+func (w *Writer) writeField(field []byte) error {
+	if err := w.writeVarint(int64(len(field))); err != nil {
+		return err
+	}
+	_, err := w.gz.Write(field)
+	return err
+}
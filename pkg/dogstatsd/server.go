@@ -87,6 +87,11 @@ type Server struct {
 	// NOTE(remy): this should probably be dropped and use a throttler logger, see
 	// package (pkg/trace/logutils) for a possible throttler implemetation.
 	disableVerboseLogs bool
+	// capture records the received packets when a traffic capture is in progress,
+	// capturing is an atomic int used as a boolean to avoid locking in the workers.
+	captureMutex sync.RWMutex
+	capture      *trafficCapture
+	capturing    uint32
 }
 
 // metricStat holds how many times a metric has been
@@ -162,6 +167,23 @@ func NewServer(aggregator *aggregator.BufferedAggregator) (*Server, error) {
 			tmpListeners = append(tmpListeners, udpListener)
 		}
 	}
+	if config.Datadog.GetInt("dogstatsd_tcp_port") > 0 {
+		tcpListener, err := listeners.NewTCPListener(packetsChannel, sharedPacketPool)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, tcpListener)
+		}
+	}
+	streamSocketPath := config.Datadog.GetString("dogstatsd_stream_socket")
+	if len(streamSocketPath) > 0 {
+		unixStreamListener, err := listeners.NewUDSStreamListener(packetsChannel, sharedPacketPool)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, unixStreamListener)
+		}
+	}
 
 	pipeName := config.Datadog.GetString("dogstatsd_windows_pipe_name")
 	if len(pipeName) > 0 {
@@ -174,7 +196,7 @@ func NewServer(aggregator *aggregator.BufferedAggregator) (*Server, error) {
 	}
 
 	if len(tmpListeners) == 0 {
-		return nil, fmt.Errorf("listening on neither udp nor socket, please check your configuration")
+		return nil, fmt.Errorf("listening on neither udp, tcp nor socket, please check your configuration")
 	}
 
 	// check configuration for custom namespace
@@ -325,6 +347,9 @@ func (s *Server) worker() {
 			return
 		case <-s.health.C:
 		case packets := <-s.packetsIn:
+			if atomic.LoadUint32(&s.capturing) == 1 {
+				s.capturePackets(packets)
+			}
 			s.parsePackets(batcher, parser, packets)
 		}
 	}
@@ -345,6 +370,8 @@ func nextMessage(packet *[]byte) (message []byte) {
 }
 
 func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*listeners.Packet) {
+	// reused for every metric message, a single message can hold several values
+	samples := make([]metrics.MetricSample, 0, 2)
 	for _, packet := range packets {
 		originTagger := originTags{origin: packet.Origin}
 		log.Tracef("Dogstatsd receive: %q", packet.Contents)
@@ -387,7 +414,8 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 				}
 				batcher.appendEvent(event)
 			case metricSampleType:
-				sample, err := s.parseMetricMessage(parser, message, originTagger.getTags)
+				var err error
+				samples, err = s.parseMetricMessage(samples[:0], parser, message, originTagger.getTags)
 				if err != nil {
 					originTags := originTagger.getTags()
 					if len(originTags) > 0 {
@@ -397,15 +425,17 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 					}
 					continue
 				}
-				if atomic.LoadUint64(&s.Debug.Enabled) == 1 {
-					s.storeMetricStats(sample)
-				}
-				batcher.appendSample(sample)
-				if s.histToDist && sample.Mtype == metrics.HistogramType {
-					distSample := sample.Copy()
-					distSample.Name = s.histToDistPrefix + distSample.Name
-					distSample.Mtype = metrics.DistributionType
-					batcher.appendSample(*distSample)
+				for _, sample := range samples {
+					if atomic.LoadUint64(&s.Debug.Enabled) == 1 {
+						s.storeMetricStats(sample)
+					}
+					batcher.appendSample(sample)
+					if s.histToDist && sample.Mtype == metrics.HistogramType {
+						distSample := sample.Copy()
+						distSample.Name = s.histToDistPrefix + distSample.Name
+						distSample.Mtype = metrics.DistributionType
+						batcher.appendSample(*distSample)
+					}
 				}
 			}
 		}
@@ -422,12 +452,15 @@ func (s *Server) errLog(format string, params ...interface{}) {
 	}
 }
 
-func (s *Server) parseMetricMessage(parser *parser, message []byte, originTagsFunc func() []string) (metrics.MetricSample, error) {
+// parseMetricMessage parses a metric message and appends the resulting samples
+// to the given slice, a message holding several values results in one sample
+// per value.
+func (s *Server) parseMetricMessage(samples []metrics.MetricSample, parser *parser, message []byte, originTagsFunc func() []string) ([]metrics.MetricSample, error) {
 	sample, err := parser.parseMetricSample(message)
 	if err != nil {
 		dogstatsdMetricParseErrors.Add(1)
 		tlmProcessed.IncWithTags(tlmProcessedErrorTags)
-		return metrics.MetricSample{}, err
+		return samples, err
 	}
 	if s.mapper != nil {
 		mapResult := s.mapper.Map(sample.name)
@@ -441,7 +474,14 @@ func (s *Server) parseMetricMessage(parser *parser, message []byte, originTagsFu
 	metricSample.Tags = append(metricSample.Tags, s.extraTags...)
 	dogstatsdMetricPackets.Add(1)
 	tlmProcessed.IncWithTags(tlmProcessedOkTags)
-	return metricSample, nil
+	if len(sample.values) == 0 {
+		return append(samples, metricSample), nil
+	}
+	for _, value := range sample.values {
+		metricSample.Value = value
+		samples = append(samples, metricSample)
+	}
+	return samples, nil
 }
 
 func (s *Server) parseEventMessage(parser *parser, message []byte, originTagsFunc func() []string) (*metrics.Event, error) {
@@ -478,6 +518,7 @@ func (s *Server) Stop() {
 	for _, l := range s.listeners {
 		l.Stop()
 	}
+	s.stopCapture()
 	if s.Statistics != nil {
 		s.Statistics.Stop()
 	}
@@ -511,10 +511,41 @@ func TestNoMappingsConfig(t *testing.T) {
 	assert.Nil(t, s.mapper)
 
 	parser := newParser()
-	_, err = s.parseMetricMessage(parser, []byte("test.metric:666|g"), getOriginTags)
+	_, err = s.parseMetricMessage(nil, parser, []byte("test.metric:666|g"), getOriginTags)
 	assert.NoError(t, err)
 }
 
+func TestParseMetricMessageMultipleValues(t *testing.T) {
+	getOriginTags := func() []string { return []string{} }
+
+	port, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+
+	s, err := NewServer(mockAggregator())
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	parser := newParser()
+	samples, err := s.parseMetricMessage(nil, parser, []byte("test.metric:1:2:3|d|#foo:bar|T1600000000"), getOriginTags)
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	for i, sample := range samples {
+		assert.Equal(t, "test.metric", sample.Name)
+		assert.Equal(t, float64(i+1), sample.Value)
+		assert.Equal(t, metrics.DistributionType, sample.Mtype)
+		assert.Equal(t, []string{"foo:bar"}, sample.Tags)
+		assert.Equal(t, 1600000000.0, sample.Timestamp)
+	}
+
+	// samples are appended to the given slice
+	samples, err = s.parseMetricMessage(samples[:1], parser, []byte("test.metric:4|g"), getOriginTags)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, 4.0, samples[1].Value)
+	assert.Equal(t, 0.0, samples[1].Timestamp)
+}
+
 type MetricSample struct {
 	Name  string
 	Value float64
@@ -624,9 +655,11 @@ dogstatsd_mapper_profiles:
 			var actualSamples []MetricSample
 			for _, p := range scenario.packets {
 				parser := newParser()
-				sample, err := s.parseMetricMessage(parser, []byte(p), getOriginTags)
+				samples, err := s.parseMetricMessage(nil, parser, []byte(p), getOriginTags)
 				assert.NoError(t, err, "Case `%s` failed. parseMetricMessage should not return error %v", err)
-				actualSamples = append(actualSamples, MetricSample{Name: sample.Name, Tags: sample.Tags, Mtype: sample.Mtype, Value: sample.Value})
+				for _, sample := range samples {
+					actualSamples = append(actualSamples, MetricSample{Name: sample.Name, Tags: sample.Tags, Mtype: sample.Mtype, Value: sample.Value})
+				}
 			}
 			for _, sample := range scenario.expectedSamples {
 				sort.Strings(sample.Tags)
@@ -127,15 +127,18 @@ func CreatePerformanceProfile(pprofURL string, profileDuration int) (*Profile, e
 // CreateArchive packages up the files
 func CreateArchive(local bool, distPath, pyChecksPath, logFilePath string, profile *Profile) (string, error) {
 	zipFilePath := getArchivePath()
-	confSearchPaths := SearchPaths{
+	return createArchive(getConfSearchPaths(distPath, pyChecksPath), local, zipFilePath, logFilePath, profile, nil)
+}
+
+func getConfSearchPaths(distPath, pyChecksPath string) SearchPaths {
+	return SearchPaths{
 		"":        config.Datadog.GetString("confd_path"),
 		"dist":    filepath.Join(distPath, "conf.d"),
 		"checksd": pyChecksPath,
 	}
-	return createArchive(confSearchPaths, local, zipFilePath, logFilePath, profile)
 }
 
-func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath, logFilePath string, profile *Profile) (string, error) {
+func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath, logFilePath string, profile *Profile, trigger *Trigger) (string, error) {
 	tempDir, err := createTempDir()
 	if err != nil {
 		return "", err
@@ -185,6 +188,13 @@ func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath, logFile
 		}
 	}
 
+	if trigger != nil {
+		err = writeTrigger(tempDir, hostname, trigger)
+		if err != nil {
+			log.Errorf("Could not write the trigger summary: %s", err)
+		}
+	}
+
 	// auth token permissions info (only if existing)
 	if _, err = os.Stat(security.GetAuthTokenFilepath()); err == nil && !os.IsNotExist(err) {
 		permsInfos.add(security.GetAuthTokenFilepath())
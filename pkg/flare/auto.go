@@ -0,0 +1,217 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const autoFlareCheckInterval = 30 * time.Second
+
+// AutoFlare creates flares automatically to capture the state of the agent while an
+// incident is happening: when components stay unhealthy, when components are restarted
+// and on a schedule. The archives are kept in a bounded local directory.
+type AutoFlare struct {
+	dir               string
+	maxArchives       int
+	unhealthyDuration time.Duration
+	onRestart         bool
+	schedule          *cronSchedule
+	minInterval       time.Duration
+
+	getHealth     func() (health.Status, error)
+	getRestarts   func() map[string]int
+	createArchive func(trigger *Trigger) (string, error)
+
+	unhealthySince map[string]time.Time
+	reported       map[string]bool
+	restarts       map[string]int
+	latestRestarts map[string]int
+	nextScheduled  time.Time
+	lastFlare      time.Time
+
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// NewAutoFlare returns an AutoFlare configured with the `auto_flare_*` settings
+func NewAutoFlare(distPath, pyChecksPath, logFilePath string) (*AutoFlare, error) {
+	a := &AutoFlare{
+		dir:               GetTriggeredArchivesPath(),
+		maxArchives:       config.Datadog.GetInt("auto_flare_max_archives"),
+		unhealthyDuration: time.Duration(config.Datadog.GetInt("auto_flare_unhealthy_duration")) * time.Minute,
+		onRestart:         config.Datadog.GetBool("auto_flare_on_restart"),
+		minInterval:       time.Duration(config.Datadog.GetInt("auto_flare_min_interval")) * time.Minute,
+		getHealth:         health.GetReadyNonBlocking,
+		getRestarts:       health.GetRestarts,
+		unhealthySince:    make(map[string]time.Time),
+		reported:          make(map[string]bool),
+		stop:              make(chan struct{}),
+	}
+	a.createArchive = func(trigger *Trigger) (string, error) {
+		return CreateTriggeredArchive(a.dir, a.maxArchives, distPath, pyChecksPath, logFilePath, trigger)
+	}
+
+	if expr := config.Datadog.GetString("auto_flare_schedule"); expr != "" {
+		schedule, err := parseCronSchedule(expr)
+		if err != nil {
+			return nil, err
+		}
+		a.schedule = schedule
+	}
+	return a, nil
+}
+
+// Start starts checking the triggers in the background
+func (a *AutoFlare) Start() {
+	a.restarts = a.getRestarts()
+	if a.schedule != nil {
+		a.nextScheduled = a.schedule.next(time.Now())
+	}
+	log.Infof("Flares will be created automatically in %s", a.dir)
+
+	a.stopped.Add(1)
+	go func() {
+		defer a.stopped.Done()
+		ticker := time.NewTicker(autoFlareCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				a.check(now)
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops checking the triggers, it waits for the flare being created if any
+func (a *AutoFlare) Stop() {
+	close(a.stop)
+	a.stopped.Wait()
+}
+
+// check creates a flare if a trigger fired, at most one every minInterval. The triggers
+// stay pending until a flare is created for them.
+func (a *AutoFlare) check(now time.Time) {
+	trigger := a.nextTrigger(now)
+	if trigger == nil {
+		return
+	}
+	if !a.lastFlare.IsZero() && now.Sub(a.lastFlare) < a.minInterval {
+		log.Infof("Not creating a flare (%s), the last one was created less than %s ago", trigger.Message, a.minInterval)
+		return
+	}
+
+	log.Infof("Creating a flare: %s", trigger.Message)
+	a.lastFlare = now
+	filePath, err := a.createArchive(trigger)
+	if err != nil {
+		log.Errorf("Could not create the flare: %s", err)
+		return
+	}
+	switch trigger.Reason {
+	case TriggerUnhealthy:
+		for _, component := range trigger.Components {
+			a.reported[component] = true
+		}
+	case TriggerRestart:
+		a.restarts = a.latestRestarts
+	case TriggerSchedule:
+		a.nextScheduled = a.schedule.next(now)
+	}
+	log.Infof("Flare created: %s", filePath)
+}
+
+// nextTrigger returns the trigger which fired, if any. It doesn't acknowledge the
+// triggers, check does once the flare is created.
+func (a *AutoFlare) nextTrigger(now time.Time) *Trigger {
+	unhealthy := a.checkUnhealthy(now)
+	restarted := a.checkRestarts()
+	scheduled := a.checkSchedule(now)
+
+	var trigger *Trigger
+	switch {
+	case len(unhealthy) > 0:
+		trigger = NewTrigger(TriggerUnhealthy, fmt.Sprintf("components unhealthy for more than %s: %s", a.unhealthyDuration, strings.Join(unhealthy, ", ")), unhealthy)
+	case len(restarted) > 0:
+		trigger = NewTrigger(TriggerRestart, fmt.Sprintf("components restarted: %s", strings.Join(restarted, ", ")), restarted)
+	case scheduled:
+		trigger = NewTrigger(TriggerSchedule, "scheduled flare", nil)
+	default:
+		return nil
+	}
+	trigger.Time = now
+	return trigger
+}
+
+// checkUnhealthy returns the components unhealthy for more than unhealthyDuration which
+// haven't been reported yet. A component is reported again after it becomes healthy.
+func (a *AutoFlare) checkUnhealthy(now time.Time) []string {
+	if a.unhealthyDuration <= 0 {
+		return nil
+	}
+	status, err := a.getHealth()
+	if err != nil {
+		log.Debugf("Agent health unknown: %s", err)
+		return nil
+	}
+
+	unhealthy := make(map[string]bool, len(status.Unhealthy))
+	for _, component := range status.Unhealthy {
+		unhealthy[component] = true
+		if _, found := a.unhealthySince[component]; !found {
+			a.unhealthySince[component] = now
+		}
+	}
+	for component := range a.unhealthySince {
+		if !unhealthy[component] {
+			delete(a.unhealthySince, component)
+			delete(a.reported, component)
+		}
+	}
+
+	var components []string
+	for component, since := range a.unhealthySince {
+		if !a.reported[component] && now.Sub(since) >= a.unhealthyDuration {
+			components = append(components, component)
+		}
+	}
+	sort.Strings(components)
+	return components
+}
+
+// checkRestarts returns the components restarted since the last flare created for a restart
+func (a *AutoFlare) checkRestarts() []string {
+	if !a.onRestart {
+		return nil
+	}
+	restarts := a.getRestarts()
+
+	var components []string
+	for component, count := range restarts {
+		if count > a.restarts[component] {
+			components = append(components, component)
+		}
+	}
+	a.latestRestarts = restarts
+	sort.Strings(components)
+	return components
+}
+
+// checkSchedule returns whether the scheduled time is reached
+func (a *AutoFlare) checkSchedule(now time.Time) bool {
+	return a.schedule != nil && !a.nextScheduled.IsZero() && !now.Before(a.nextScheduled)
+}
@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+)
+
+type autoFlareTest struct {
+	status   health.Status
+	restarts map[string]int
+	triggers []*Trigger
+}
+
+func newTestAutoFlare(test *autoFlareTest) *AutoFlare {
+	test.restarts = make(map[string]int)
+	a := &AutoFlare{
+		unhealthyDuration: 10 * time.Minute,
+		onRestart:         true,
+		minInterval:       time.Hour,
+		getHealth:         func() (health.Status, error) { return test.status, nil },
+		getRestarts: func() map[string]int {
+			restarts := make(map[string]int)
+			for name, count := range test.restarts {
+				restarts[name] = count
+			}
+			return restarts
+		},
+		createArchive: func(trigger *Trigger) (string, error) {
+			test.triggers = append(test.triggers, trigger)
+			return fmt.Sprintf("flare-%d.zip", len(test.triggers)), nil
+		},
+		unhealthySince: make(map[string]time.Time),
+		reported:       make(map[string]bool),
+		restarts:       make(map[string]int),
+	}
+	return a
+}
+
+func TestAutoFlareUnhealthy(t *testing.T) {
+	test := &autoFlareTest{}
+	a := newTestAutoFlare(test)
+	a.minInterval = 0
+	now := time.Now()
+
+	test.status.Unhealthy = []string{"forwarder"}
+	a.check(now)
+	a.check(now.Add(9 * time.Minute))
+	assert.Empty(t, test.triggers)
+
+	a.check(now.Add(10 * time.Minute))
+	require.Len(t, test.triggers, 1)
+	assert.Equal(t, TriggerUnhealthy, test.triggers[0].Reason)
+	assert.Equal(t, []string{"forwarder"}, test.triggers[0].Components)
+
+	// The component is reported once while it stays unhealthy
+	a.check(now.Add(30 * time.Minute))
+	assert.Len(t, test.triggers, 1)
+
+	// And reported again if it becomes unhealthy again
+	test.status.Unhealthy = nil
+	a.check(now.Add(31 * time.Minute))
+	test.status.Unhealthy = []string{"forwarder"}
+	a.check(now.Add(32 * time.Minute))
+	a.check(now.Add(42 * time.Minute))
+	assert.Len(t, test.triggers, 2)
+}
+
+func TestAutoFlareRestart(t *testing.T) {
+	test := &autoFlareTest{}
+	a := newTestAutoFlare(test)
+	now := time.Now()
+
+	a.check(now)
+	assert.Empty(t, test.triggers)
+
+	test.restarts["jmxfetch"] = 1
+	a.check(now.Add(time.Minute))
+	require.Len(t, test.triggers, 1)
+	assert.Equal(t, TriggerRestart, test.triggers[0].Reason)
+	assert.Equal(t, []string{"jmxfetch"}, test.triggers[0].Components)
+
+	a.check(now.Add(2 * time.Minute))
+	assert.Len(t, test.triggers, 1)
+
+	a.onRestart = false
+	test.restarts["jmxfetch"] = 2
+	a.check(now.Add(3 * time.Minute))
+	assert.Len(t, test.triggers, 1)
+}
+
+func TestAutoFlareSchedule(t *testing.T) {
+	test := &autoFlareTest{}
+	a := newTestAutoFlare(test)
+	a.minInterval = 0
+	now := time.Date(2020, time.July, 15, 2, 59, 0, 0, time.UTC)
+
+	var err error
+	a.schedule, err = parseCronSchedule("0 3 * * *")
+	require.NoError(t, err)
+	a.nextScheduled = a.schedule.next(now)
+
+	a.check(now.Add(30 * time.Second))
+	assert.Empty(t, test.triggers)
+
+	a.check(now.Add(time.Minute))
+	require.Len(t, test.triggers, 1)
+	assert.Equal(t, TriggerSchedule, test.triggers[0].Reason)
+	assert.Equal(t, time.Date(2020, time.July, 16, 3, 0, 0, 0, time.UTC), a.nextScheduled)
+
+	a.check(now.Add(2 * time.Minute))
+	assert.Len(t, test.triggers, 1)
+}
+
+func TestAutoFlareMinInterval(t *testing.T) {
+	test := &autoFlareTest{}
+	a := newTestAutoFlare(test)
+	now := time.Now()
+
+	test.restarts["jmxfetch"] = 1
+	a.check(now)
+	assert.Len(t, test.triggers, 1)
+
+	test.restarts["jmxfetch"] = 2
+	a.check(now.Add(time.Minute))
+	assert.Len(t, test.triggers, 1)
+
+	// The unhealthy components are reported once the minimum interval has elapsed
+	test.status.Unhealthy = []string{"forwarder"}
+	a.check(now.Add(2 * time.Minute))
+	a.check(now.Add(20 * time.Minute))
+	assert.Len(t, test.triggers, 1)
+	a.check(now.Add(time.Hour))
+	require.Len(t, test.triggers, 2)
+	assert.Equal(t, TriggerUnhealthy, test.triggers[1].Reason)
+
+	// The restart stays pending until a flare is created for it
+	a.check(now.Add(90 * time.Minute))
+	assert.Len(t, test.triggers, 2)
+	a.check(now.Add(2 * time.Hour))
+	require.Len(t, test.triggers, 3)
+	assert.Equal(t, TriggerRestart, test.triggers[2].Reason)
+	assert.Equal(t, []string{"jmxfetch"}, test.triggers[2].Components)
+	a.check(now.Add(4 * time.Hour))
+	assert.Len(t, test.triggers, 3)
+}
+
+func TestAutoFlareMinIntervalSchedule(t *testing.T) {
+	test := &autoFlareTest{}
+	a := newTestAutoFlare(test)
+	now := time.Date(2020, time.July, 15, 2, 30, 0, 0, time.UTC)
+
+	var err error
+	a.schedule, err = parseCronSchedule("0 3 * * *")
+	require.NoError(t, err)
+	a.nextScheduled = a.schedule.next(now)
+
+	test.restarts["jmxfetch"] = 1
+	a.check(now)
+	require.Len(t, test.triggers, 1)
+
+	// The scheduled flare is created once the minimum interval has elapsed
+	a.check(now.Add(30 * time.Minute))
+	assert.Len(t, test.triggers, 1)
+	a.check(now.Add(time.Hour))
+	require.Len(t, test.triggers, 2)
+	assert.Equal(t, TriggerSchedule, test.triggers[1].Reason)
+	assert.Equal(t, time.Date(2020, time.July, 16, 3, 0, 0, 0, time.UTC), a.nextScheduled)
+}
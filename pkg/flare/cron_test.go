@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronField(t *testing.T) {
+	for field, expected := range map[string][]int{
+		"*":       {0, 1, 2, 3, 4, 5, 6, 7},
+		"3":       {3},
+		"1,5":     {1, 5},
+		"2-4":     {2, 3, 4},
+		"*/3":     {0, 3, 6},
+		"1-7/2":   {1, 3, 5, 7},
+		"4/2":     {4, 6},
+		"0,2-3,7": {0, 2, 3, 7},
+	} {
+		var bits uint64
+		for _, v := range expected {
+			bits |= 1 << uint(v)
+		}
+		actual, err := parseCronField(field, 0, 7)
+		require.NoError(t, err, field)
+		assert.Equal(t, bits, actual, field)
+	}
+
+	for _, field := range []string{"", "8", "a", "3-1", "*/0", "1-", "-1"} {
+		_, err := parseCronField(field, 0, 7)
+		assert.Error(t, err, field)
+	}
+}
+
+func TestParseCronSchedule(t *testing.T) {
+	_, err := parseCronSchedule("0 3 * *")
+	assert.Error(t, err)
+	_, err = parseCronSchedule("60 3 * * *")
+	assert.Error(t, err)
+	_, err = parseCronSchedule("@yearly")
+	assert.Error(t, err)
+
+	s, err := parseCronSchedule("@daily")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), s.minute)
+	assert.Equal(t, uint64(1), s.hour)
+
+	// 7 is Sunday
+	s, err = parseCronSchedule("0 0 * * 7")
+	require.NoError(t, err)
+	assert.NotZero(t, s.dayOfWeek&1)
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	// Wednesday
+	now := time.Date(2020, time.July, 15, 10, 30, 20, 0, time.UTC)
+
+	for expr, expected := range map[string]time.Time{
+		"* * * * *":    time.Date(2020, time.July, 15, 10, 31, 0, 0, time.UTC),
+		"*/15 * * * *": time.Date(2020, time.July, 15, 10, 45, 0, 0, time.UTC),
+		"30 * * * *":   time.Date(2020, time.July, 15, 11, 30, 0, 0, time.UTC),
+		"0 3 * * *":    time.Date(2020, time.July, 16, 3, 0, 0, 0, time.UTC),
+		"0 0 * * 1":    time.Date(2020, time.July, 20, 0, 0, 0, 0, time.UTC),
+		"0 0 1 * *":    time.Date(2020, time.August, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 1 1 *":    time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":   time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		// either the 20th or a Friday
+		"0 0 20 * 5": time.Date(2020, time.July, 17, 0, 0, 0, 0, time.UTC),
+	} {
+		s, err := parseCronSchedule(expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, expected, s.next(now), expr)
+	}
+
+	s, err := parseCronSchedule("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.next(now).IsZero())
+}
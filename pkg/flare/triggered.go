@@ -0,0 +1,219 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	triggeredArchivePrefix = "datadog-agent-"
+	triggeredArchiveExt    = ".zip"
+	triggerSummaryExt      = ".json"
+	triggerSummaryFilename = "trigger.json"
+)
+
+// TriggerReason is the reason why a flare was created automatically
+type TriggerReason string
+
+const (
+	// TriggerUnhealthy is used when components stayed unhealthy
+	TriggerUnhealthy TriggerReason = "unhealthy"
+	// TriggerRestart is used when components were restarted
+	TriggerRestart TriggerReason = "restart"
+	// TriggerSchedule is used for the flares created on the configured schedule
+	TriggerSchedule TriggerReason = "schedule"
+)
+
+// Trigger is the summary of what triggered the creation of a flare. It is
+// added to the archive and stored next to it.
+type Trigger struct {
+	Reason       TriggerReason `json:"reason"`
+	Message      string        `json:"message"`
+	Components   []string      `json:"components,omitempty"`
+	Time         time.Time     `json:"time"`
+	AgentVersion string        `json:"agent_version"`
+}
+
+// NewTrigger returns the summary of a trigger happening now
+func NewTrigger(reason TriggerReason, message string, components []string) *Trigger {
+	return &Trigger{
+		Reason:       reason,
+		Message:      message,
+		Components:   components,
+		Time:         time.Now(),
+		AgentVersion: version.AgentVersion,
+	}
+}
+
+// LocalArchive is a flare archive created automatically and kept in the local flare directory
+type LocalArchive struct {
+	Name    string
+	Path    string
+	Size    int64
+	Created time.Time
+	// Trigger is nil if the summary of the trigger can't be read
+	Trigger *Trigger
+}
+
+// GetTriggeredArchivesPath returns the directory where the flares created automatically are kept
+func GetTriggeredArchivesPath() string {
+	if dir := config.Datadog.GetString("auto_flare_path"); dir != "" {
+		return dir
+	}
+	return filepath.Join(config.Datadog.GetString("run_path"), "flares")
+}
+
+// CreateTriggeredArchive creates a flare archive including the summary of its trigger in
+// dir, then removes the oldest archives of dir to keep at most maxArchives of them
+func CreateTriggeredArchive(dir string, maxArchives int, distPath, pyChecksPath, logFilePath string, trigger *Trigger) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("unable to create the flare directory %s: %v", dir, err)
+	}
+
+	name := fmt.Sprintf("%s%s-%s%s", triggeredArchivePrefix, trigger.Time.Format("2006-01-02-15-04-05"), trigger.Reason, triggeredArchiveExt)
+	zipFilePath := filepath.Join(dir, name)
+	filePath, err := createArchive(getConfSearchPaths(distPath, pyChecksPath), false, zipFilePath, logFilePath, nil, trigger)
+	if err != nil {
+		return "", err
+	}
+
+	// The summary is stored next to the archive to list the archives without opening them
+	summary, err := json.MarshalIndent(trigger, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(summaryPath(filePath), summary, 0600); err != nil {
+		log.Warnf("Could not write the trigger summary of %s: %s", filePath, err)
+	}
+
+	if err := rotateTriggeredArchives(dir, maxArchives); err != nil {
+		log.Warnf("Could not remove the oldest flares of %s: %s", dir, err)
+	}
+	return filePath, nil
+}
+
+// ListTriggeredArchives returns the flare archives of dir, the most recent first
+func ListTriggeredArchives(dir string) ([]LocalArchive, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, triggeredArchivePrefix+"*"+triggeredArchiveExt))
+	if err != nil {
+		return nil, err
+	}
+
+	archives := make([]LocalArchive, 0, len(paths))
+	for _, path := range paths {
+		archive, err := readTriggeredArchive(path)
+		if err != nil {
+			log.Debugf("Ignoring the flare %s: %s", path, err)
+			continue
+		}
+		archives = append(archives, archive)
+	}
+
+	sort.Slice(archives, func(i, j int) bool {
+		if archives[i].Created.Equal(archives[j].Created) {
+			return archives[i].Name > archives[j].Name
+		}
+		return archives[i].Created.After(archives[j].Created)
+	})
+	return archives, nil
+}
+
+// GetTriggeredArchive returns the flare archive of dir with the given name
+func GetTriggeredArchive(dir, name string) (LocalArchive, error) {
+	if name == "" || filepath.Base(name) != name || !strings.HasSuffix(name, triggeredArchiveExt) {
+		return LocalArchive{}, fmt.Errorf("invalid flare name %q", name)
+	}
+	return readTriggeredArchive(filepath.Join(dir, name))
+}
+
+func readTriggeredArchive(path string) (LocalArchive, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return LocalArchive{}, err
+	}
+	if info.IsDir() {
+		return LocalArchive{}, fmt.Errorf("%s is a directory", path)
+	}
+
+	archive := LocalArchive{
+		Name:    info.Name(),
+		Path:    path,
+		Size:    info.Size(),
+		Created: info.ModTime(),
+	}
+	if summary, err := ioutil.ReadFile(summaryPath(path)); err == nil {
+		trigger := &Trigger{}
+		if err := json.Unmarshal(summary, trigger); err == nil {
+			archive.Trigger = trigger
+			archive.Created = trigger.Time
+		}
+	}
+	return archive, nil
+}
+
+// rotateTriggeredArchives removes the oldest archives of dir to keep at most maxArchives of them,
+// a maxArchives lower than 1 keeps all of them
+func rotateTriggeredArchives(dir string, maxArchives int) error {
+	if maxArchives < 1 {
+		return nil
+	}
+	archives, err := ListTriggeredArchives(dir)
+	if err != nil {
+		return err
+	}
+	if len(archives) <= maxArchives {
+		return nil
+	}
+
+	for _, archive := range archives[maxArchives:] {
+		log.Infof("Removing the flare %s", archive.Path)
+		if err := os.Remove(archive.Path); err != nil {
+			return err
+		}
+		if err := os.Remove(summaryPath(archive.Path)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func summaryPath(archivePath string) string {
+	return strings.TrimSuffix(archivePath, triggeredArchiveExt) + triggerSummaryExt
+}
+
+func writeTrigger(tempDir, hostname string, trigger *Trigger) error {
+	data, err := json.MarshalIndent(trigger, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f := filepath.Join(tempDir, hostname, triggerSummaryFilename)
+	err = ensureParentDirsExist(f)
+	if err != nil {
+		return err
+	}
+
+	w, err := newRedactingWriter(f, os.ModePerm, true)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTriggeredArchive writes a fake archive and its trigger summary in dir
+func writeTriggeredArchive(t *testing.T, dir string, trigger *Trigger) string {
+	name := fmt.Sprintf("datadog-agent-%s-%s.zip", trigger.Time.Format("2006-01-02-15-04-05"), trigger.Reason)
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte("archive"), 0600))
+	summary, err := json.Marshal(trigger)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(summaryPath(path), summary, 0600))
+	return name
+}
+
+func TestListTriggeredArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flares")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2020, time.July, 15, 10, 30, 0, 0, time.UTC)
+	older := writeTriggeredArchive(t, dir, &Trigger{Reason: TriggerSchedule, Time: start})
+	newer := writeTriggeredArchive(t, dir, &Trigger{Reason: TriggerRestart, Message: "components restarted: jmxfetch", Components: []string{"jmxfetch"}, Time: start.Add(time.Hour)})
+	// Other files are ignored
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other.zip"), []byte("other"), 0600))
+
+	archives, err := ListTriggeredArchives(dir)
+	require.NoError(t, err)
+	require.Len(t, archives, 2)
+	assert.Equal(t, newer, archives[0].Name)
+	assert.Equal(t, filepath.Join(dir, newer), archives[0].Path)
+	assert.Equal(t, int64(len("archive")), archives[0].Size)
+	require.NotNil(t, archives[0].Trigger)
+	assert.Equal(t, TriggerRestart, archives[0].Trigger.Reason)
+	assert.Equal(t, []string{"jmxfetch"}, archives[0].Trigger.Components)
+	assert.True(t, start.Add(time.Hour).Equal(archives[0].Created))
+	assert.Equal(t, older, archives[1].Name)
+
+	// The archives without summary are listed too
+	require.NoError(t, os.Remove(summaryPath(archives[1].Path)))
+	archive, err := GetTriggeredArchive(dir, older)
+	require.NoError(t, err)
+	assert.Nil(t, archive.Trigger)
+	archives, err = ListTriggeredArchives(dir)
+	require.NoError(t, err)
+	assert.Len(t, archives, 2)
+}
+
+func TestGetTriggeredArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flares")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := writeTriggeredArchive(t, dir, &Trigger{Reason: TriggerUnhealthy, Time: time.Now()})
+	archive, err := GetTriggeredArchive(dir, name)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, name), archive.Path)
+	assert.Equal(t, TriggerUnhealthy, archive.Trigger.Reason)
+
+	for _, invalid := range []string{"", "../" + name, "/etc/passwd", "datadog-agent.json", "missing.zip"} {
+		_, err = GetTriggeredArchive(dir, invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRotateTriggeredArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flares")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2020, time.July, 15, 10, 30, 0, 0, time.UTC)
+	var names []string
+	for i := 0; i < 4; i++ {
+		names = append(names, writeTriggeredArchive(t, dir, &Trigger{Reason: TriggerSchedule, Time: start.Add(time.Duration(i) * time.Hour)}))
+	}
+
+	require.NoError(t, rotateTriggeredArchives(dir, 0))
+	archives, err := ListTriggeredArchives(dir)
+	require.NoError(t, err)
+	assert.Len(t, archives, 4)
+
+	require.NoError(t, rotateTriggeredArchives(dir, 2))
+	archives, err = ListTriggeredArchives(dir)
+	require.NoError(t, err)
+	require.Len(t, archives, 2)
+	assert.Equal(t, names[3], archives[0].Name)
+	assert.Equal(t, names[2], archives[1].Name)
+
+	// The summaries of the removed archives are removed too
+	_, err = os.Stat(summaryPath(filepath.Join(dir, names[0])))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -31,30 +31,51 @@ const (
 	PayloadTypeReplicaSet = "replicaset"
 	// PayloadTypeService is the name of the service payload type
 	PayloadTypeService = "service"
+	// PayloadTypeNode is the name of the node payload type
+	PayloadTypeNode = "node"
+	// PayloadTypeDaemonSet is the name of the daemon set payload type
+	PayloadTypeDaemonSet = "daemonset"
+	// PayloadTypeStatefulSet is the name of the stateful set payload type
+	PayloadTypeStatefulSet = "statefulset"
+	// PayloadTypeJob is the name of the job payload type
+	PayloadTypeJob = "job"
+	// PayloadTypeCronJob is the name of the cron job payload type
+	PayloadTypeCronJob = "cronjob"
+	// PayloadTypePersistentVolume is the name of the persistent volume payload type
+	PayloadTypePersistentVolume = "persistentvolume"
+	// PayloadTypePersistentVolumeClaim is the name of the persistent volume claim payload type
+	PayloadTypePersistentVolumeClaim = "persistentvolumeclaim"
 )
 
 var (
-	forwarderExpvars              = expvar.NewMap("forwarder")
-	connectionEvents              = expvar.Map{}
-	transactionsExpvars           = expvar.Map{}
-	transactionsSeries            = expvar.Int{}
-	transactionsEvents            = expvar.Int{}
-	transactionsServiceChecks     = expvar.Int{}
-	transactionsSketchSeries      = expvar.Int{}
-	transactionsHostMetadata      = expvar.Int{}
-	transactionsMetadata          = expvar.Int{}
-	transactionsTimeseriesV1      = expvar.Int{}
-	transactionsCheckRunsV1       = expvar.Int{}
-	transactionsIntakeV1          = expvar.Int{}
-	transactionsIntakeProcesses   = expvar.Int{}
-	transactionsIntakeRTProcesses = expvar.Int{}
-	transactionsIntakeContainer   = expvar.Int{}
-	transactionsIntakeRTContainer = expvar.Int{}
-	transactionsIntakeConnections = expvar.Int{}
-	transactionsIntakePod         = expvar.Int{}
-	transactionsIntakeDeployment  = expvar.Int{}
-	transactionsIntakeReplicaSet  = expvar.Int{}
-	transactionsIntakeService     = expvar.Int{}
+	forwarderExpvars                        = expvar.NewMap("forwarder")
+	connectionEvents                        = expvar.Map{}
+	transactionsExpvars                     = expvar.Map{}
+	transactionsSeries                      = expvar.Int{}
+	transactionsEvents                      = expvar.Int{}
+	transactionsServiceChecks               = expvar.Int{}
+	transactionsSketchSeries                = expvar.Int{}
+	transactionsHostMetadata                = expvar.Int{}
+	transactionsMetadata                    = expvar.Int{}
+	transactionsTimeseriesV1                = expvar.Int{}
+	transactionsCheckRunsV1                 = expvar.Int{}
+	transactionsIntakeV1                    = expvar.Int{}
+	transactionsIntakeProcesses             = expvar.Int{}
+	transactionsIntakeRTProcesses           = expvar.Int{}
+	transactionsIntakeContainer             = expvar.Int{}
+	transactionsIntakeRTContainer           = expvar.Int{}
+	transactionsIntakeConnections           = expvar.Int{}
+	transactionsIntakePod                   = expvar.Int{}
+	transactionsIntakeDeployment            = expvar.Int{}
+	transactionsIntakeReplicaSet            = expvar.Int{}
+	transactionsIntakeService               = expvar.Int{}
+	transactionsIntakeNode                  = expvar.Int{}
+	transactionsIntakeDaemonSet             = expvar.Int{}
+	transactionsIntakeStatefulSet           = expvar.Int{}
+	transactionsIntakeJob                   = expvar.Int{}
+	transactionsIntakeCronJob               = expvar.Int{}
+	transactionsIntakePersistentVolume      = expvar.Int{}
+	transactionsIntakePersistentVolumeClaim = expvar.Int{}
 
 	tlm = telemetry.NewCounter("forwarder", "transactions",
 		[]string{"endpoint", "route"}, "Forwarder telemetry")
@@ -103,6 +124,13 @@ func init() {
 	transactionsExpvars.Set("Deployments", &transactionsIntakeDeployment)
 	transactionsExpvars.Set("ReplicaSets", &transactionsIntakeReplicaSet)
 	transactionsExpvars.Set("Services", &transactionsIntakeService)
+	transactionsExpvars.Set("Nodes", &transactionsIntakeNode)
+	transactionsExpvars.Set("DaemonSets", &transactionsIntakeDaemonSet)
+	transactionsExpvars.Set("StatefulSets", &transactionsIntakeStatefulSet)
+	transactionsExpvars.Set("Jobs", &transactionsIntakeJob)
+	transactionsExpvars.Set("CronJobs", &transactionsIntakeCronJob)
+	transactionsExpvars.Set("PersistentVolumes", &transactionsIntakePersistentVolume)
+	transactionsExpvars.Set("PersistentVolumeClaims", &transactionsIntakePersistentVolumeClaim)
 	initDomainForwarderExpvars()
 	initTransactionExpvars()
 	initForwarderHealthExpvars()
@@ -489,6 +517,20 @@ func (f *DefaultForwarder) SubmitOrchestratorChecks(payload Payloads, extra http
 		transactionsIntakeReplicaSet.Add(1)
 	case PayloadTypeService:
 		transactionsIntakeService.Add(1)
+	case PayloadTypeNode:
+		transactionsIntakeNode.Add(1)
+	case PayloadTypeDaemonSet:
+		transactionsIntakeDaemonSet.Add(1)
+	case PayloadTypeStatefulSet:
+		transactionsIntakeStatefulSet.Add(1)
+	case PayloadTypeJob:
+		transactionsIntakeJob.Add(1)
+	case PayloadTypeCronJob:
+		transactionsIntakeCronJob.Add(1)
+	case PayloadTypePersistentVolume:
+		transactionsIntakePersistentVolume.Add(1)
+	case PayloadTypePersistentVolumeClaim:
+		transactionsIntakePersistentVolumeClaim.Add(1)
 	}
 
 	return f.submitProcessLikePayload(orchestratorEndpoint, payload, extra, true)
@@ -149,6 +149,7 @@ func (j *JMXFetch) Monitor() {
 		default:
 			// restart
 			log.Warnf("JMXFetch process had to be restarted.")
+			health.NotifyRestart("jmxfetch")
 			j.Start(false) //nolint:errcheck
 		}
 	}
@@ -14,6 +14,7 @@ import (
 
 	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/snmp/traps"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/eventlogs"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -23,6 +24,9 @@ const ContainerCollectAll = "container_collect_all"
 // SnmpTraps is the name of the integration that collects logs from SNMP traps received by the Agent
 const SnmpTraps = "snmp_traps"
 
+// KubernetesEvents is the name of the integration that collects the Kubernetes events as logs
+const KubernetesEvents = "kubernetes_events"
+
 // logs-intake endpoint prefix.
 const (
 	tcpEndpointPrefix  = "agent-intake.logs."
@@ -71,6 +75,16 @@ func DefaultSources() []*LogSource {
 		sources = append(sources, source)
 	}
 
+	if eventlogs.IsEnabled() {
+		// Append a new source to forward the Kubernetes events collected by the kubernetes_apiserver check as logs.
+		source := NewLogSource(KubernetesEvents, &LogsConfig{
+			Type:    KubernetesEventsType,
+			Service: "kubernetes",
+			Source:  "kubernetes",
+		})
+		sources = append(sources, source)
+	}
+
 	return sources
 }
 
@@ -12,13 +12,14 @@ import (
 
 // Logs source types
 const (
-	TCPType          = "tcp"
-	UDPType          = "udp"
-	FileType         = "file"
-	DockerType       = "docker"
-	JournaldType     = "journald"
-	WindowsEventType = "windows_event"
-	SnmpTrapsType    = "snmp_traps"
+	TCPType              = "tcp"
+	UDPType              = "udp"
+	FileType             = "file"
+	DockerType           = "docker"
+	JournaldType         = "journald"
+	WindowsEventType     = "windows_event"
+	SnmpTrapsType        = "snmp_traps"
+	KubernetesEventsType = "kubernetes_events"
 )
 
 // LogsConfig represents a log source config, which can be for instance
@@ -21,6 +21,8 @@ func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 		{Type: DockerType},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
 		{Type: SnmpTrapsType},
+		{Type: FileType, Path: "/var/log/foo.log", ProcessingRules: []*ProcessingRule{{Name: "errors", Type: ExtractMetric, Pattern: "ERROR", MetricName: "app.errors"}}},
+		{Type: FileType, Path: "/var/log/foo.log", ProcessingRules: []*ProcessingRule{{Name: "latency", Type: ExtractMetric, Pattern: "took (?P<duration>\\d+)ms", MetricName: "app.latency", MetricType: DistributionMetric, ValueGroup: "duration"}}},
 	}
 
 	for _, config := range validConfigs {
@@ -42,6 +44,11 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch, Pattern: ".*"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Pattern: ".*"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExtractMetric, Pattern: ".*"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExtractMetric, Pattern: ".*", MetricName: "foo", MetricType: "gauge"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExtractMetric, Pattern: ".*", MetricName: "foo", MetricType: DistributionMetric}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExtractMetric, Pattern: "(?P<value>\\d+)", MetricName: "foo", MetricType: DistributionMetric, ValueGroup: "duration"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExtractMetric, Pattern: "(?P<value>\\d+)", MetricName: "foo", ValueGroup: "value"}}},
 	}
 
 	for _, config := range invalidConfigs {
@@ -16,6 +16,13 @@ const (
 	IncludeAtMatch = "include_at_match"
 	MaskSequences  = "mask_sequences"
 	MultiLine      = "multi_line"
+	ExtractMetric  = "extract_metric"
+)
+
+// Metric types supported by extract_metric processing rules
+const (
+	CountMetric        = "count"
+	DistributionMetric = "distribution"
 )
 
 // ProcessingRule defines an exclusion or a masking rule to
@@ -25,6 +32,10 @@ type ProcessingRule struct {
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
 	Pattern            string
+	MetricName         string `mapstructure:"metric_name" json:"metric_name"`
+	MetricType         string `mapstructure:"metric_type" json:"metric_type"`
+	ValueGroup         string `mapstructure:"value_group" json:"value_group"`
+	DropLine           bool   `mapstructure:"drop_line" json:"drop_line"`
 	// TODO: should be moved out
 	Regex       *regexp.Regexp
 	Placeholder []byte
@@ -42,7 +53,7 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		}
 
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
+		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine, ExtractMetric:
 			break
 		case "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
@@ -53,10 +64,40 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		if rule.Pattern == "" {
 			return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
 		}
-		_, err := regexp.Compile(rule.Pattern)
+		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
 		}
+
+		if rule.Type == ExtractMetric {
+			if err := validateExtractMetricRule(rule, re); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateExtractMetricRule makes sure an extract_metric rule has a metric name,
+// a supported metric type and, for distributions, a capture group holding the value.
+func validateExtractMetricRule(rule *ProcessingRule, re *regexp.Regexp) error {
+	if rule.MetricName == "" {
+		return fmt.Errorf("no metric_name provided for processing rule: %s", rule.Name)
+	}
+	switch rule.MetricType {
+	case "", CountMetric:
+		if rule.ValueGroup != "" {
+			return fmt.Errorf("value_group is only supported for distribution metrics in processing rule: %s", rule.Name)
+		}
+	case DistributionMetric:
+		if rule.ValueGroup == "" {
+			return fmt.Errorf("no value_group provided for distribution processing rule: %s", rule.Name)
+		}
+		if !hasNamedGroup(re, rule.ValueGroup) {
+			return fmt.Errorf("value_group %s is not a named group of the pattern of processing rule: %s", rule.ValueGroup, rule.Name)
+		}
+	default:
+		return fmt.Errorf("metric_type %s is not supported for processing rule `%s`", rule.MetricType, rule.Name)
 	}
 	return nil
 }
@@ -69,7 +110,7 @@ func CompileProcessingRules(rules []*ProcessingRule) error {
 			return err
 		}
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch:
+		case ExcludeAtMatch, IncludeAtMatch, ExtractMetric:
 			rule.Regex = re
 		case MaskSequences:
 			rule.Regex = re
@@ -83,3 +124,13 @@ func CompileProcessingRules(rules []*ProcessingRule) error {
 	}
 	return nil
 }
+
+// hasNamedGroup returns true if the regular expression declares a capture group with this name.
+func hasNamedGroup(re *regexp.Regexp, name string) bool {
+	for _, groupName := range re.SubexpNames() {
+		if groupName == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kubeevents
+
+import (
+	"encoding/json"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/eventlogs"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Tailer consumes the Kubernetes events and forwards them as structured logs.
+type Tailer struct {
+	source     *config.LogSource
+	inputChan  eventlogs.Channel
+	outputChan chan *message.Message
+	stop       chan interface{}
+	done       chan interface{}
+}
+
+// NewTailer returns a new Tailer
+func NewTailer(source *config.LogSource, inputChan eventlogs.Channel, outputChan chan *message.Message) *Tailer {
+	return &Tailer{
+		source:     source,
+		inputChan:  inputChan,
+		outputChan: outputChan,
+		stop:       make(chan interface{}, 1),
+		done:       make(chan interface{}, 1),
+	}
+}
+
+// Start starts the tailer.
+func (t *Tailer) Start() {
+	eventlogs.SetRunning(true)
+	go t.run()
+}
+
+// Stop stops the tailer and waits for the pending events to be forwarded.
+func (t *Tailer) Stop() {
+	eventlogs.SetRunning(false)
+	t.stop <- true
+	<-t.done
+}
+
+func (t *Tailer) run() {
+	defer func() {
+		t.done <- true
+	}()
+
+	for {
+		select {
+		case event := <-t.inputChan:
+			t.forward(event)
+		case <-t.stop:
+			// The channel is shared with the check and never closed, flush what is buffered
+			for {
+				select {
+				case event := <-t.inputChan:
+					t.forward(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// forward sends an event as a log to the pipeline
+func (t *Tailer) forward(event *eventlogs.EventLog) {
+	content, err := json.Marshal(event)
+	if err != nil {
+		log.Errorf("failed to serialize the Kubernetes event to JSON: %s", err)
+		return
+	}
+	origin := message.NewOrigin(t.source)
+	origin.SetTags(event.Tags)
+	t.outputChan <- message.NewMessage(content, origin, toStatus(event.Type))
+}
+
+// toStatus converts a Kubernetes event type to a log status
+func toStatus(eventType string) string {
+	if eventType == "Warning" {
+		return message.StatusWarning
+	}
+	return message.StatusInfo
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kubeevents
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/eventlogs"
+)
+
+func TestKubeEventsShouldReceiveMessages(t *testing.T) {
+	inputChan := make(eventlogs.Channel, 2)
+	outputChan := make(chan *message.Message, 2)
+	tailer := NewTailer(config.NewLogSource("test", &config.LogsConfig{}), inputChan, outputChan)
+	tailer.Start()
+	assert.True(t, eventlogs.IsRunning())
+
+	event := &eventlogs.EventLog{
+		Message: "Back-off restarting failed container",
+		Reason:  "BackOff",
+		Type:    "Warning",
+		Count:   3,
+		InvolvedObject: eventlogs.ObjectReference{
+			Kind:      "Pod",
+			Namespace: "default",
+			Name:      "redis",
+		},
+		Source:          eventlogs.EventSource{Component: "kubelet", Host: "node-1"},
+		Name:            "redis.15f8d6a5e1b8c0d9",
+		ResourceVersion: "1234",
+		Tags:            []string{"kube_namespace:default"},
+	}
+	inputChan <- event
+
+	var msg *message.Message
+	select {
+	case msg = <-outputChan:
+		break
+	case <-time.After(1 * time.Second):
+		t.Error("Message not received")
+		return
+	}
+
+	assert.Equal(t, message.StatusWarning, msg.GetStatus())
+	assert.Equal(t, []string{"kube_namespace:default"}, msg.Origin.Tags())
+
+	content := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(msg.Content, &content))
+	assert.Equal(t, "BackOff", content["reason"])
+	assert.Equal(t, float64(3), content["count"])
+	assert.Equal(t, "kubelet", content["source"].(map[string]interface{})["component"])
+	assert.Equal(t, "redis", content["involved_object"].(map[string]interface{})["name"])
+	assert.NotContains(t, content, "Tags")
+
+	// buffered events are flushed on stop
+	inputChan <- &eventlogs.EventLog{Message: "Started container", Type: "Normal"}
+	tailer.Stop()
+	assert.False(t, eventlogs.IsRunning())
+	select {
+	case msg = <-outputChan:
+		assert.Equal(t, message.StatusInfo, msg.GetStatus())
+	default:
+		t.Error("Message not flushed")
+	}
+}
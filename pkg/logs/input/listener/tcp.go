@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
@@ -86,6 +87,7 @@ func (l *TCPListener) run() {
 			case err != nil:
 				// an error occurred, restart the listener.
 				log.Warnf("Can't listen on port %d, restarting a listener: %v", l.source.Config.Port, err)
+				health.NotifyRestart("logs-tcp-listener")
 				l.listener.Close()
 				err := l.startListener()
 				if err != nil {
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net"
 
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
@@ -109,6 +110,7 @@ func (l *UDPListener) read(tailer *Tailer) ([]byte, error) {
 // resetTailer creates a new tailer.
 func (l *UDPListener) resetTailer() {
 	log.Infof("Resetting the UDP connection on port: %d", l.source.Config.Port)
+	health.NotifyRestart("logs-udp-listener")
 	l.tailer.Stop()
 	err := l.startNewTailer()
 	if err != nil {
@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 )
 
 // use a randomly assigned port
@@ -37,3 +38,15 @@ func TestUDPShouldReceiveMessage(t *testing.T) {
 
 	listener.Stop()
 }
+
+func TestUDPResetIsNotified(t *testing.T) {
+	pp := mock.NewMockProvider()
+	listener := NewUDPListener(pp, config.NewLogSource("", &config.LogsConfig{Port: udpTestPort}), 9000)
+	listener.Start()
+	restarts := health.GetRestarts()["logs-udp-listener"]
+
+	listener.resetTailer()
+	assert.Equal(t, restarts+1, health.GetRestarts()["logs-udp-listener"])
+
+	listener.Stop()
+}
@@ -6,6 +6,10 @@
 package processor
 
 import (
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
@@ -13,6 +17,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 )
 
+// metricsCommitInterval is the interval at which the metrics extracted from logs
+// are committed to the aggregator.
+const metricsCommitInterval = 10 * time.Second
+
 // A Processor updates messages from an inputChan and pushes
 // in an outputChan.
 type Processor struct {
@@ -21,6 +29,10 @@ type Processor struct {
 	processingRules []*config.ProcessingRule
 	encoder         Encoder
 	done            chan struct{}
+	// sender is used to submit the metrics extracted from logs, it is
+	// lazily set to the aggregator default sender.
+	sender         aggregator.Sender
+	pendingMetrics bool
 }
 
 // New returns an initialized Processor.
@@ -51,22 +63,39 @@ func (p *Processor) run() {
 	defer func() {
 		p.done <- struct{}{}
 	}()
-	for msg := range p.inputChan {
-		metrics.LogsDecoded.Add(1)
-		metrics.TlmLogsDecoded.Inc()
-		if shouldProcess, redactedMsg := p.applyRedactingRules(msg); shouldProcess {
-			metrics.LogsProcessed.Add(1)
-			metrics.TlmLogsProcessed.Inc()
-
-			// Encode the message to its final format
-			content, err := p.encoder.Encode(msg, redactedMsg)
-			if err != nil {
-				log.Error("unable to encode msg ", err)
-				continue
+	ticker := time.NewTicker(metricsCommitInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg, ok := <-p.inputChan:
+			if !ok {
+				p.commitMetrics()
+				return
 			}
-			msg.Content = content
-			p.outputChan <- msg
+			p.process(msg)
+		case <-ticker.C:
+			p.commitMetrics()
+		}
+	}
+}
+
+// process applies the processing rules on a message, encodes it
+// and forwards it to the outputChan if it should not be dropped
+func (p *Processor) process(msg *message.Message) {
+	metrics.LogsDecoded.Add(1)
+	metrics.TlmLogsDecoded.Inc()
+	if shouldProcess, redactedMsg := p.applyRedactingRules(msg); shouldProcess {
+		metrics.LogsProcessed.Add(1)
+		metrics.TlmLogsProcessed.Inc()
+
+		// Encode the message to its final format
+		content, err := p.encoder.Encode(msg, redactedMsg)
+		if err != nil {
+			log.Error("unable to encode msg ", err)
+			return
 		}
+		msg.Content = content
+		p.outputChan <- msg
 	}
 }
 
@@ -87,7 +116,85 @@ func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
 			}
 		case config.MaskSequences:
 			content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+		case config.ExtractMetric:
+			if p.extractMetric(rule, msg, content) && rule.DropLine {
+				return false, nil
+			}
 		}
 	}
 	return true, content
 }
+
+// extractMetric submits a metric sample if the content matches the rule,
+// returns true if the sample was submitted.
+func (p *Processor) extractMetric(rule *config.ProcessingRule, msg *message.Message, content []byte) bool {
+	match := rule.Regex.FindSubmatch(content)
+	if match == nil {
+		return false
+	}
+
+	sender := p.getSender()
+	if sender == nil {
+		return false
+	}
+
+	originTags := msg.Origin.Tags()
+	tags := make([]string, 0, len(originTags)+len(match)+1)
+	tags = append(tags, originTags...)
+	if service := msg.Origin.Service(); service != "" {
+		tags = append(tags, "service:"+service)
+	}
+	if source := msg.Origin.Source(); source != "" {
+		tags = append(tags, "source:"+source)
+	}
+
+	var rawValue []byte
+	for i, name := range rule.Regex.SubexpNames() {
+		if name == "" || match[i] == nil {
+			continue
+		}
+		if name == rule.ValueGroup {
+			rawValue = match[i]
+			continue
+		}
+		tags = append(tags, name+":"+string(match[i]))
+	}
+
+	switch rule.MetricType {
+	case config.DistributionMetric:
+		value, err := strconv.ParseFloat(string(rawValue), 64)
+		if err != nil {
+			log.Debugf("Unable to parse value %q captured by processing rule %s: %v", rawValue, rule.Name, err)
+			return false
+		}
+		sender.Distribution(rule.MetricName, value, "", tags)
+	default:
+		sender.Count(rule.MetricName, 1, "", tags)
+	}
+	p.pendingMetrics = true
+	metrics.MetricsExtracted.Add(1)
+	metrics.TlmMetricsExtracted.Inc()
+	return true
+}
+
+// getSender returns the sender used to submit metrics extracted from logs.
+func (p *Processor) getSender() aggregator.Sender {
+	if p.sender == nil {
+		sender, err := aggregator.GetDefaultSender()
+		if err != nil {
+			log.Debugf("Unable to get the default sender, metrics extracted from logs are dropped: %v", err)
+			return nil
+		}
+		p.sender = sender
+	}
+	return p.sender
+}
+
+// commitMetrics commits the metrics extracted since the last commit.
+func (p *Processor) commitMetrics() {
+	if !p.pendingMetrics {
+		return
+	}
+	p.sender.Commit()
+	p.pendingMetrics = false
+}
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/stretchr/testify/assert"
@@ -128,6 +129,68 @@ func TestMask(t *testing.T) {
 	assert.Equal(t, []byte("New data added to data_values= on prod"), redactedMessage)
 }
 
+func TestExtractCountMetric(t *testing.T) {
+	sender := new(mocksender.MockSender)
+	p := &Processor{sender: sender}
+
+	rule := newProcessingRule("extract_metric", "", "ERROR in (?P<module>\\w+)")
+	rule.MetricName = "app.errors"
+	source := config.LogSource{Config: &config.LogsConfig{Service: "billing", Source: "go", Tags: []string{"env:prod"}, ProcessingRules: []*config.ProcessingRule{rule}}}
+
+	sender.On("Count", "app.errors", 1.0, "", []string{"env:prod", "service:billing", "source:go", "module:payments"}).Return().Once()
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte("ERROR in payments"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("ERROR in payments"), redactedMessage)
+
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("INFO in payments"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("INFO in payments"), redactedMessage)
+
+	sender.AssertExpectations(t)
+	assert.True(t, p.pendingMetrics)
+
+	sender.On("Commit").Return().Once()
+	p.commitMetrics()
+	p.commitMetrics()
+	sender.AssertExpectations(t)
+	assert.False(t, p.pendingMetrics)
+}
+
+func TestExtractDistributionMetricAndDropLine(t *testing.T) {
+	sender := new(mocksender.MockSender)
+	p := &Processor{sender: sender}
+
+	rule := newProcessingRule("extract_metric", "", "took (?P<duration>[0-9.]+)ms")
+	rule.MetricName = "app.duration"
+	rule.MetricType = config.DistributionMetric
+	rule.ValueGroup = "duration"
+	rule.DropLine = true
+	source := config.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}}}
+
+	sender.On("Distribution", "app.duration", 12.5, "", []string{}).Return().Once()
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte("request took 12.5ms"), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+	assert.Nil(t, redactedMessage)
+
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("request failed"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("request failed"), redactedMessage)
+
+	// The line is kept when no sample could be submitted
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("request took 1.2.3ms"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("request took 1.2.3ms"), redactedMessage)
+
+	p = &Processor{}
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("request took 12.5ms"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("request took 12.5ms"), redactedMessage)
+
+	sender.AssertExpectations(t)
+}
+
 func TestTruncate(t *testing.T) {
 	p := &Processor{}
 
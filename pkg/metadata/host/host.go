@@ -23,9 +23,12 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metadata/host/container"
 	"github.com/DataDog/datadog-agent/pkg/util/azure"
 	"github.com/DataDog/datadog-agent/pkg/util/cloudfoundry"
+	"github.com/DataDog/datadog-agent/pkg/util/digitalocean"
 	"github.com/DataDog/datadog-agent/pkg/util/ec2"
 	"github.com/DataDog/datadog-agent/pkg/util/gce"
 	kubelet "github.com/DataDog/datadog-agent/pkg/util/hostname/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/openstack"
+	"github.com/DataDog/datadog-agent/pkg/util/oracle"
 
 	"github.com/DataDog/datadog-agent/pkg/logs"
 
@@ -148,6 +151,27 @@ func getHostAliases() []string {
 		aliases = append(aliases, tencentAlias)
 	}
 
+	oracleAlias, err := oracle.GetHostAlias()
+	if err != nil {
+		log.Debugf("no Oracle Cloud Host Alias: %s", err)
+	} else if oracleAlias != "" {
+		aliases = append(aliases, oracleAlias)
+	}
+
+	openstackAlias, err := openstack.GetHostAlias()
+	if err != nil {
+		log.Debugf("no OpenStack Host Alias: %s", err)
+	} else if openstackAlias != "" {
+		aliases = append(aliases, openstackAlias)
+	}
+
+	digitaloceanAlias, err := digitalocean.GetHostAlias()
+	if err != nil {
+		log.Debugf("no DigitalOcean Host Alias: %s", err)
+	} else if digitaloceanAlias != "" {
+		aliases = append(aliases, digitaloceanAlias)
+	}
+
 	return aliases
 }
 
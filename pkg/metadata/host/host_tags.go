@@ -5,12 +5,15 @@ import (
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/digitalocean"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 	"github.com/DataDog/datadog-agent/pkg/util/ec2"
 	"github.com/DataDog/datadog-agent/pkg/util/gce"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/clustername"
 	k8s "github.com/DataDog/datadog-agent/pkg/util/kubernetes/hostinfo"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/openstack"
+	"github.com/DataDog/datadog-agent/pkg/util/oracle"
 )
 
 // this is a "low-tech" version of tagger/utils/taglist.go
@@ -67,6 +70,33 @@ func getHostTags() *tags {
 		}
 	}
 
+	if config.Datadog.GetBool("collect_oracle_tags") {
+		oracleTags, err := oracle.GetTags()
+		if err != nil {
+			log.Debugf("No Oracle Cloud host tags %v", err)
+		} else {
+			hostTags = appendToHostTags(hostTags, oracleTags)
+		}
+	}
+
+	if config.Datadog.GetBool("collect_openstack_tags") {
+		openstackTags, err := openstack.GetTags()
+		if err != nil {
+			log.Debugf("No OpenStack host tags %v", err)
+		} else {
+			hostTags = appendToHostTags(hostTags, openstackTags)
+		}
+	}
+
+	if config.Datadog.GetBool("collect_digitalocean_tags") {
+		digitaloceanTags, err := digitalocean.GetTags()
+		if err != nil {
+			log.Debugf("No DigitalOcean host tags %v", err)
+		} else {
+			hostTags = appendToHostTags(hostTags, digitaloceanTags)
+		}
+	}
+
 	clusterName := clustername.GetClusterName()
 	if len(clusterName) != 0 {
 		clusterNameTags := []string{"kube_cluster_name:" + clusterName}
@@ -85,7 +85,7 @@ func (c *ConnectionsCheck) Run(cfg *config.AgentConfig, groupID int32) ([]model.
 	// Resolve the Raddr side of connections for local containers
 	LocalResolver.Resolve(conns)
 
-	tel := c.diffTelemetry(conns.Telemetry)
+	tel := c.diffTelemetry(conns.ConnTelemetry)
 
 	log.Debugf("collected connections in %s", time.Since(start))
 	return batchConnections(cfg, groupID, c.enrichConnections(conns.Conns), conns.Dns, c.networkID, tel), nil
@@ -199,7 +199,7 @@ func batchConnections(
 		}
 		// only add the telemetry to the first message to prevent double counting
 		if len(batches) == 0 {
-			cc.Telemetry = telemetry
+			cc.ConnTelemetry = telemetry
 		}
 		batches = append(batches, cc)
 
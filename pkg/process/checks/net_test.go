@@ -89,9 +89,9 @@ func TestNetworkConnectionBatching(t *testing.T) {
 
 			// ensure only first chunk has telemetry
 			if i == 0 {
-				assert.NotNil(t, connections.Telemetry)
+				assert.NotNil(t, connections.ConnTelemetry)
 			} else {
-				assert.Nil(t, connections.Telemetry)
+				assert.Nil(t, connections.ConnTelemetry)
 			}
 		}
 		assert.Equal(t, tc.expectedTotal, total, "total test %d", i)
@@ -1,6 +1,7 @@
 package quantile
 
 import (
+	"fmt"
 	"math"
 	"strings"
 	"unsafe"
@@ -145,6 +146,29 @@ func (s *Sketch) Copy() *Sketch {
 	return dst
 }
 
+// FromCols returns a sketch with the given summary and the bins of the keys k and counts n,
+// as returned by Cols. The bins are merged like inserted values, so they don't need to be sorted.
+func FromCols(c *Config, basic summary.Summary, k []int32, n []uint32) (*Sketch, error) {
+	if len(k) != len(n) {
+		return nil, fmt.Errorf("mismatched number of keys (%d) and counts (%d)", len(k), len(n))
+	}
+
+	kcs := make([]KeyCount, 0, len(k))
+	for i := range k {
+		if k[i] < uvneginf || k[i] > uvinf {
+			return nil, fmt.Errorf("key %d is out of range", k[i])
+		}
+		if n[i] == 0 {
+			continue
+		}
+		kcs = append(kcs, KeyCount{k: Key(k[i]), n: uint(n[i])})
+	}
+
+	s := &Sketch{Basic: basic}
+	s.insertCounts(c, kcs)
+	return s, nil
+}
+
 // Equals returns true if s and o are equivalent.
 func (s *Sketch) Equals(o *Sketch) bool {
 	if s.Basic != o.Basic {
@@ -189,3 +189,22 @@ func TestRank(t *testing.T) {
 		}
 	})
 }
+
+func TestFromCols(t *testing.T) {
+	c := Default()
+	s := &Sketch{}
+	for i := -50; i <= 50; i++ {
+		s.Insert(c, float64(i))
+	}
+	s.Insert(c, 10, 10, 10)
+
+	k, n := s.Cols()
+	fromCols, err := FromCols(c, s.Basic, k, n)
+	require.NoError(t, err)
+	require.True(t, s.Equals(fromCols), "expected %s, got %s", s, fromCols)
+
+	_, err = FromCols(c, s.Basic, k, n[1:])
+	require.Error(t, err)
+	_, err = FromCols(c, s.Basic, []int32{1 << 20}, []uint32{1})
+	require.Error(t, err)
+}
@@ -123,3 +123,18 @@ func TestGetHealthy(t *testing.T) {
 	assert.Len(t, status.Healthy, 2)
 	assert.Len(t, status.Unhealthy, 0)
 }
+
+func TestRestartCounter(t *testing.T) {
+	r := newRestartCounter()
+	assert.Empty(t, r.get())
+
+	r.notify("jmxfetch")
+	r.notify("jmxfetch")
+	r.notify("logs-agent")
+	counts := r.get()
+	assert.Equal(t, map[string]int{"jmxfetch": 2, "logs-agent": 1}, counts)
+
+	// The returned counts are a copy
+	counts["jmxfetch"] = 10
+	assert.Equal(t, 2, r.get()["jmxfetch"])
+}
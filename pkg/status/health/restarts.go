@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package health
+
+import "sync"
+
+var restarts = newRestartCounter()
+
+type restartCounter struct {
+	sync.Mutex
+	counts map[string]int
+}
+
+func newRestartCounter() *restartCounter {
+	return &restartCounter{
+		counts: make(map[string]int),
+	}
+}
+
+func (r *restartCounter) notify(name string) {
+	r.Lock()
+	defer r.Unlock()
+	r.counts[name]++
+}
+
+func (r *restartCounter) get() map[string]int {
+	r.Lock()
+	defer r.Unlock()
+	counts := make(map[string]int, len(r.counts))
+	for name, count := range r.counts {
+		counts[name] = count
+	}
+	return counts
+}
+
+// NotifyRestart records that a component had to be restarted
+func NotifyRestart(name string) {
+	restarts.notify(name)
+}
+
+// GetRestarts returns the number of restarts of each component since the agent started
+func GetRestarts() map[string]int {
+	return restarts.get()
+}
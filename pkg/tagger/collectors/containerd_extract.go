@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build containerd
+
+package collectors
+
+import (
+	"strings"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/oci"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
+	ddContainers "github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// Label set by nerdctl to store the name of the container,
+	// containerd itself doesn't name containers
+	nerdctlLabelName = "nerdctl/name"
+)
+
+// extractFromInfo extracts tags from the containerd container info and its OCI spec
+func (c *ContainerdCollector) extractFromInfo(info containers.Container, spec *oci.Spec) ([]string, []string, []string, []string) {
+	tags := utils.NewTagList()
+
+	containerdExtractImage(tags, info.Image)
+	containerdExtractLabels(tags, info.Labels, c.labelsAsTags)
+	if spec != nil && spec.Process != nil {
+		containerdExtractEnvironmentVariables(tags, spec.Process.Env, c.envAsTags)
+	}
+
+	tags.AddHigh("container_id", info.ID)
+
+	low, orchestrator, high, standard := tags.Compute()
+	return low, orchestrator, high, standard
+}
+
+// containerdExtractImage extracts the image tags from the image
+// reference of the container, e.g. docker.io/library/redis:6.0
+func containerdExtractImage(tags *utils.TagList, image string) {
+	if image == "" {
+		return
+	}
+	imageName, shortImage, imageTag, err := ddContainers.SplitImageName(image)
+	if err != nil {
+		log.Debugf("Cannot split %s: %s", image, err)
+		return
+	}
+	tags.AddLow("image_name", imageName)
+	tags.AddLow("short_image", shortImage)
+	tags.AddLow("image_tag", imageTag)
+}
+
+// containerdExtractLabels extracts tags from the container labels
+// extracts env, version and service tags
+// extracts labels as tags
+// extracts the container name set by nerdctl
+func containerdExtractLabels(tags *utils.TagList, containerLabels map[string]string, labelsAsTags map[string]string) {
+	for labelName, labelValue := range containerLabels {
+		switch labelName {
+		case nerdctlLabelName:
+			tags.AddHigh("container_name", labelValue)
+
+		// Standard tags
+		case dockerLabelEnv:
+			tags.AddStandard(tagKeyEnv, labelValue)
+		case dockerLabelVersion:
+			tags.AddStandard(tagKeyVersion, labelValue)
+		case dockerLabelService:
+			tags.AddStandard(tagKeyService, labelValue)
+
+		default:
+			if tagName, found := labelsAsTags[strings.ToLower(labelName)]; found {
+				tags.AddAuto(tagName, labelValue)
+			}
+		}
+	}
+}
+
+// containerdExtractEnvironmentVariables extracts tags from the container's environment variables
+// extracts env, version and service tags
+// extracts environment variables as tags
+// extracts hard-coded environment variables from Nomad
+func containerdExtractEnvironmentVariables(tags *utils.TagList, containerEnvVariables []string, envAsTags map[string]string) {
+	for _, envEntry := range containerEnvVariables {
+		envSplit := strings.SplitN(envEntry, "=", 2)
+		if len(envSplit) != 2 {
+			continue
+		}
+		envName, envValue := envSplit[0], envSplit[1]
+		switch envName {
+		// Nomad
+		case "NOMAD_TASK_NAME":
+			tags.AddLow("nomad_task", envValue)
+		case "NOMAD_JOB_NAME":
+			tags.AddLow("nomad_job", envValue)
+		case "NOMAD_GROUP_NAME":
+			tags.AddLow("nomad_group", envValue)
+
+		// Standard tags
+		case envVarEnv:
+			tags.AddStandard(tagKeyEnv, envValue)
+		case envVarVersion:
+			tags.AddStandard(tagKeyVersion, envValue)
+		case envVarService:
+			tags.AddStandard(tagKeyService, envValue)
+
+		default:
+			if tagName, found := envAsTags[strings.ToLower(envName)]; found {
+				tags.AddAuto(tagName, envValue)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build containerd
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/oci"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerdRecordsFromInfo(t *testing.T) {
+	testCases := []struct {
+		testName             string
+		info                 containers.Container
+		spec                 *oci.Spec
+		toRecordEnvAsTags    map[string]string
+		toRecordLabelsAsTags map[string]string
+		expectedLow          []string
+		expectedOrch         []string
+		expectedHigh         []string
+		expectedStandard     []string
+	}{
+		{
+			testName: "emptyExtract",
+			info: containers.Container{
+				ID:     "3e8a1c",
+				Labels: map[string]string{"labelKey": "labelValue"},
+			},
+			spec:                 &oci.Spec{Process: &specs.Process{Env: []string{"k=v"}}},
+			toRecordEnvAsTags:    map[string]string{},
+			toRecordLabelsAsTags: map[string]string{},
+			expectedLow:          []string{},
+			expectedOrch:         []string{},
+			expectedHigh:         []string{"container_id:3e8a1c"},
+			expectedStandard:     []string{},
+		},
+		{
+			testName: "image",
+			info: containers.Container{
+				ID:    "3e8a1c",
+				Image: "docker.io/library/redis:6.0",
+			},
+			expectedLow:      []string{"image_name:docker.io/library/redis", "short_image:redis", "image_tag:6.0"},
+			expectedOrch:     []string{},
+			expectedHigh:     []string{"container_id:3e8a1c"},
+			expectedStandard: []string{},
+		},
+		{
+			testName: "labelsAndEnvAsTags",
+			info: containers.Container{
+				ID: "3e8a1c",
+				Labels: map[string]string{
+					"Team":   "containers",
+					"commit": "8d9f2a",
+				},
+			},
+			spec: &oci.Spec{Process: &specs.Process{Env: []string{
+				"TIER=backend",
+				"PATH=/usr/bin",
+				"MALFORMED",
+			}}},
+			toRecordEnvAsTags:    map[string]string{"tier": "tier"},
+			toRecordLabelsAsTags: map[string]string{"team": "team", "commit": "+git_commit"},
+			expectedLow:          []string{"team:containers", "tier:backend"},
+			expectedOrch:         []string{},
+			expectedHigh:         []string{"container_id:3e8a1c", "git_commit:8d9f2a"},
+			expectedStandard:     []string{},
+		},
+		{
+			testName: "nerdctlAndNomad",
+			info: containers.Container{
+				ID:     "3e8a1c",
+				Labels: map[string]string{"nerdctl/name": "redis-cache"},
+			},
+			spec: &oci.Spec{Process: &specs.Process{Env: []string{
+				"NOMAD_TASK_NAME=cache",
+				"NOMAD_JOB_NAME=backend",
+				"NOMAD_GROUP_NAME=redis",
+			}}},
+			expectedLow:      []string{"nomad_task:cache", "nomad_job:backend", "nomad_group:redis"},
+			expectedOrch:     []string{},
+			expectedHigh:     []string{"container_id:3e8a1c", "container_name:redis-cache"},
+			expectedStandard: []string{},
+		},
+		{
+			testName: "standardTags",
+			info: containers.Container{
+				ID: "3e8a1c",
+				Labels: map[string]string{
+					"com.datadoghq.tags.service": "redis",
+				},
+			},
+			spec: &oci.Spec{Process: &specs.Process{Env: []string{
+				"DD_ENV=production",
+				"DD_VERSION=6.0",
+			}}},
+			expectedLow:      []string{"service:redis", "env:production", "version:6.0"},
+			expectedOrch:     []string{},
+			expectedHigh:     []string{"container_id:3e8a1c"},
+			expectedStandard: []string{"service:redis", "env:production", "version:6.0"},
+		},
+		{
+			testName: "noSpec",
+			info: containers.Container{
+				ID:     "3e8a1c",
+				Labels: map[string]string{"com.datadoghq.tags.env": "staging"},
+			},
+			expectedLow:      []string{"env:staging"},
+			expectedOrch:     []string{},
+			expectedHigh:     []string{"container_id:3e8a1c"},
+			expectedStandard: []string{"env:staging"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.testName, func(t *testing.T) {
+			collector := &ContainerdCollector{
+				labelsAsTags: test.toRecordLabelsAsTags,
+				envAsTags:    test.toRecordEnvAsTags,
+			}
+			low, orch, high, standard := collector.extractFromInfo(test.info, test.spec)
+
+			assert.ElementsMatch(t, test.expectedLow, low)
+			assert.ElementsMatch(t, test.expectedOrch, orch)
+			assert.ElementsMatch(t, test.expectedHigh, high)
+			assert.ElementsMatch(t, test.expectedStandard, standard)
+		})
+	}
+}
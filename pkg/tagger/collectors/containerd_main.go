@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build containerd
+
+package collectors
+
+import (
+	"context"
+	"errors"
+
+	"github.com/containerd/containerd/api/events"
+	containerdevents "github.com/containerd/containerd/events"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	containerdutil "github.com/DataDog/datadog-agent/pkg/util/containerd"
+	ddContainers "github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	containerdCollectorName = "containerd"
+
+	containerdCreateTopic = "/containers/create"
+	containerdDeleteTopic = "/containers/delete"
+)
+
+// containerdEventFilters only subscribes to the container lifecycle events
+var containerdEventFilters = []string{
+	`topic=="` + containerdCreateTopic + `"`,
+	`topic=="` + containerdDeleteTopic + `"`,
+}
+
+// ContainerdCollector listens to events on the containerd socket to get new/deleted
+// containers and feed a stream of TagInfo. It inspects the containers to extract
+// their image, labels and environment variables.
+type ContainerdCollector struct {
+	client       containerdutil.ContainerdItf
+	stop         chan bool
+	infoOut      chan<- []*TagInfo
+	labelsAsTags map[string]string
+	envAsTags    map[string]string
+}
+
+// Detect tries to connect to the containerd socket and returns success.
+// On Kubernetes, the kubelet collector already tags the containers.
+func (c *ContainerdCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error) {
+	if config.IsKubernetes() {
+		return NoCollection, errors.New("the containers are tagged by the kubelet collector on Kubernetes")
+	}
+
+	client, err := containerdutil.GetContainerdUtil()
+	if err != nil {
+		return NoCollection, err
+	}
+
+	c.client = client
+	c.stop = make(chan bool)
+	c.infoOut = out
+
+	// We lower-case the values collected by viper as well as the ones from inspecting the labels of containers.
+	c.labelsAsTags = retrieveMappingFromConfig("containerd_labels_as_tags")
+	c.envAsTags = retrieveMappingFromConfig("containerd_env_as_tags")
+
+	return StreamCollection, nil
+}
+
+// Stream runs the continuous event watching loop and sends new info
+// to the channel. But be called in a goroutine.
+func (c *ContainerdCollector) Stream() error {
+	healthHandle := health.RegisterLiveness("tagger-containerd")
+
+	// Only the containers of the containerd_namespace namespace are tagged
+	log.Infof("Tagging the containers of the containerd namespace %q", c.client.Namespace())
+	ctx, cancel := context.WithCancel(namespaces.WithNamespace(context.Background(), c.client.Namespace()))
+	defer cancel()
+
+	messages, errs := c.client.GetEvents().Subscribe(ctx, containerdEventFilters...)
+
+	// Subscribe before listing the running containers so that no container is missed
+	c.processRunningContainers()
+
+	for {
+		select {
+		case <-c.stop:
+			healthHandle.Deregister() //nolint:errcheck
+			return nil
+		case <-healthHandle.C:
+		case msg := <-messages:
+			c.processEvent(msg)
+		case err := <-errs:
+			if err != nil {
+				log.Errorf("stopping collection: %s", err)
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+// Stop queues a shutdown of ContainerdCollector
+func (c *ContainerdCollector) Stop() error {
+	c.stop <- true
+	return nil
+}
+
+// Fetch inspects a given container to get its tags on-demand (cache miss)
+func (c *ContainerdCollector) Fetch(entity string) ([]string, []string, []string, error) {
+	entityType, cID := ddContainers.SplitEntityName(entity)
+	if entityType != ddContainers.ContainerEntityName || len(cID) == 0 {
+		return nil, nil, nil, nil
+	}
+	low, orchestrator, high, _, err := c.fetchForContainerID(cID)
+	return low, orchestrator, high, err
+}
+
+func (c *ContainerdCollector) processRunningContainers() {
+	ctns, err := c.client.Containers()
+	if err != nil {
+		log.Warnf("Cannot list the containerd containers: %s", err)
+		return
+	}
+
+	var infos []*TagInfo
+	for _, ctn := range ctns {
+		info, err := c.tagInfoForContainerID(ctn.ID())
+		if err != nil {
+			log.Debugf("Error fetching tags for container '%s': %v", ctn.ID(), err)
+			continue
+		}
+		infos = append(infos, info)
+	}
+	if len(infos) > 0 {
+		c.infoOut <- infos
+	}
+}
+
+func (c *ContainerdCollector) processEvent(e *containerdevents.Envelope) {
+	var info *TagInfo
+
+	switch e.Topic {
+	case containerdDeleteTopic:
+		deleted := &events.ContainerDelete{}
+		if err := proto.Unmarshal(e.Event.Value, deleted); err != nil {
+			log.Debugf("Could not process delete event from containerd: %v", err)
+			return
+		}
+		info = &TagInfo{Entity: ddContainers.BuildTaggerEntityName(deleted.ID), Source: containerdCollectorName, DeleteEntity: true}
+	case containerdCreateTopic:
+		created := &events.ContainerCreate{}
+		if err := proto.Unmarshal(e.Event.Value, created); err != nil {
+			log.Debugf("Could not process create event from containerd: %v", err)
+			return
+		}
+		var err error
+		info, err = c.tagInfoForContainerID(created.ID)
+		if err != nil {
+			log.Debugf("Error fetching tags for container '%s': %v", created.ID, err)
+			return
+		}
+	default:
+		return // Nothing to see here
+	}
+	c.infoOut <- []*TagInfo{info}
+}
+
+func (c *ContainerdCollector) tagInfoForContainerID(cID string) (*TagInfo, error) {
+	low, orchestrator, high, standard, err := c.fetchForContainerID(cID)
+	if err != nil {
+		return nil, err
+	}
+	return &TagInfo{
+		Entity:               ddContainers.BuildTaggerEntityName(cID),
+		Source:               containerdCollectorName,
+		LowCardTags:          low,
+		OrchestratorCardTags: orchestrator,
+		HighCardTags:         high,
+		StandardTags:         standard,
+	}, nil
+}
+
+func (c *ContainerdCollector) fetchForContainerID(cID string) ([]string, []string, []string, []string, error) {
+	ctn, err := c.client.LoadContainer(cID)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	info, err := c.client.Info(ctn)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	spec, err := c.client.Spec(ctn)
+	if err != nil {
+		// The labels and image are still worth tagging with
+		log.Debugf("Cannot get the OCI spec of container %s: %s", cID, err)
+	}
+	low, orchestrator, high, standard := c.extractFromInfo(info, spec)
+	return low, orchestrator, high, standard, nil
+}
+
+func containerdFactory() Collector {
+	return &ContainerdCollector{}
+}
+
+func init() {
+	registerCollector(containerdCollectorName, containerdFactory, NodeRuntime)
+}
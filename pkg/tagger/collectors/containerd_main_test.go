@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build containerd
+
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd"
+	containerdevents "github.com/containerd/containerd/api/events"
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/events"
+	"github.com/containerd/containerd/oci"
+	prototypes "github.com/gogo/protobuf/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	containerdutil "github.com/DataDog/datadog-agent/pkg/util/containerd"
+)
+
+type fakeContainerdContainer struct {
+	containerd.Container
+	id string
+}
+
+func (c *fakeContainerdContainer) ID() string {
+	return c.id
+}
+
+type fakeContainerdEvents struct {
+	events.Publisher
+	events.Forwarder
+	messages chan *events.Envelope
+	errs     chan error
+}
+
+func (e *fakeContainerdEvents) Subscribe(ctx context.Context, filters ...string) (<-chan *events.Envelope, <-chan error) {
+	return e.messages, e.errs
+}
+
+// fakeContainerdClient serves the containers from memory, the methods
+// that aren't used by the collector panic through the nil embedded interface
+type fakeContainerdClient struct {
+	containerdutil.ContainerdItf
+	events     *fakeContainerdEvents
+	containers map[string]containers.Container
+	specs      map[string]*oci.Spec
+}
+
+func (f *fakeContainerdClient) Namespace() string {
+	return "default"
+}
+
+func (f *fakeContainerdClient) GetEvents() containerd.EventService {
+	return f.events
+}
+
+func (f *fakeContainerdClient) Containers() ([]containerd.Container, error) {
+	var ctns []containerd.Container
+	for id := range f.containers {
+		ctns = append(ctns, &fakeContainerdContainer{id: id})
+	}
+	return ctns, nil
+}
+
+func (f *fakeContainerdClient) LoadContainer(id string) (containerd.Container, error) {
+	if _, found := f.containers[id]; !found {
+		return nil, fmt.Errorf("container %q not found", id)
+	}
+	return &fakeContainerdContainer{id: id}, nil
+}
+
+func (f *fakeContainerdClient) Info(ctn containerd.Container) (containers.Container, error) {
+	return f.containers[ctn.ID()], nil
+}
+
+func (f *fakeContainerdClient) Spec(ctn containerd.Container) (*oci.Spec, error) {
+	spec, found := f.specs[ctn.ID()]
+	if !found {
+		return nil, fmt.Errorf("no spec for container %q", ctn.ID())
+	}
+	return spec, nil
+}
+
+func newFakeContainerdClient() *fakeContainerdClient {
+	return &fakeContainerdClient{
+		events: &fakeContainerdEvents{
+			messages: make(chan *events.Envelope),
+			errs:     make(chan error),
+		},
+		containers: map[string]containers.Container{
+			"running": {
+				ID:     "running",
+				Image:  "docker.io/library/redis:6.0",
+				Labels: map[string]string{"nerdctl/name": "redis"},
+			},
+		},
+		specs: map[string]*oci.Spec{
+			"running": {Process: &specs.Process{Env: []string{"DD_SERVICE=cache"}}},
+		},
+	}
+}
+
+func containerdEnvelope(t *testing.T, topic string, event interface{ Marshal() ([]byte, error) }) *events.Envelope {
+	value, err := event.Marshal()
+	require.NoError(t, err)
+	return &events.Envelope{
+		Timestamp: time.Now(),
+		Namespace: "default",
+		Topic:     topic,
+		Event:     &prototypes.Any{Value: value},
+	}
+}
+
+func receiveTagInfo(t *testing.T, out chan []*TagInfo) []*TagInfo {
+	select {
+	case infos := <-out:
+		return infos
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for the tag infos")
+		return nil
+	}
+}
+
+func TestContainerdCollectorStream(t *testing.T) {
+	client := newFakeContainerdClient()
+	out := make(chan []*TagInfo)
+	collector := &ContainerdCollector{
+		client:  client,
+		stop:    make(chan bool),
+		infoOut: out,
+	}
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- collector.Stream()
+	}()
+
+	// The running containers are tagged first
+	infos := receiveTagInfo(t, out)
+	require.Len(t, infos, 1)
+	assert.Equal(t, "container_id://running", infos[0].Entity)
+	assert.Equal(t, containerdCollectorName, infos[0].Source)
+	assert.ElementsMatch(t, []string{"image_name:docker.io/library/redis", "short_image:redis", "image_tag:6.0", "service:cache"}, infos[0].LowCardTags)
+	assert.ElementsMatch(t, []string{"container_id:running", "container_name:redis"}, infos[0].HighCardTags)
+	assert.ElementsMatch(t, []string{"service:cache"}, infos[0].StandardTags)
+
+	// A new container is tagged even without its spec
+	client.containers["created"] = containers.Container{ID: "created", Image: "nginx:1.19"}
+	client.events.messages <- containerdEnvelope(t, containerdCreateTopic, &containerdevents.ContainerCreate{ID: "created", Image: "nginx:1.19"})
+	infos = receiveTagInfo(t, out)
+	require.Len(t, infos, 1)
+	assert.Equal(t, "container_id://created", infos[0].Entity)
+	assert.ElementsMatch(t, []string{"image_name:nginx", "short_image:nginx", "image_tag:1.19"}, infos[0].LowCardTags)
+	assert.False(t, infos[0].DeleteEntity)
+
+	// Deleted containers are removed
+	client.events.messages <- containerdEnvelope(t, containerdDeleteTopic, &containerdevents.ContainerDelete{ID: "running"})
+	infos = receiveTagInfo(t, out)
+	require.Len(t, infos, 1)
+	assert.Equal(t, "container_id://running", infos[0].Entity)
+	assert.True(t, infos[0].DeleteEntity)
+
+	// Other events are ignored
+	client.events.messages <- containerdEnvelope(t, "/tasks/paused", &containerdevents.TaskPaused{ContainerID: "created"})
+	select {
+	case infos = <-out:
+		assert.FailNow(t, "unexpected tag infos", "%v", infos)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	collector.Stop() //nolint:errcheck
+	assert.NoError(t, <-errCh)
+}
+
+func TestContainerdCollectorFetch(t *testing.T) {
+	collector := &ContainerdCollector{client: newFakeContainerdClient()}
+
+	low, orchestrator, high, err := collector.Fetch("container_id://running")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"image_name:docker.io/library/redis", "short_image:redis", "image_tag:6.0", "service:cache"}, low)
+	assert.Empty(t, orchestrator)
+	assert.ElementsMatch(t, []string{"container_id:running", "container_name:redis"}, high)
+
+	_, _, _, err = collector.Fetch("container_id://unknown")
+	assert.Error(t, err)
+
+	low, _, _, err = collector.Fetch("kubernetes_pod_uid://running")
+	assert.NoError(t, err)
+	assert.Nil(t, low)
+}
@@ -1,6 +1,7 @@
 package collectors
 
 import (
+	"encoding/json"
 	"testing"
 
 	"code.cloudfoundry.org/garden"
@@ -108,6 +109,10 @@ func (fakeDCAClient) GetEndpointsCheckConfigs(nodeName string) (types.ConfigResp
 	panic("implement me")
 }
 
+func (fakeDCAClient) PostSeries(series json.Marshaler) (apiv1.SeriesResponse, error) {
+	panic("implement me")
+}
+
 // Unused GardenUtilInterface methodes
 func (fakeGardenUtil) ListContainers() ([]*containers.Container, error) {
 	panic("implement me")
@@ -8,6 +8,7 @@
 package collectors
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
@@ -83,6 +84,10 @@ func (f *FakeDCAClient) GetCFAppsMetadataForNode(nodename string) (map[string][]
 	panic("implement me")
 }
 
+func (f *FakeDCAClient) PostSeries(series json.Marshaler) (apiv1.SeriesResponse, error) {
+	panic("implement me")
+}
+
 func TestKubeMetadataCollector_getMetadaNames(t *testing.T) {
 	type fields struct {
 		dcaClient           clusteragent.DCAClientInterface
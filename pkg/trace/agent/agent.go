@@ -8,6 +8,7 @@ package agent
 import (
 	"context"
 	"runtime"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 	"github.com/DataDog/datadog-agent/pkg/trace/stats"
+	"github.com/DataDog/datadog-agent/pkg/trace/tail"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/writer"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -67,10 +69,12 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 	in := make(chan *api.Payload, 1000)
 	out := make(chan *writer.SampledSpans, 1000)
 	statsChan := make(chan []stats.Bucket)
+	concentrator := stats.NewConcentrator(conf.ExtraAggregators, conf.BucketInterval.Nanoseconds(), statsChan)
+	concentrator.InferPeerServices = conf.InferPeerServices
 
 	return &Agent{
-		Receiver:           api.NewHTTPReceiver(conf, dynConf, in),
-		Concentrator:       stats.NewConcentrator(conf.ExtraAggregators, conf.BucketInterval.Nanoseconds(), statsChan),
+		Receiver:           api.NewHTTPReceiver(conf, dynConf, in, concentrator.ClientIn),
+		Concentrator:       concentrator,
 		Blacklister:        filters.NewBlacklister(conf.Ignore["resource"]),
 		Replacer:           filters.NewReplacer(conf.ReplaceTags),
 		ScoreSampler:       NewScoreSampler(conf),
@@ -126,8 +130,12 @@ func (a *Agent) work() {
 }
 
 func (a *Agent) loop() {
+	statsTicker := time.NewTicker(10 * time.Second)
+	defer statsTicker.Stop()
 	for {
 		select {
+		case <-statsTicker.C:
+			a.obfuscator.ReportStats()
 		case <-a.ctx.Done():
 			log.Info("Exiting...")
 			if err := a.Receiver.Stop(); err != nil {
@@ -157,6 +165,7 @@ func (a *Agent) Process(p *api.Payload, sublayerCalculator *stats.SublayerCalcul
 	ts := p.Source
 	ss := new(writer.SampledSpans)
 	sinputs := make([]*stats.Input, 0, len(p.Traces))
+	tailing := a.Receiver.Tail.Active()
 	for _, t := range p.Traces {
 		if len(t) == 0 {
 			log.Debugf("Skipping received empty trace")
@@ -165,10 +174,21 @@ func (a *Agent) Process(p *api.Payload, sublayerCalculator *stats.SublayerCalcul
 
 		tracen := int64(len(t))
 		atomic.AddInt64(&ts.SpansReceived, tracen)
-		err := normalizeTrace(p.Source, t)
+		nts := ts
+		if tailing {
+			// normalize against empty stats to report the issues of this trace alone
+			nts = info.NewTagStats(ts.Tags)
+		}
+		err := normalizeTrace(nts, t)
+		if tailing {
+			ts.Update(&nts.Stats)
+		}
 		if err != nil {
 			log.Debug("Dropping invalid trace: %s", err)
 			atomic.AddInt64(&ts.SpansDropped, tracen)
+			if tailing {
+				a.Receiver.Tail.Publish(a.newDroppedTailTrace(t, nts, "normalization"))
+			}
 			continue
 		}
 
@@ -179,6 +199,9 @@ func (a *Agent) Process(p *api.Payload, sublayerCalculator *stats.SublayerCalcul
 			log.Debugf("Trace rejected by blacklister. root: %v", root)
 			atomic.AddInt64(&ts.TracesFiltered, 1)
 			atomic.AddInt64(&ts.SpansFiltered, tracen)
+			if tailing {
+				a.Receiver.Tail.Publish(a.newDroppedTailTrace(t, nts, "filtered"))
+			}
 			return
 		}
 
@@ -186,6 +209,7 @@ func (a *Agent) Process(p *api.Payload, sublayerCalculator *stats.SublayerCalcul
 		for _, span := range t {
 			a.obfuscator.Obfuscate(span)
 			Truncate(span)
+			setErrorFingerprint(span)
 		}
 		a.Replacer.Replace(t)
 
@@ -220,7 +244,7 @@ func (a *Agent) Process(p *api.Payload, sublayerCalculator *stats.SublayerCalcul
 			Sublayers:     make(map[*pb.Span][]stats.SublayerValue),
 		}
 
-		events, keep := a.sample(ts, pt)
+		events, keep, reason := a.sample(ts, pt)
 
 		subtraces := stats.ExtractSubtraces(t, root)
 		for _, subtrace := range subtraces {
@@ -230,11 +254,24 @@ func (a *Agent) Process(p *api.Payload, sublayerCalculator *stats.SublayerCalcul
 				stats.SetSublayersOnSpan(subtrace.Root, subtraceSublayers)
 			}
 		}
-		sinputs = append(sinputs, &stats.Input{
-			Trace:     pt.WeightedTrace,
-			Sublayers: pt.Sublayers,
-			Env:       pt.Env,
-		})
+		if tailing {
+			tt := a.newTailTrace(t, nts)
+			tt.Sampled = keep
+			tt.SamplingReason = reason
+			if keep {
+				tt.SampleRate = sampler.GetGlobalRate(root)
+			}
+			tt.Events = len(events)
+			a.Receiver.Tail.Publish(tt)
+		}
+		if !p.ClientComputedStats {
+			// otherwise the stats of this trace were sent by the tracer to the stats endpoint
+			sinputs = append(sinputs, &stats.Input{
+				Trace:     pt.WeightedTrace,
+				Sublayers: pt.Sublayers,
+				Env:       pt.Env,
+			})
+		}
 
 		if keep {
 			ss.Traces = append(ss.Traces, traceutil.APITrace(t))
@@ -258,9 +295,69 @@ func (a *Agent) Process(p *api.Payload, sublayerCalculator *stats.SublayerCalcul
 	}
 }
 
+// newTailTrace returns the description of the trace t sent to the clients tailing the traces,
+// ts holding the normalization issues of t.
+// newDroppedTailTrace returns the tail trace of a trace dropped before its obfuscation. Its spans
+// are published without their resource and meta, which may hold sensitive data.
+func (a *Agent) newDroppedTailTrace(t pb.Trace, ts *info.TagStats, reason string) *tail.Trace {
+	tt := a.newTailTrace(t, ts)
+	tt.Dropped = reason
+	for _, s := range tt.Spans {
+		s.Resource = ""
+		s.Meta = nil
+	}
+	return tt
+}
+
+func (a *Agent) newTailTrace(t pb.Trace, ts *info.TagStats) *tail.Trace {
+	root := traceutil.GetRoot(t)
+	env := a.conf.DefaultEnv
+	if v := traceutil.GetEnv(t); v != "" {
+		env = v
+	}
+	priority := "none"
+	if p, ok := sampler.GetSamplingPriority(root); ok {
+		priority = strconv.Itoa(int(p))
+	}
+	// the spans are copied as they are encoded by the tail while the rest of the
+	// pipeline still uses them
+	spans := make([]*pb.Span, len(t))
+	for i, s := range t {
+		cp := *s
+		cp.Meta = make(map[string]string, len(s.Meta))
+		for k, v := range s.Meta {
+			cp.Meta[k] = v
+		}
+		cp.Metrics = make(map[string]float64, len(s.Metrics))
+		for k, v := range s.Metrics {
+			cp.Metrics[k] = v
+		}
+		spans[i] = &cp
+	}
+	return &tail.Trace{
+		Time:                time.Now(),
+		TraceID:             root.TraceID,
+		Service:             root.Service,
+		Env:                 env,
+		NormalizationIssues: ts.NormalizationIssues(),
+		Priority:            priority,
+		Spans:               spans,
+	}
+}
+
+// Sampling reasons, reported along with the sampling decision of each trace to the clients
+// tailing the traces.
+const (
+	reasonUserDrop = "user_drop" // the trace has a negative sampling priority
+	reasonPriority = "priority"  // the decision was taken by the priority sampler
+	reasonErrors   = "errors"    // the trace was kept because it contains an error
+	reasonRare     = "rare"      // the trace was caught by the exception sampler
+	reasonScore    = "score"     // the decision was taken by the score sampler
+)
+
 // sample decides whether the trace will be kept and extracts any APM events
-// from it.
-func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (events []*pb.Span, keep bool) {
+// from it. It also returns the reason of the sampling decision.
+func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (events []*pb.Span, keep bool, reason string) {
 	priority, hasPriority := sampler.GetSamplingPriority(pt.Root)
 
 	// Depending on the sampling priority, count that trace differently.
@@ -279,10 +376,10 @@ func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (events []*pb.Span,
 	atomic.AddInt64(stat, 1)
 
 	if priority < 0 {
-		return nil, false
+		return nil, false, reasonUserDrop
 	}
 
-	sampled, rate := a.runSamplers(pt, hasPriority)
+	sampled, rate, reason := a.runSamplers(pt, hasPriority)
 	if sampled {
 		sampler.AddGlobalRate(pt.Root, rate)
 	}
@@ -292,12 +389,12 @@ func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (events []*pb.Span,
 	atomic.AddInt64(&ts.EventsExtracted, int64(numExtracted))
 	atomic.AddInt64(&ts.EventsSampled, int64(len(events)))
 
-	return events, sampled
+	return events, sampled, reason
 }
 
 // runSamplers runs all the agent's samplers on pt and returns the sampling decision
-// along with the sampling rate.
-func (a *Agent) runSamplers(pt ProcessedTrace, hasPriority bool) (bool, float64) {
+// along with the sampling rate and the reason of the decision.
+func (a *Agent) runSamplers(pt ProcessedTrace, hasPriority bool) (bool, float64, string) {
 	if hasPriority {
 		return a.samplePriorityTrace(pt)
 	}
@@ -307,25 +404,31 @@ func (a *Agent) runSamplers(pt ProcessedTrace, hasPriority bool) (bool, float64)
 // samplePriorityTrace samples traces with priority set on them. PrioritySampler and
 // ErrorSampler are run in parallel. The ExceptionSampler catches traces with rare top-level
 // or measured spans that are not caught by PrioritySampler and ErrorSampler.
-func (a *Agent) samplePriorityTrace(pt ProcessedTrace) (sampled bool, rate float64) {
+func (a *Agent) samplePriorityTrace(pt ProcessedTrace) (sampled bool, rate float64, reason string) {
 	sampledPriority, ratePriority := a.PrioritySampler.Add(pt)
 	if traceContainsError(pt.Trace) {
 		sampledError, rateError := a.ErrorsScoreSampler.Add(pt)
-		return sampledError || sampledPriority, sampler.CombineRates(ratePriority, rateError)
+		reason = reasonPriority
+		if sampledError && !sampledPriority {
+			reason = reasonErrors
+		}
+		return sampledError || sampledPriority, sampler.CombineRates(ratePriority, rateError), reason
 	}
 	if sampled := a.ExceptionSampler.Add(pt.Env, pt.Root, pt.Trace); sampled {
-		return sampled, 1
+		return sampled, 1, reasonRare
 	}
-	return sampledPriority, ratePriority
+	return sampledPriority, ratePriority, reasonPriority
 }
 
 // sampleNoPriorityTrace samples traces with no priority set on them. The traces
 // get sampled by either the score sampler or the error sampler if they have an error.
-func (a *Agent) sampleNoPriorityTrace(pt ProcessedTrace) (sampled bool, rate float64) {
+func (a *Agent) sampleNoPriorityTrace(pt ProcessedTrace) (sampled bool, rate float64, reason string) {
 	if traceContainsError(pt.Trace) {
-		return a.ErrorsScoreSampler.Add(pt)
+		sampled, rate = a.ErrorsScoreSampler.Add(pt)
+		return sampled, rate, reasonErrors
 	}
-	return a.ScoreSampler.Add(pt)
+	sampled, rate = a.ScoreSampler.Add(pt)
+	return sampled, rate, reasonScore
 }
 
 func traceContainsError(trace pb.Trace) bool {
@@ -8,6 +8,7 @@ package agent
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"math"
 	"net/http"
 	"net/http/httptest"
@@ -26,6 +27,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 	"github.com/DataDog/datadog-agent/pkg/trace/stats"
+	"github.com/DataDog/datadog-agent/pkg/trace/tail"
 	"github.com/DataDog/datadog-agent/pkg/trace/test/testutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/writer"
@@ -172,6 +174,109 @@ func TestProcess(t *testing.T) {
 		assert.Equal(t, "A:B,C", span.Meta[tagContainersTags])
 	})
 
+	t.Run("ClientComputedStats", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg)
+		defer cancel()
+
+		for _, clientComputed := range []bool{true, false} {
+			span := &pb.Span{
+				TraceID:  1,
+				SpanID:   1,
+				Resource: "SELECT name FROM people WHERE age = 42 AND extra = 55",
+				Type:     "sql",
+				Start:    time.Now().Add(-time.Second).UnixNano(),
+				Duration: (500 * time.Millisecond).Nanoseconds(),
+			}
+			agnt.Process(&api.Payload{
+				Traces:              pb.Traces{{span}},
+				Source:              info.NewReceiverStats().GetTagStats(info.Tags{}),
+				ClientComputedStats: clientComputed,
+			}, stats.NewSublayerCalculator())
+		}
+
+		// only the trace whose stats weren't computed by the tracer is sent to the concentrator
+		assert.Len(t, agnt.Concentrator.In, 1)
+	})
+
+	t.Run("Tail", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg)
+		defer cancel()
+
+		srv := httptest.NewServer(agnt.Receiver.Tail)
+		defer srv.Close()
+		resp, err := http.Get(srv.URL + "?service=web")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+		for i := 0; i < 100 && !agnt.Receiver.Tail.Active(); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		now := time.Now()
+		root := &pb.Span{
+			TraceID:  1,
+			SpanID:   1,
+			Service:  "web",
+			Name:     "sql.query",
+			Resource: "SELECT name FROM people WHERE age = 42",
+			Type:     "sql",
+			Start:    now.Add(-time.Second).UnixNano(),
+			Duration: (500 * time.Millisecond).Nanoseconds(),
+			Metrics:  map[string]float64{sampler.KeySamplingPriority: 2},
+		}
+		// the child has no name and will be fixed during the normalization
+		child := &pb.Span{TraceID: 1, SpanID: 2, ParentID: 1, Service: "web", Resource: "fetch", Start: root.Start, Duration: 10}
+		agnt.Process(&api.Payload{
+			Traces: pb.Traces{{root, child}},
+			Source: agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+		}, stats.NewSublayerCalculator())
+
+		var trace tail.Trace
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&trace))
+		assert.EqualValues(t, 1, trace.TraceID)
+		assert.Equal(t, "2", trace.Priority)
+		assert.True(t, trace.Sampled)
+		assert.Equal(t, reasonPriority, trace.SamplingReason)
+		assert.Equal(t, map[string]int64{"span_name_empty": 1}, trace.NormalizationIssues)
+		assert.Len(t, trace.Spans, 2)
+		// the resource is obfuscated
+		assert.Equal(t, "SELECT name FROM people WHERE age = ?", trace.Spans[0].Resource)
+
+		// the traces dropped before their obfuscation are published without resource and meta
+		dropped := &pb.Span{
+			TraceID:  3,
+			SpanID:   1,
+			Service:  "web",
+			Name:     "sql.query",
+			Resource: "SELECT name FROM people WHERE ssn = '123-45-6789'",
+			Meta:     map[string]string{"sql.query": "SELECT name FROM people WHERE ssn = '123-45-6789'"},
+			Start:    now.UnixNano(),
+			Duration: 10,
+		}
+		foreign := &pb.Span{TraceID: 4, SpanID: 2, ParentID: 1, Service: "web", Name: "fetch", Start: now.UnixNano(), Duration: 10}
+		agnt.Process(&api.Payload{
+			Traces: pb.Traces{{dropped, foreign}},
+			Source: agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+		}, stats.NewSublayerCalculator())
+
+		trace = tail.Trace{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&trace))
+		assert.EqualValues(t, 3, trace.TraceID)
+		assert.Equal(t, "normalization", trace.Dropped)
+		assert.Len(t, trace.Spans, 2)
+		for _, s := range trace.Spans {
+			assert.Empty(t, s.Resource)
+			assert.Empty(t, s.Meta)
+		}
+	})
+
 	t.Run("Stats/Priority", func(t *testing.T) {
 		cfg := config.New()
 		cfg.Endpoints[0].APIKey = "test"
@@ -416,7 +521,7 @@ func TestSampling(t *testing.T) {
 				sampler.SetSamplingPriority(pt.Root, 1)
 			}
 
-			sampled, rate := a.runSamplers(pt, tt.hasPriority)
+			sampled, rate, _ := a.runSamplers(pt, tt.hasPriority)
 			assert.EqualValues(t, tt.wantRate, rate)
 			assert.EqualValues(t, tt.wantSampled, sampled)
 		})
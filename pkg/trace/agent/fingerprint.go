@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/stats"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+const (
+	// maxFingerprintFrames is the number of frames of the top of the stack trace which
+	// are part of the fingerprint.
+	maxFingerprintFrames = 5
+	// maxFingerprintMsgLen is the length of the error message which is part of the fingerprint.
+	maxFingerprintMsgLen = 500
+)
+
+var (
+	uuidRegexp = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	// hexRegexp matches the 0x prefixed values, and the words made of hexadecimal digits
+	// including at least one letter and one digit
+	hexRegexp    = regexp.MustCompile(`\b(?:0[xX][0-9a-fA-F]+|[0-9a-fA-F]*(?:[0-9][0-9a-fA-F]*[a-fA-F]|[a-fA-F][0-9a-fA-F]*[0-9])[0-9a-fA-F]*)\b`)
+	digitsRegexp = regexp.MustCompile(`[0-9]+`)
+)
+
+// setErrorFingerprint sets the fingerprint of the error of the span in its meta. Errors with
+// the same fingerprint are the same issue: they have the same type, message and stack trace,
+// ignoring the identifiers, numbers and line numbers which vary from an occurrence to the other.
+// A fingerprint set by the tracer is kept as is.
+func setErrorFingerprint(s *pb.Span) {
+	if s.Error == 0 {
+		return
+	}
+	if _, ok := s.Meta[stats.TagErrorFingerprint]; ok {
+		return
+	}
+	if fp := errorFingerprint(s); fp != "" {
+		traceutil.SetMeta(s, stats.TagErrorFingerprint, fp)
+	}
+}
+
+// errorFingerprint returns the fingerprint of the error of the span, or an empty string if the
+// span doesn't describe its error.
+func errorFingerprint(s *pb.Span) string {
+	typ := strings.TrimSpace(s.Meta["error.type"])
+	msg := normalizeErrorText(traceutil.TruncateUTF8(s.Meta["error.msg"], maxFingerprintMsgLen))
+	frames := stackFrames(s.Meta["error.stack"])
+	if typ == "" && msg == "" && len(frames) == 0 {
+		return ""
+	}
+	h := fnv.New64a()
+	h.Write([]byte(typ))
+	h.Write([]byte{0})
+	h.Write([]byte(msg))
+	for _, f := range frames {
+		h.Write([]byte{0})
+		h.Write([]byte(f))
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// normalizeErrorText masks the UUIDs, hexadecimal values and numbers found in s.
+func normalizeErrorText(s string) string {
+	s = uuidRegexp.ReplaceAllString(s, "?")
+	s = hexRegexp.ReplaceAllString(s, "?")
+	s = digitsRegexp.ReplaceAllString(s, "?")
+	return strings.TrimSpace(s)
+}
+
+// stackFrames returns the normalized lines of the top of the stack trace.
+func stackFrames(stack string) []string {
+	var frames []string
+	for _, line := range strings.Split(stack, "\n") {
+		if len(frames) == maxFingerprintFrames {
+			break
+		}
+		if line = normalizeErrorText(line); line != "" {
+			frames = append(frames, line)
+		}
+	}
+	return frames
+}
@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func errorSpan(typ, msg, stack string) *pb.Span {
+	return &pb.Span{
+		Error: 1,
+		Meta: map[string]string{
+			"error.type":  typ,
+			"error.msg":   msg,
+			"error.stack": stack,
+		},
+	}
+}
+
+func TestNormalizeErrorText(t *testing.T) {
+	for in, out := range map[string]string{
+		"user 42 not found": "user ? not found",
+		"order 3f2b8c1e-9d4a-4b6f-8e2c-1a5d7f9b0c3e already exists":         "order ? already exists",
+		"segfault at 0x7ffd5a3c":                                            "segfault at ?",
+		"object deadbeef12 was deleted":                                     "object ? was deleted",
+		"  at com.foo.Bar.baz(Bar.java:128)":                                "at com.foo.Bar.baz(Bar.java:?)",
+		"connection refused":                                                "connection refused",
+		"File \"/app/views.py\", line 12, in get":                           "File \"/app/views.py\", line ?, in get",
+		"cafe face added to the dead feed":                                  "cafe face added to the dead feed",
+		"main.(*Server).handle(0xc000123456, 0x2)\n\t/app/main.go:42 +0x1d": "main.(*Server).handle(?, ?)\n\t/app/main.go:? +?",
+	} {
+		assert.Equal(t, out, normalizeErrorText(in), in)
+	}
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	assert := assert.New(t)
+
+	stack := "java.lang.IllegalStateException: user 42\n" +
+		"\tat com.foo.Users.get(Users.java:128)\n" +
+		"\tat com.foo.Api.handle(Api.java:12)\n" +
+		"\tat com.foo.Router.route(Router.java:50)\n" +
+		"\tat com.foo.Server.serve(Server.java:8)\n"
+	fp := errorFingerprint(errorSpan("IllegalStateException", "user 42 not found", stack))
+	assert.Len(fp, 16)
+
+	// the identifiers, line numbers and bottom of the stack don't change the fingerprint
+	assert.Equal(fp, errorFingerprint(errorSpan("IllegalStateException", "user 1337 not found", stack+"\tat com.foo.Main.main(Main.java:3)\n")))
+	assert.Equal(fp, errorFingerprint(errorSpan(" IllegalStateException ", "user 42 not found", strings.Replace(stack, "12", "99", 1)+"\n\n")))
+
+	// but the type, message and frames do
+	assert.NotEqual(fp, errorFingerprint(errorSpan("IllegalArgumentException", "user 42 not found", stack)))
+	assert.NotEqual(fp, errorFingerprint(errorSpan("IllegalStateException", "user 42 is disabled", stack)))
+	assert.NotEqual(fp, errorFingerprint(errorSpan("IllegalStateException", "user 42 not found", "\tat com.foo.Users.list(Users.java:1)")))
+
+	// an error without any description has no fingerprint
+	assert.Equal("", errorFingerprint(&pb.Span{Error: 1}))
+}
+
+func TestSetErrorFingerprint(t *testing.T) {
+	s := errorSpan("KeyError", "'id'", "")
+	setErrorFingerprint(s)
+	assert.Equal(t, errorFingerprint(s), s.Meta[stats.TagErrorFingerprint])
+
+	// the fingerprint computed by the tracer is kept
+	s = errorSpan("KeyError", "'id'", "")
+	s.Meta[stats.TagErrorFingerprint] = "custom"
+	setErrorFingerprint(s)
+	assert.Equal(t, "custom", s.Meta[stats.TagErrorFingerprint])
+
+	// the spans without errors have no fingerprint
+	s = errorSpan("KeyError", "'id'", "")
+	s.Error = 0
+	setErrorFingerprint(s)
+	assert.NotContains(t, s.Meta, stats.TagErrorFingerprint)
+}
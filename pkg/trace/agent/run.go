@@ -12,6 +12,7 @@ import (
 	"os"
 	"runtime"
 	"runtime/pprof"
+	"strings"
 	"time"
 
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
@@ -23,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics/timing"
 	"github.com/DataDog/datadog-agent/pkg/trace/osutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/tail"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -67,6 +69,18 @@ func Run(ctx context.Context) {
 		return
 	}
 
+	if flags.Tail {
+		var services []string
+		if flags.TailServices != "" {
+			services = strings.Split(flags.TailServices, ",")
+		}
+		addr := fmt.Sprintf("%s:%d", cfg.ReceiverHost, cfg.ReceiverPort)
+		if err := tail.Follow(ctx, os.Stdout, addr, services); err != nil {
+			osutil.Exitf("Failed to tail traces: %s", err)
+		}
+		return
+	}
+
 	if err := coreconfig.SetupLogger(
 		coreconfig.LoggerName("TRACE"),
 		cfg.LogLevel,
@@ -39,6 +39,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/osutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/stats"
+	"github.com/DataDog/datadog-agent/pkg/trace/tail"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -48,11 +51,18 @@ import (
 type HTTPReceiver struct {
 	Stats       *info.ReceiverStats
 	RateLimiter *rateLimiter
+	// Tail streams the processed traces to the clients of /debug/traces, when enabled
+	Tail *tail.Tail
 
-	out     chan *Payload
-	conf    *config.AgentConfig
-	dynConf *sampler.DynamicConfig
-	server  *http.Server
+	serviceLimiter *serviceRateLimiter
+
+	out      chan *Payload
+	statsOut chan []stats.Bucket
+	conf     *config.AgentConfig
+	dynConf  *sampler.DynamicConfig
+	server   *http.Server
+	// listeners are closed on Stop, Shutdown only closes the ones already served
+	listeners []net.Listener
 
 	debug               bool
 	rateLimiterResponse int // HTTP status code when refusing
@@ -61,8 +71,9 @@ type HTTPReceiver struct {
 	exit chan struct{}
 }
 
-// NewHTTPReceiver returns a pointer to a new HTTPReceiver
-func NewHTTPReceiver(conf *config.AgentConfig, dynConf *sampler.DynamicConfig, out chan *Payload) *HTTPReceiver {
+// NewHTTPReceiver returns a pointer to a new HTTPReceiver. The traces received are sent to out
+// and the stats computed by the tracers to statsOut.
+func NewHTTPReceiver(conf *config.AgentConfig, dynConf *sampler.DynamicConfig, out chan *Payload, statsOut chan []stats.Bucket) *HTTPReceiver {
 	rateLimiterResponse := http.StatusOK
 	if config.HasFeature("429") {
 		rateLimiterResponse = http.StatusTooManyRequests
@@ -70,7 +81,11 @@ func NewHTTPReceiver(conf *config.AgentConfig, dynConf *sampler.DynamicConfig, o
 	return &HTTPReceiver{
 		Stats:       info.NewReceiverStats(),
 		RateLimiter: newRateLimiter(),
+		Tail:        tail.New(),
 		out:         out,
+		statsOut:    statsOut,
+
+		serviceLimiter: newServiceRateLimiter(conf.ServiceRateLimit),
 
 		conf:    conf,
 		dynConf: dynConf,
@@ -99,6 +114,7 @@ func (r *HTTPReceiver) Start() {
 	mux.HandleFunc("/v0.4/traces", r.handleWithVersion(v04, r.handleTraces))
 	mux.HandleFunc("/v0.4/services", r.handleWithVersion(v04, r.handleServices))
 	mux.HandleFunc("/v0.5/traces", r.handleWithVersion(v05, r.handleTraces))
+	mux.HandleFunc("/v0.4/stats", r.handleWithVersion(v04, r.handleStats))
 	mux.Handle("/profiling/v1/input", r.profileProxyHandler())
 
 	timeout := 5 * time.Second
@@ -112,12 +128,16 @@ func (r *HTTPReceiver) Start() {
 		ErrorLog:     stdlog.New(httpLogger, "http.Server: ", 0),
 		Handler:      mux,
 	}
+	if r.conf.ReceiverSocket != "" && r.conf.ReceiverSocketOriginDetection {
+		r.server.ConnContext = withConnContainerID
+	}
 
 	addr := fmt.Sprintf("%s:%d", r.conf.ReceiverHost, r.conf.ReceiverPort)
 	ln, err := r.listenTCP(addr)
 	if err != nil {
 		killProcess("Error creating tcp listener: %v", err)
 	}
+	r.listeners = append(r.listeners, ln)
 	go func() {
 		defer watchdog.LogOnPanic()
 		r.server.Serve(ln)
@@ -129,6 +149,7 @@ func (r *HTTPReceiver) Start() {
 		if err != nil {
 			killProcess("Error creating UDS listener: %v", err)
 		}
+		r.listeners = append(r.listeners, ln)
 		go func() {
 			defer watchdog.LogOnPanic()
 			r.server.Serve(ln)
@@ -137,6 +158,7 @@ func (r *HTTPReceiver) Start() {
 	}
 
 	go r.RateLimiter.Run()
+	go r.serviceLimiter.Run()
 
 	go func() {
 		defer watchdog.LogOnPanic()
@@ -179,6 +201,11 @@ func (r *HTTPReceiver) attachDebugHandlers(mux *http.ServeMux) {
 		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:"+mainconfig.Datadog.GetString("GUI_port"))
 		expvar.Handler().ServeHTTP(w, req)
 	}))
+
+	// the traces are streamed without authentication, see apm_config.debug_traces_enabled
+	if r.conf.DebugTracesEnabled {
+		mux.Handle("/debug/traces", r.Tail)
+	}
 }
 
 // listenUnix returns a net.Listener listening on the given "unix" socket path.
@@ -197,7 +224,7 @@ func (r *HTTPReceiver) listenUnix(path string) (net.Listener, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := os.Chmod(path, 0722); err != nil {
+	if err := os.Chmod(path, r.conf.ReceiverSocketPermissions); err != nil {
 		return nil, fmt.Errorf("error setting socket permissions: %v", err)
 	}
 	return ln, err
@@ -226,11 +253,17 @@ func (r *HTTPReceiver) Stop() error {
 	<-r.exit
 
 	r.RateLimiter.Stop()
+	r.serviceLimiter.Stop()
+	r.Tail.Close()
 
 	expiry := time.Now().Add(5 * time.Second) // give it 5 seconds
 	ctx, cancel := context.WithDeadline(context.Background(), expiry)
 	defer cancel()
-	if err := r.server.Shutdown(ctx); err != nil {
+	err := r.server.Shutdown(ctx)
+	for _, ln := range r.listeners {
+		ln.Close()
+	}
+	if err != nil {
 		return err
 	}
 	r.wg.Wait()
@@ -296,6 +329,10 @@ const (
 	// headerTracerVersion specifies the name of the header which contains the version
 	// of the tracer sending the payload.
 	headerTracerVersion = "Datadog-Meta-Tracer-Version"
+
+	// headerClientComputedStats specifies the name of the header which tells that the stats
+	// of the traces in the payload were computed by the tracer and sent to the stats endpoint.
+	headerClientComputedStats = "Datadog-Client-Computed-Stats"
 )
 
 func (r *HTTPReceiver) tagStats(v Version, req *http.Request) *info.TagStats {
@@ -353,6 +390,31 @@ func (r *HTTPReceiver) rateLimited(n int64) bool {
 	return !r.RateLimiter.Permits(n)
 }
 
+// applyServiceRateLimit filters out, in place, the traces whose root service is over its
+// rate limit, and counts them in the receiver stats.
+func (r *HTTPReceiver) applyServiceRateLimit(traces pb.Traces) pb.Traces {
+	now := time.Now()
+	kept := traces[:0]
+	for _, t := range traces {
+		if len(t) == 0 {
+			// dropped later on by the agent
+			kept = append(kept, t)
+			continue
+		}
+		service := traceutil.GetRoot(t).Service
+		env := traceutil.GetEnv(t)
+		if env == "" {
+			env = r.conf.DefaultEnv
+		}
+		if !r.serviceLimiter.Permits(service, env, now) {
+			r.Stats.AddServiceRateLimited(r.serviceLimiter.key(service, env), 1)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return kept
+}
+
 // handleTraces knows how to handle a bunch of traces
 func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.Request) {
 	ts := r.tagStats(v, req)
@@ -383,10 +445,16 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 	atomic.AddInt64(&ts.TracesBytes, req.Body.(*LimitedReader).Count)
 	atomic.AddInt64(&ts.PayloadAccepted, 1)
 
+	if r.serviceLimiter.Active() {
+		traces = r.applyServiceRateLimit(traces)
+	}
+
 	payload := &Payload{
 		Source:        ts,
 		Traces:        traces,
-		ContainerTags: getContainerTags(req.Header.Get(headerContainerID)),
+		ContainerTags: getContainerTags(containerID(req)),
+		// any value other than a false one is considered set
+		ClientComputedStats: !isFalse(req.Header.Get(headerClientComputedStats)),
 	}
 	select {
 	case r.out <- payload:
@@ -417,6 +485,50 @@ type Payload struct {
 
 	// Traces contains all the traces received in the payload
 	Traces pb.Traces
+
+	// ClientComputedStats reports whether the stats of these traces were computed by the
+	// tracer, in which case the agent must not compute them.
+	ClientComputedStats bool
+}
+
+// isFalse reports whether the value of a boolean header is false or unset
+func isFalse(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "0", "false", "no":
+		return true
+	}
+	return false
+}
+
+// handleStats handles a payload of stats buckets computed by a tracer
+func (r *HTTPReceiver) handleStats(v Version, w http.ResponseWriter, req *http.Request) {
+	var payload stats.Payload
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		httpDecodingError(err, []string{"handler:stats", fmt.Sprintf("v:%s", v)}, w)
+		log.Errorf("Cannot decode %s stats payload: %v", v, err)
+		return
+	}
+	httpOK(w)
+
+	tags := []string{"lang:" + req.Header.Get(headerLang), "tracer_version:" + req.Header.Get(headerTracerVersion)}
+	metrics.Count("datadog.trace_agent.receiver.client_stats_buckets", int64(len(payload.Stats)), tags, 1)
+	if len(payload.Stats) == 0 || r.statsOut == nil {
+		return
+	}
+	select {
+	case r.statsOut <- payload.Stats:
+		// ok
+	default:
+		// channel blocked, add a goroutine to ensure we never drop
+		r.wg.Add(1)
+		go func() {
+			defer func() {
+				r.wg.Done()
+				watchdog.LogOnPanic()
+			}()
+			r.statsOut <- payload.Stats
+		}()
+	}
 }
 
 // handleServices handle a request with a list of several services
@@ -576,6 +688,39 @@ func tracesFromSpans(spans []pb.Span) pb.Traces {
 	return traces
 }
 
+// containerIDKey is the key of the value of the connection contexts holding the ID of
+// the container at the other end of the connection, see withConnContainerID.
+type containerIDKey struct{}
+
+// withConnContainerID returns a copy of the context of the connection holding the ID of the
+// container at its other end, if it's a unix socket connection from a container.
+func withConnContainerID(ctx context.Context, c net.Conn) context.Context {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+	cid, err := connContainerID(uc)
+	if err != nil {
+		log.Debugf("Could not detect the container of the UDS connection: %v", err)
+		return ctx
+	}
+	if cid == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, containerIDKey{}, cid)
+}
+
+// containerID returns the ID of the container where the request originated: the one sent by
+// the client in the Datadog-Container-ID header or, if missing, the one detected from the
+// credentials of the connection.
+func containerID(req *http.Request) string {
+	if cid := req.Header.Get(headerContainerID); cid != "" {
+		return cid
+	}
+	cid, _ := req.Context().Value(containerIDKey{}).(string)
+	return cid
+}
+
 // getContainerTag returns container and orchestrator tags belonging to containerID. If containerID
 // is empty or no tags are found, an empty string is returned.
 func getContainerTags(containerID string) string {
@@ -12,6 +12,7 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"os"
 	"testing"
 	"time"
 
@@ -67,4 +68,24 @@ func TestUDS(t *testing.T) {
 			t.Fatalf("expected http.StatusOK, got response: %#v", resp)
 		}
 	})
+
+	t.Run("permissions", func(t *testing.T) {
+		conf := config.New()
+		conf.Endpoints[0].APIKey = "apikey_2"
+		conf.ReceiverSocket = sockPath
+		conf.ReceiverSocketPermissions = 0600
+		conf.ReceiverPort = 0 // use a randomly assigned port
+
+		r := newTestReceiverFromConfig(conf)
+		r.Start()
+		defer r.Stop()
+
+		fi, err := os.Stat(sockPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := fi.Mode().Perm(); perm != 0600 {
+			t.Fatalf("expected permissions 0600, got %#o", perm)
+		}
+	})
 }
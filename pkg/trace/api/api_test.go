@@ -7,14 +7,17 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -23,6 +26,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/info"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/stats"
 	"github.com/DataDog/datadog-agent/pkg/trace/test/testutil"
 
 	"github.com/cihub/seelog"
@@ -46,7 +50,7 @@ func newTestReceiverFromConfig(conf *config.AgentConfig) *HTTPReceiver {
 	dynConf := sampler.NewDynamicConfig("none")
 
 	rawTraceChan := make(chan *Payload, 5000)
-	receiver := NewHTTPReceiver(conf, dynConf, rawTraceChan)
+	receiver := NewHTTPReceiver(conf, dynConf, rawTraceChan, nil)
 
 	return receiver
 }
@@ -526,6 +530,118 @@ func TestHandleTraces(t *testing.T) {
 	assert.Equal("C#|go|java|python|ruby", receiver.Languages())
 }
 
+func TestHandleStats(t *testing.T) {
+	assert := assert.New(t)
+
+	bucket := stats.NewBucket(time.Now().UnixNano(), (10 * time.Second).Nanoseconds())
+	key := stats.GrainKey("http.request", stats.HITS, "env:prod,resource:/,service:web")
+	bucket.Counts[key] = stats.Count{Key: key, Name: "http.request", Measure: stats.HITS, Value: 3}
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(&stats.Payload{Stats: []stats.Bucket{bucket}})
+
+	statsOut := make(chan []stats.Bucket, 1)
+	receiver := NewHTTPReceiver(newTestReceiverConfig(), sampler.NewDynamicConfig("none"), make(chan *Payload, 1), statsOut)
+	handler := http.HandlerFunc(receiver.handleWithVersion(v04, receiver.handleStats))
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v0.4/stats", &buf)
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(rr, req)
+	assert.Equal(http.StatusOK, rr.Code)
+
+	select {
+	case buckets := <-statsOut:
+		assert.Len(buckets, 1)
+		assert.Equal(3.0, buckets[0].Counts[key].Value)
+	case <-time.After(time.Second):
+		t.Fatal("no stats received")
+	}
+
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/v0.4/stats", strings.NewReader("not json"))
+	handler.ServeHTTP(rr, req)
+	assert.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func TestClientComputedStatsHeader(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":      false,
+		"false": false,
+		"0":     false,
+		"true":  true,
+		"yes":   true,
+		"1":     true,
+	} {
+		receiver := newTestReceiverFromConfig(newTestReceiverConfig())
+		handler := http.HandlerFunc(receiver.handleWithVersion(v04, receiver.handleTraces))
+
+		var buf bytes.Buffer
+		msgp.Encode(&buf, testutil.GetTestTraces(1, 1, true))
+		req, _ := http.NewRequest("POST", "/v0.4/traces", &buf)
+		req.Header.Set("Content-Type", "application/msgpack")
+		if header != "" {
+			req.Header.Set("Datadog-Client-Computed-Stats", header)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		p := <-receiver.out
+		assert.Equal(t, want, p.ClientComputedStats, "header value %q", header)
+	}
+}
+
+func TestContainerID(t *testing.T) {
+	assert := assert.New(t)
+
+	req, _ := http.NewRequest("POST", "/v0.4/traces", nil)
+	assert.Equal("", containerID(req))
+
+	// detected from the connection
+	ctx := context.WithValue(req.Context(), containerIDKey{}, "detected")
+	req = req.WithContext(ctx)
+	assert.Equal("detected", containerID(req))
+
+	// the header sent by the client prevails
+	req.Header.Set(headerContainerID, "header")
+	assert.Equal("header", containerID(req))
+
+	// only unix socket connections are inspected
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	assert.Equal(ctx, withConnContainerID(ctx, c1))
+}
+
+func TestHandleTracesServiceRateLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	conf := newTestReceiverConfig()
+	conf.ServiceRateLimit.ServiceTPS = 2
+	receiver := newTestReceiverFromConfig(conf)
+	handler := http.HandlerFunc(receiver.handleWithVersion(v04, receiver.handleTraces))
+
+	var traces pb.Traces
+	for i, service := range []string{"web", "web", "db", "web", "web", "web"} {
+		traces = append(traces, pb.Trace{{TraceID: uint64(i + 1), SpanID: 1, Service: service, Name: "request"}})
+	}
+	var buf bytes.Buffer
+	msgp.Encode(&buf, traces)
+	req, _ := http.NewRequest("POST", "/v0.4/traces", &buf)
+	req.Header.Set("Content-Type", "application/msgpack")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(http.StatusOK, rr.Code)
+
+	// web is allowed a burst of 2 traces
+	p := <-receiver.out
+	var services []string
+	for _, t := range p.Traces {
+		services = append(services, t[0].Service)
+	}
+	assert.Equal([]string{"web", "web", "db"}, services)
+	assert.Equal(map[string]int64{"service:web": 3}, receiver.Stats.ServiceRateLimited)
+	assert.EqualValues(6, p.Source.TracesReceived)
+}
+
 // chunkedReader is a reader which forces partial reads, this is required
 // to trigger some network related bugs, such as body not being read fully by server.
 // Without this, all the data could be read/written at once, not triggering the issue.
@@ -714,7 +830,7 @@ func BenchmarkWatchdog(b *testing.B) {
 	now := time.Now()
 	conf := config.New()
 	conf.Endpoints[0].APIKey = "apikey_2"
-	r := NewHTTPReceiver(conf, nil, nil)
+	r := NewHTTPReceiver(conf, nil, nil, nil)
 
 	b.ResetTimer()
 	b.ReportAllocs()
@@ -762,6 +878,19 @@ func TestExpvar(t *testing.T) {
 	}
 }
 
+func TestDebugTraces(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		conf := newTestReceiverConfig()
+		conf.DebugTracesEnabled = enabled
+		r := newTestReceiverFromConfig(conf)
+		mux := http.NewServeMux()
+		r.attachDebugHandlers(mux)
+
+		_, pattern := mux.Handler(httptest.NewRequest("GET", "/debug/traces", nil))
+		assert.Equal(t, enabled, pattern == "/debug/traces")
+	}
+}
+
 func TestWatchdog(t *testing.T) {
 	t.Run("rate-limit", func(t *testing.T) {
 		if testing.Short() {
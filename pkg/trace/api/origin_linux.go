@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package api
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+)
+
+const (
+	pidToContainerIDCacheKeyPrefix = "trace_pid_to_container_id"
+	pidToContainerIDCacheDuration  = time.Minute
+)
+
+// connContainerID returns the ID of the container of the process at the other end of the
+// connection, identified by the credentials attached to the connection by the kernel. It
+// returns an empty string if the process isn't running in a container.
+func connContainerID(conn *net.UnixConn) (string, error) {
+	rawconn, err := conn.SyscallConn()
+	if err != nil {
+		return "", err
+	}
+	var (
+		cred    *unix.Ucred
+		credErr error
+	)
+	err = rawconn.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return "", err
+	}
+	if credErr != nil {
+		return "", credErr
+	}
+	if cred.Pid == 0 {
+		return "", errors.New("matched PID for the process is 0, it belongs " +
+			"probably to another namespace. Is the agent in host PID mode?")
+	}
+	return containerIDForPID(cred.Pid)
+}
+
+// containerIDForPID returns the ID of the container of the process, derived from its cgroups,
+// and caches it for future lookups.
+func containerIDForPID(pid int32) (string, error) {
+	key := cache.BuildAgentKey(pidToContainerIDCacheKeyPrefix, strconv.Itoa(int(pid)))
+	if x, found := cache.Cache.Get(key); found {
+		return x.(string), nil
+	}
+	cid, err := providers.ContainerImpl().ContainerIDForPID(int(pid))
+	if err != nil {
+		// lookup error, retry next time
+		return "", err
+	}
+	cache.Cache.Set(key, cid, pidToContainerIDCacheDuration)
+	return cid, nil
+}
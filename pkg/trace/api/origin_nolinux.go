@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package api
+
+import (
+	"errors"
+	"net"
+)
+
+// errLinuxOnly is returned by the origin detection on non-linux hosts
+var errLinuxOnly = errors.New("only implemented on Linux hosts")
+
+// connContainerID returns a "not implemented" error on non-linux hosts
+func connContainerID(conn *net.UnixConn) (string, error) {
+	return "", errLinuxOnly
+}
@@ -96,8 +96,7 @@ func newProfileProxy(transport http.RoundTripper, targets []*url.URL, keys []str
 			// See https://codereview.appspot.com/7532043
 			req.Header.Set("User-Agent", "")
 		}
-		containerID := req.Header.Get(headerContainerID)
-		if ctags := getContainerTags(containerID); ctags != "" {
+		if ctags := getContainerTags(containerID(req)); ctags != "" {
 			req.Header.Set("X-Datadog-Container-Tags", ctags)
 		}
 		req.Header.Set("X-Datadog-Additional-Tags", tags)
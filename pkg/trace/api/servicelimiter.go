@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+const (
+	// serviceLimiterPeriod is the interval at which the rates of the services are
+	// measured and the limits shared between them.
+	serviceLimiterPeriod = time.Second
+	// serviceLimiterIdlePeriods is the number of periods without traffic after which
+	// the bucket of a service is removed.
+	serviceLimiterIdlePeriods = 60
+)
+
+// tokenBucket limits the rate of the traces of a single service.
+type tokenBucket struct {
+	// limit is the configured maximum rate of the service, +Inf if unlimited.
+	limit float64
+	// rate is the current maximum rate of the service, it is lower than limit when
+	// the global limit is shared between the services.
+	rate   float64
+	tokens float64
+	last   time.Time
+
+	// seen is the number of traces received during the current period
+	seen float64
+	// idle is the number of consecutive periods without traces
+	idle int
+}
+
+// burst returns the maximum number of tokens in the bucket: one second worth of traffic.
+func (b *tokenBucket) burst() float64 {
+	return math.Max(b.rate, 1)
+}
+
+// take reports whether a token was available, and consumes it.
+func (b *tokenBucket) take(now time.Time) bool {
+	b.seen++
+	if math.IsInf(b.rate, 1) {
+		return true
+	}
+	b.tokens = math.Min(b.burst(), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// serviceRateLimiter limits the number of traces per second accepted from each service, and
+// from all of them. Each service gets a token bucket refilled at its own rate: the configured
+// limit of the service, or its fair share of the global limit when the services send more
+// traces than it.
+type serviceRateLimiter struct {
+	conf *config.ServiceRateLimitConfig
+	// overrides holds the limits configured for specific services, by service signature.
+	overrides map[sampler.ServiceSignature]float64
+
+	mu      sync.Mutex
+	buckets map[sampler.ServiceSignature]*tokenBucket
+	// share is the maximum rate of each service, +Inf when the global limit isn't reached.
+	share float64
+	// periodStart is the start of the current period.
+	periodStart time.Time
+
+	exit chan struct{}
+}
+
+// newServiceRateLimiter returns a rate limiter applying the given configuration.
+func newServiceRateLimiter(conf *config.ServiceRateLimitConfig) *serviceRateLimiter {
+	overrides := make(map[sampler.ServiceSignature]float64, len(conf.Overrides))
+	for _, o := range conf.Overrides {
+		sig := sampler.ServiceSignature{Name: o.Service}
+		if conf.ByEnv {
+			sig.Env = o.Env
+		}
+		overrides[sig] = o.MaxTPS
+	}
+	return &serviceRateLimiter{
+		conf:        conf,
+		overrides:   overrides,
+		buckets:     make(map[sampler.ServiceSignature]*tokenBucket),
+		share:       math.Inf(1),
+		periodStart: time.Now(),
+		exit:        make(chan struct{}),
+	}
+}
+
+// Active reports whether any limit is configured.
+func (sl *serviceRateLimiter) Active() bool {
+	return sl.conf.Enabled()
+}
+
+// Run periodically shares the global limit between the services.
+func (sl *serviceRateLimiter) Run() {
+	t := time.NewTicker(serviceLimiterPeriod)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C:
+			sl.rebalance(now)
+		case <-sl.exit:
+			return
+		}
+	}
+}
+
+// Stop stops the rate limiter.
+func (sl *serviceRateLimiter) Stop() { close(sl.exit) }
+
+// signature returns the signature of the bucket of the given service and env.
+func (sl *serviceRateLimiter) signature(service, env string) sampler.ServiceSignature {
+	if !sl.conf.ByEnv {
+		return sampler.ServiceSignature{Name: service}
+	}
+	return sampler.ServiceSignature{Name: service, Env: env}
+}
+
+// key returns the key under which the traces of the given service and env dropped by the
+// rate limiter are counted, e.g. "service:web,env:prod", or "service:web" without ByEnv.
+func (sl *serviceRateLimiter) key(service, env string) string {
+	if !sl.conf.ByEnv {
+		return "service:" + service
+	}
+	return sampler.ServiceSignature{Name: service, Env: env}.String()
+}
+
+// limit returns the configured maximum rate of the service, +Inf if unlimited.
+func (sl *serviceRateLimiter) limit(sig sampler.ServiceSignature) float64 {
+	tps, ok := sl.overrides[sig]
+	if !ok && sig.Env != "" {
+		// an override without env applies to all the envs of the service
+		tps, ok = sl.overrides[sampler.ServiceSignature{Name: sig.Name}]
+	}
+	if !ok {
+		tps = sl.conf.ServiceTPS
+	}
+	if tps <= 0 {
+		return math.Inf(1)
+	}
+	return tps
+}
+
+// Permits reports whether a trace of the given service and env should be accepted.
+func (sl *serviceRateLimiter) Permits(service, env string, now time.Time) bool {
+	sig := sl.signature(service, env)
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	b, ok := sl.buckets[sig]
+	if !ok {
+		limit := sl.limit(sig)
+		b = &tokenBucket{limit: limit, rate: math.Min(limit, sl.share), last: now}
+		b.tokens = b.burst()
+		sl.buckets[sig] = b
+	}
+	return b.take(now)
+}
+
+// rebalance measures the rate of each service during the period which just ended, and
+// computes their maximum rates for the next one.
+func (sl *serviceRateLimiter) rebalance(now time.Time) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	elapsed := now.Sub(sl.periodStart).Seconds()
+	sl.periodStart = now
+	if elapsed <= 0 {
+		return
+	}
+	wants := make([]float64, 0, len(sl.buckets))
+	for sig, b := range sl.buckets {
+		if b.seen == 0 {
+			if b.idle++; b.idle >= serviceLimiterIdlePeriods {
+				delete(sl.buckets, sig)
+			}
+			continue
+		}
+		b.idle = 0
+		wants = append(wants, math.Min(b.seen/elapsed, b.limit))
+		b.seen = 0
+	}
+	sl.share = fairShare(sl.conf.MaxTPS, wants)
+	for _, b := range sl.buckets {
+		b.rate = math.Min(b.limit, sl.share)
+		b.tokens = math.Min(b.tokens, b.burst())
+	}
+}
+
+// fairShare returns the max-min fair share of the total rate between the given wanted rates:
+// the highest rate such that allowing each service min(want, share) stays within total.
+// It returns +Inf if total is 0 or isn't reached.
+func fairShare(total float64, wants []float64) float64 {
+	if total <= 0 {
+		return math.Inf(1)
+	}
+	sort.Float64s(wants)
+	remaining := total
+	for i, want := range wants {
+		share := remaining / float64(len(wants)-i)
+		if want > share {
+			return share
+		}
+		remaining -= want
+	}
+	return math.Inf(1)
+}
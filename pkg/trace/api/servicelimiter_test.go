@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFairShare(t *testing.T) {
+	assert := assert.New(t)
+	inf := math.Inf(1)
+
+	assert.Equal(inf, fairShare(0, []float64{100, 200}))
+	assert.Equal(inf, fairShare(100, nil))
+	assert.Equal(inf, fairShare(100, []float64{20, 30, 50}))
+	assert.Equal(50.0, fairShare(100, []float64{80, 120}))
+	// the share unused by the small services goes to the big ones
+	assert.Equal(45.0, fairShare(100, []float64{10, 300, 60}))
+}
+
+// sendTraces sends n traces of the service every 10ms during the given duration, starting
+// at now, rebalancing the limiter every second. It returns the number of traces accepted.
+func sendTraces(sl *serviceRateLimiter, now time.Time, d time.Duration, services map[string]int) map[string]int {
+	kept := make(map[string]int)
+	for t := now; t.Before(now.Add(d)); t = t.Add(10 * time.Millisecond) {
+		if t.Sub(sl.periodStart) >= serviceLimiterPeriod {
+			sl.rebalance(t)
+		}
+		for service, n := range services {
+			for i := 0; i < n; i++ {
+				if sl.Permits(service, "prod", t) {
+					kept[service]++
+				}
+			}
+		}
+	}
+	return kept
+}
+
+func TestServiceRateLimiter(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		sl := newServiceRateLimiter(&config.ServiceRateLimitConfig{})
+		assert.False(t, sl.Active())
+		kept := sendTraces(sl, sl.periodStart, 2*time.Second, map[string]int{"web": 5})
+		assert.Equal(t, 1000, kept["web"])
+	})
+
+	t.Run("service", func(t *testing.T) {
+		sl := newServiceRateLimiter(&config.ServiceRateLimitConfig{
+			ServiceTPS: 100,
+			Overrides: []*config.ServiceRateLimitOverride{
+				{Service: "db", MaxTPS: 10},
+				{Service: "cache", MaxTPS: 0},
+			},
+		})
+		assert.True(t, sl.Active())
+		// 500 traces per second for each service during 10 seconds, the buckets start full
+		kept := sendTraces(sl, sl.periodStart, 10*time.Second, map[string]int{"web": 5, "db": 5, "cache": 5})
+		assert.InDelta(t, 1100, kept["web"], 10)
+		assert.InDelta(t, 110, kept["db"], 2)
+		assert.Equal(t, 5000, kept["cache"])
+	})
+
+	t.Run("fair", func(t *testing.T) {
+		sl := newServiceRateLimiter(&config.ServiceRateLimitConfig{MaxTPS: 300})
+		// web sends 1000 traces per second, db 100 and cache 400: after the first second, the
+		// global limit is reached and each service gets a third of it, plus a one second burst
+		kept := sendTraces(sl, sl.periodStart, 10*time.Second, map[string]int{"web": 10, "db": 1, "cache": 4})
+		assert.InDelta(t, 100.0, sl.share, 0.1)
+		assert.InDelta(t, 1000, kept["db"], 10)
+		assert.InDelta(t, 2000, kept["web"], 20)
+		assert.InDelta(t, 1400, kept["cache"], 20)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		sl := newServiceRateLimiter(&config.ServiceRateLimitConfig{
+			ServiceTPS: 100,
+			ByEnv:      true,
+			Overrides: []*config.ServiceRateLimitOverride{
+				{Service: "web", MaxTPS: 10},
+				{Service: "web", Env: "prod", MaxTPS: 50},
+			},
+		})
+		assert.Equal(t, 50.0, sl.limit(sl.signature("web", "prod")))
+		assert.Equal(t, 10.0, sl.limit(sl.signature("web", "staging")))
+		assert.Equal(t, 100.0, sl.limit(sl.signature("db", "prod")))
+		assert.Equal(t, "service:web,env:prod", sl.key("web", "prod"))
+
+		sl = newServiceRateLimiter(&config.ServiceRateLimitConfig{ServiceTPS: 100})
+		assert.Equal(t, sl.signature("web", "prod"), sl.signature("web", "staging"))
+		assert.Equal(t, "service:web", sl.key("web", "prod"))
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		sl := newServiceRateLimiter(&config.ServiceRateLimitConfig{ServiceTPS: 100})
+		now := sl.periodStart
+		sl.Permits("web", "prod", now)
+		for i := 1; i < serviceLimiterIdlePeriods+1; i++ {
+			sl.rebalance(now.Add(time.Duration(i) * time.Second))
+		}
+		assert.Len(t, sl.buckets, 1)
+		sl.rebalance(now.Add((serviceLimiterIdlePeriods + 1) * time.Second))
+		assert.Len(t, sl.buckets, 0)
+	})
+}
@@ -10,7 +10,9 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -45,6 +47,42 @@ type ObfuscationConfig struct {
 	// Memcached holds the configuration for obfuscating the "memcached.command" tag
 	// for spans of type "memcached".
 	Memcached Enablable `mapstructure:"memcached"`
+
+	// SensitiveData holds the configuration for replacing the credit card numbers, email
+	// addresses, JWTs and authorization tokens found in the tags of all spans.
+	SensitiveData SensitiveDataObfuscationConfig `mapstructure:"sensitive_data"`
+
+	// GraphQL holds the configuration for obfuscating the argument values of the queries
+	// of spans of type "graphql".
+	GraphQL Enablable `mapstructure:"graphql"`
+
+	// Cassandra holds the configuration for obfuscating the queries of spans of type
+	// "cassandra" as CQL rather than SQL, replacing their collection literals.
+	Cassandra Enablable `mapstructure:"cassandra"`
+
+	// AWS holds the configuration for obfuscating the "aws.*" tags holding request
+	// parameters, such as S3 object keys and DynamoDB keys.
+	AWS AWSObfuscationConfig `mapstructure:"aws"`
+}
+
+// AWSObfuscationConfig holds the configuration for obfuscating the AWS SDK request parameters.
+type AWSObfuscationConfig struct {
+	// Enabled will specify whether the "aws.*" tags should be obfuscated.
+	Enabled bool `mapstructure:"enabled"`
+
+	// KeepValues will specify a set of tags for which their values will
+	// not be obfuscated.
+	KeepValues []string `mapstructure:"keep_values"`
+}
+
+// SensitiveDataObfuscationConfig holds the configuration of the sensitive data scanner.
+type SensitiveDataObfuscationConfig struct {
+	// Enabled will specify whether the tags should be scanned.
+	Enabled bool `mapstructure:"enabled"`
+
+	// KeepValues will specify a set of tags for which their values will
+	// not be scanned.
+	KeepValues []string `mapstructure:"keep_values"`
 }
 
 // HTTPObfuscationConfig holds the configuration settings for HTTP obfuscation.
@@ -90,6 +128,43 @@ type ReplaceRule struct {
 	Repl string `mapstructure:"repl"`
 }
 
+// ServiceRateLimitConfig holds the configuration of the per-service rate limiting of the
+// traces received by the agent.
+type ServiceRateLimitConfig struct {
+	// MaxTPS specifies the maximum number of traces per second accepted from all the
+	// services. Once it is reached, it is shared fairly between the services. 0 disables it.
+	MaxTPS float64 `mapstructure:"max_traces_per_second"`
+
+	// ServiceTPS specifies the maximum number of traces per second accepted from each
+	// service. 0 disables it.
+	ServiceTPS float64 `mapstructure:"service_traces_per_second"`
+
+	// ByEnv specifies whether each env of a service is rate limited separately.
+	ByEnv bool `mapstructure:"by_env"`
+
+	// Overrides replace ServiceTPS for specific services.
+	Overrides []*ServiceRateLimitOverride `mapstructure:"overrides"`
+}
+
+// Enabled reports whether any limit is set.
+func (c *ServiceRateLimitConfig) Enabled() bool {
+	return c.MaxTPS > 0 || c.ServiceTPS > 0 || len(c.Overrides) > 0
+}
+
+// ServiceRateLimitOverride specifies the rate limit of a single service.
+type ServiceRateLimitOverride struct {
+	// Service specifies the name of the service.
+	Service string `mapstructure:"service"`
+
+	// Env restricts the override to an env of the service, it is only used when
+	// ByEnv is set. When empty, the override applies to all the envs.
+	Env string `mapstructure:"env"`
+
+	// MaxTPS specifies the maximum number of traces per second accepted from the
+	// service. 0 disables the limit.
+	MaxTPS float64 `mapstructure:"max_traces_per_second"`
+}
+
 // WriterConfig specifies configuration for an API writer.
 type WriterConfig struct {
 	// ConnectionLimit specifies the maximum number of concurrent outgoing
@@ -195,6 +270,20 @@ func (c *AgentConfig) applyDatadogConfig() error {
 	if config.Datadog.IsSet("apm_config.receiver_socket") {
 		c.ReceiverSocket = config.Datadog.GetString("apm_config.receiver_socket")
 	}
+	if config.Datadog.IsSet("apm_config.receiver_socket_permissions") {
+		perm, err := parseFileMode(config.Datadog.Get("apm_config.receiver_socket_permissions"))
+		if err != nil {
+			log.Errorf("Invalid receiver socket permissions, using %#o: %v", c.ReceiverSocketPermissions, err)
+		} else {
+			c.ReceiverSocketPermissions = perm
+		}
+	}
+	if config.Datadog.IsSet("apm_config.receiver_socket_origin_detection") {
+		c.ReceiverSocketOriginDetection = config.Datadog.GetBool("apm_config.receiver_socket_origin_detection")
+	}
+	if config.Datadog.IsSet("apm_config.debug_traces_enabled") {
+		c.DebugTracesEnabled = config.Datadog.GetBool("apm_config.debug_traces_enabled")
+	}
 	if config.Datadog.IsSet("apm_config.connection_limit") {
 		c.ConnectionLimit = config.Datadog.GetInt("apm_config.connection_limit")
 	}
@@ -265,6 +354,14 @@ func (c *AgentConfig) applyDatadogConfig() error {
 			log.Errorf("Error reading writer config %q: %v", key, err)
 		}
 	}
+	if config.Datadog.IsSet("apm_config.infer_peer_services") {
+		c.InferPeerServices = config.Datadog.GetBool("apm_config.infer_peer_services")
+	}
+	if config.Datadog.IsSet("apm_config.service_rate_limit") {
+		if err := config.Datadog.UnmarshalKey("apm_config.service_rate_limit", c.ServiceRateLimit); err != nil {
+			log.Errorf("Error reading service rate limit config: %v", err)
+		}
+	}
 	if config.Datadog.IsSet("apm_config.connection_reset_interval") {
 		c.ConnectionResetInterval = getDuration(config.Datadog.GetInt("apm_config.connection_reset_interval"))
 	}
@@ -408,3 +505,28 @@ func splitString(s string, sep rune) ([]string, error) {
 
 	return r.Read()
 }
+
+// parseFileMode parses file permissions given as an octal string, e.g. "0660", or as a
+// number, which is how YAML decodes unquoted octal values.
+func parseFileMode(v interface{}) (os.FileMode, error) {
+	var perm uint64
+	switch v := v.(type) {
+	case string:
+		n, err := strconv.ParseUint(strings.TrimSpace(v), 8, 32)
+		if err != nil {
+			return 0, err
+		}
+		perm = n
+	case int:
+		if v < 0 {
+			return 0, fmt.Errorf("negative file mode: %d", v)
+		}
+		perm = uint64(v)
+	default:
+		return 0, fmt.Errorf("unsupported file mode type %T", v)
+	}
+	if perm&^uint64(os.ModePerm) != 0 {
+		return 0, fmt.Errorf("file mode %#o is not made of permission bits", perm)
+	}
+	return os.FileMode(perm), nil
+}
@@ -62,8 +62,9 @@ type AgentConfig struct {
 	Endpoints []*Endpoint
 
 	// Concentrator
-	BucketInterval   time.Duration // the size of our pre-aggregation per bucket
-	ExtraAggregators []string
+	BucketInterval    time.Duration // the size of our pre-aggregation per bucket
+	ExtraAggregators  []string
+	InferPeerServices bool // compute the stats of the services called by the client spans, e.g. databases
 
 	// Sampler configuration
 	ExtraSampleRate float64
@@ -78,6 +79,19 @@ type AgentConfig struct {
 	ReceiverTimeout int
 	MaxRequestBytes int64 // specifies the maximum allowed request size for incoming trace payloads
 
+	// ReceiverSocketPermissions are the file permissions of the receiver socket.
+	ReceiverSocketPermissions os.FileMode
+	// ReceiverSocketOriginDetection specifies whether the traces and profiles received through the
+	// receiver socket are tagged with the tags of the container of the sender, identified by the
+	// credentials of the connection, when the tracer doesn't send its container ID.
+	ReceiverSocketOriginDetection bool
+	// DebugTracesEnabled specifies whether the processed traces are streamed to the clients
+	// of the /debug/traces endpoint of the receiver.
+	DebugTracesEnabled bool
+
+	// ServiceRateLimit holds the configuration of the per-service rate limiting of the traces received.
+	ServiceRateLimit *ServiceRateLimitConfig
+
 	// Writers
 	StatsWriter             *WriterConfig
 	TraceWriter             *WriterConfig
@@ -127,7 +141,7 @@ func New() *AgentConfig {
 		Endpoints:  []*Endpoint{{Host: "https://trace.agent.datadoghq.com"}},
 
 		BucketInterval:   time.Duration(10) * time.Second,
-		ExtraAggregators: []string{"http.status_code", "version", "_dd.hostname"},
+		ExtraAggregators: []string{"version", "_dd.hostname"},
 
 		ExtraSampleRate: 1.0,
 		MaxTPS:          10,
@@ -137,6 +151,10 @@ func New() *AgentConfig {
 		ReceiverPort:    8126,
 		MaxRequestBytes: 50 * 1024 * 1024, // 50MB
 
+		ReceiverSocketPermissions: 0722,
+
+		ServiceRateLimit: new(ServiceRateLimitConfig),
+
 		StatsWriter:             new(WriterConfig),
 		TraceWriter:             new(WriterConfig),
 		ConnectionResetInterval: 0, // disabled
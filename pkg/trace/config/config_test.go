@@ -115,7 +115,7 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal("INFO", c.LogLevel)
 	assert.Equal(true, c.Enabled)
 
-	assert.Equal([]string{"http.status_code", "version", "_dd.hostname"}, c.ExtraAggregators)
+	assert.Equal([]string{"version", "_dd.hostname"}, c.ExtraAggregators)
 }
 
 func TestNoAPMConfig(t *testing.T) {
@@ -157,6 +157,9 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.Equal("test", c.DefaultEnv)
 	assert.Equal(123, c.ConnectionLimit)
 	assert.Equal(18126, c.ReceiverPort)
+	assert.Equal("/var/run/datadog/apm.socket", c.ReceiverSocket)
+	assert.Equal(os.FileMode(0660), c.ReceiverSocketPermissions)
+	assert.True(c.ReceiverSocketOriginDetection)
 	assert.Equal(0.5, c.ExtraSampleRate)
 	assert.Equal(5.0, c.MaxTPS)
 	assert.Equal(50.0, c.MaxEPS)
@@ -204,6 +207,17 @@ func TestFullYamlConfig(t *testing.T) {
 	}, c.ReplaceTags)
 
 	assert.EqualValues([]string{"/health", "/500"}, c.Ignore["resource"])
+	assert.True(c.InferPeerServices)
+
+	assert.Equal(&ServiceRateLimitConfig{
+		MaxTPS:     1000,
+		ServiceTPS: 200,
+		ByEnv:      true,
+		Overrides: []*ServiceRateLimitOverride{
+			{Service: "web", MaxTPS: 500},
+			{Service: "db", Env: "staging", MaxTPS: 10},
+		},
+	}, c.ServiceRateLimit)
 
 	o := c.Obfuscation
 	assert.NotNil(o)
@@ -216,6 +230,12 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.True(o.RemoveStackTraces)
 	assert.True(c.Obfuscation.Redis.Enabled)
 	assert.True(c.Obfuscation.Memcached.Enabled)
+	assert.True(c.Obfuscation.SensitiveData.Enabled)
+	assert.EqualValues([]string{"order_id"}, c.Obfuscation.SensitiveData.KeepValues)
+	assert.True(c.Obfuscation.GraphQL.Enabled)
+	assert.True(c.Obfuscation.Cassandra.Enabled)
+	assert.True(c.Obfuscation.AWS.Enabled)
+	assert.EqualValues([]string{"aws.s3.key"}, c.Obfuscation.AWS.KeepValues)
 }
 
 func TestUndocumentedYamlConfig(t *testing.T) {
@@ -268,3 +288,15 @@ func TestAcquireHostname(t *testing.T) {
 	host, _ := os.Hostname()
 	assert.Equal(t, host, c.Hostname)
 }
+
+func TestParseFileMode(t *testing.T) {
+	for _, v := range []interface{}{"0660", " 660 ", 0660} {
+		perm, err := parseFileMode(v)
+		assert.NoError(t, err, v)
+		assert.Equal(t, os.FileMode(0660), perm, v)
+	}
+	for _, v := range []interface{}{"rw-rw----", "0888", 01777, -1, true} {
+		_, err := parseFileMode(v)
+		assert.Error(t, err, v)
+	}
+}
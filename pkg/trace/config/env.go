@@ -48,6 +48,9 @@ func loadEnv() {
 		{"DD_APM_MAX_MEMORY", "apm_config.max_memory"},
 		{"DD_APM_MAX_CPU_PERCENT", "apm_config.max_cpu_percent"},
 		{"DD_APM_RECEIVER_SOCKET", "apm_config.receiver_socket"},
+		{"DD_APM_RECEIVER_SOCKET_PERMISSIONS", "apm_config.receiver_socket_permissions"},
+		{"DD_APM_RECEIVER_SOCKET_ORIGIN_DETECTION", "apm_config.receiver_socket_origin_detection"},
+		{"DD_APM_DEBUG_TRACES_ENABLED", "apm_config.debug_traces_enabled"},
 		{"DD_APM_PROFILING_DD_URL", "apm_config.profiling_dd_url"},
 	} {
 		if v := os.Getenv(override.env); v != "" {
@@ -324,4 +324,26 @@ func TestLoadEnv(t *testing.T) {
 			t.Fatalf("Failed to process env var %s, expected %v and got %v", env, expected, actual)
 		}
 	})
+
+	env = "DD_APM_RECEIVER_SOCKET_PERMISSIONS"
+	t.Run(env, func(t *testing.T) {
+		assert := assert.New(t)
+		err := os.Setenv(env, "0600")
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal(os.FileMode(0600), cfg.ReceiverSocketPermissions)
+	})
+
+	env = "DD_APM_RECEIVER_SOCKET_ORIGIN_DETECTION"
+	t.Run(env, func(t *testing.T) {
+		assert := assert.New(t)
+		err := os.Setenv(env, "false")
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.False(cfg.ReceiverSocketOriginDetection)
+	})
 }
@@ -23,6 +23,13 @@ var (
 	// Info will display information about a running agent.
 	Info bool
 
+	// Tail will stream the traces processed by a running agent.
+	Tail bool
+
+	// TailServices is the comma separated list of services whose traces are streamed
+	// with Tail. When empty, all the traces are streamed.
+	TailServices string
+
 	// CPUProfile specifies the path to output CPU profiling information to.
 	// When empty, CPU profiling is disabled.
 	CPUProfile string
@@ -46,6 +53,8 @@ func init() {
 	flag.StringVar(&PIDFilePath, "pid", "", "Path to set pidfile for process")
 	flag.BoolVar(&Version, "version", false, "Show version information and exit")
 	flag.BoolVar(&Info, "info", false, "Show info about running trace agent process and exit")
+	flag.BoolVar(&Tail, "tail", false, "Stream the traces processed by the running trace agent")
+	flag.StringVar(&TailServices, "tail-services", "", "Comma separated list of the services whose traces are streamed with -tail")
 
 	// profiling
 	flag.StringVar(&CPUProfile, "cpuprofile", "", "Write cpu profile to file")
@@ -40,6 +40,7 @@ var (
 	errorsSamplerInfo   SamplerInfo
 	rateByService       map[string]float64
 	rateLimiterStats    RateLimiterStats
+	serviceRateLimited  map[string]int64
 	start               = time.Now()
 	once                sync.Once
 	infoTmpl            *template.Template
@@ -80,6 +81,9 @@ const (
   {{if lt .Status.RateLimiter.TargetRate 1.0}}
   WARNING: Rate-limiter keep percentage: {{percent .Status.RateLimiter.TargetRate}} %
   {{end}}
+  {{ range $key, $value := .Status.ServiceRateLimited }}
+  WARNING: Traces dropped by the rate limit of '{{ $key }}': {{ $value }}
+  {{ end }}
 
   --- Writer stats (1 min) ---
 
@@ -123,6 +127,11 @@ func UpdateReceiverStats(rs *ReceiverStats) {
 
 	receiverStats = s
 	languages = rs.Languages()
+
+	serviceRateLimited = make(map[string]int64, len(rs.ServiceRateLimited))
+	for service, n := range rs.ServiceRateLimited {
+		serviceRateLimited[service] = n
+	}
 }
 
 // Languages exposes languages reporting traces to the Agent.
@@ -233,6 +242,12 @@ func publishRateLimiterStats() interface{} {
 	return rateLimiterStats
 }
 
+func publishServiceRateLimited() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return serviceRateLimited
+}
+
 func publishUptime() interface{} {
 	return int(time.Since(start) / time.Second)
 }
@@ -267,6 +282,7 @@ func InitInfo(conf *config.AgentConfig) error {
 		expvar.Publish("ratebyservice", expvar.Func(publishRateByService))
 		expvar.Publish("watchdog", expvar.Func(publishWatchdogInfo))
 		expvar.Publish("ratelimiter", expvar.Func(publishRateLimiterStats))
+		expvar.Publish("service_rate_limited", expvar.Func(publishServiceRateLimited))
 
 		// copy the config to ensure we don't expose sensitive data such as API keys
 		c := *conf
@@ -317,14 +333,15 @@ type StatusInfo struct {
 	MemStats struct {
 		Alloc uint64
 	} `json:"memstats"`
-	Version       infoVersion        `json:"version"`
-	Receiver      []TagStats         `json:"receiver"`
-	RateByService map[string]float64 `json:"ratebyservice"`
-	TraceWriter   TraceWriterInfo    `json:"trace_writer"`
-	StatsWriter   StatsWriterInfo    `json:"stats_writer"`
-	Watchdog      watchdog.Info      `json:"watchdog"`
-	RateLimiter   RateLimiterStats   `json:"ratelimiter"`
-	Config        config.AgentConfig `json:"config"`
+	Version            infoVersion        `json:"version"`
+	Receiver           []TagStats         `json:"receiver"`
+	RateByService      map[string]float64 `json:"ratebyservice"`
+	TraceWriter        TraceWriterInfo    `json:"trace_writer"`
+	StatsWriter        StatsWriterInfo    `json:"stats_writer"`
+	Watchdog           watchdog.Info      `json:"watchdog"`
+	RateLimiter        RateLimiterStats   `json:"ratelimiter"`
+	ServiceRateLimited map[string]int64   `json:"service_rate_limited"`
+	Config             config.AgentConfig `json:"config"`
 }
 
 func getProgramBanner(version string) (string, string) {
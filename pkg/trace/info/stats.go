@@ -20,11 +20,22 @@ import (
 type ReceiverStats struct {
 	sync.RWMutex
 	Stats map[Tags]*TagStats
+	// ServiceRateLimited counts the traces dropped by the per-service rate limiting of the
+	// receiver, by service signature (e.g. "service:web,env:prod").
+	ServiceRateLimited map[string]int64
 }
 
 // NewReceiverStats returns a new ReceiverStats
 func NewReceiverStats() *ReceiverStats {
-	return &ReceiverStats{sync.RWMutex{}, map[Tags]*TagStats{}}
+	return &ReceiverStats{sync.RWMutex{}, map[Tags]*TagStats{}, map[string]int64{}}
+}
+
+// AddServiceRateLimited records n traces of the given service signature dropped by the
+// per-service rate limiting.
+func (rs *ReceiverStats) AddServiceRateLimited(service string, n int64) {
+	rs.Lock()
+	rs.ServiceRateLimited[service] += n
+	rs.Unlock()
 }
 
 // GetTagStats returns the struct in which the stats will be stored depending of their tags.
@@ -32,7 +43,7 @@ func (rs *ReceiverStats) GetTagStats(tags Tags) *TagStats {
 	rs.Lock()
 	tagStats, ok := rs.Stats[tags]
 	if !ok {
-		tagStats = newTagStats(tags)
+		tagStats = NewTagStats(tags)
 		rs.Stats[tags] = tagStats
 	}
 	rs.Unlock()
@@ -45,7 +56,10 @@ func (rs *ReceiverStats) Acc(recent *ReceiverStats) {
 	recent.Lock()
 	for _, tagStats := range recent.Stats {
 		ts := rs.GetTagStats(tagStats.Tags)
-		ts.update(&tagStats.Stats)
+		ts.Update(&tagStats.Stats)
+	}
+	for service, n := range recent.ServiceRateLimited {
+		rs.AddServiceRateLimited(service, n)
 	}
 	recent.Unlock()
 }
@@ -56,6 +70,10 @@ func (rs *ReceiverStats) Publish() {
 	for _, tagStats := range rs.Stats {
 		tagStats.publish()
 	}
+	for service, n := range rs.ServiceRateLimited {
+		// the service signature is made of comma separated tags
+		metrics.Count("datadog.trace_agent.receiver.service_rate_limited", n, strings.Split(service, ","), 1)
+	}
 	rs.RUnlock()
 }
 
@@ -89,6 +107,7 @@ func (rs *ReceiverStats) Reset() {
 		}
 		tagStats.reset()
 	}
+	rs.ServiceRateLimited = make(map[string]int64)
 	rs.Unlock()
 }
 
@@ -97,6 +116,10 @@ func (rs *ReceiverStats) LogStats() {
 	rs.RLock()
 	defer rs.RUnlock()
 
+	for service, n := range rs.ServiceRateLimited {
+		log.Warnf("%s -> %d traces dropped by the per-service rate limiting", service, n)
+	}
+
 	if len(rs.Stats) == 0 {
 		log.Info("No data received")
 		return
@@ -120,7 +143,8 @@ type TagStats struct {
 	Stats
 }
 
-func newTagStats(tags Tags) *TagStats {
+// NewTagStats returns empty stats for the given set of tags.
+func NewTagStats(tags Tags) *TagStats {
 	return &TagStats{tags, Stats{TracesDropped: &TracesDropped{}, SpansMalformed: &SpansMalformed{}}}
 }
 
@@ -272,6 +296,20 @@ func (s *SpansMalformed) String() string {
 	return mapToString(s.tagValues())
 }
 
+// NormalizationIssues returns the number of dropped traces and malformed spans by reason,
+// only the reasons with a non-zero count are included.
+func (s *Stats) NormalizationIssues() map[string]int64 {
+	issues := make(map[string]int64)
+	for _, values := range []map[string]int64{s.TracesDropped.tagValues(), s.SpansMalformed.tagValues()} {
+		for reason, count := range values {
+			if count > 0 {
+				issues[reason] = count
+			}
+		}
+	}
+	return issues
+}
+
 // Stats holds the metrics that will be reported every 10s by the agent.
 // Its fields require to be accessed in an atomic way.
 type Stats struct {
@@ -311,7 +349,8 @@ type Stats struct {
 	PayloadRefused int64
 }
 
-func (s *Stats) update(recent *Stats) {
+// Update adds the stats of recent to s.
+func (s *Stats) Update(recent *Stats) {
 	atomic.AddInt64(&s.TracesReceived, atomic.LoadInt64(&recent.TracesReceived))
 
 	atomic.AddInt64(&s.TracesDropped.DecodingError, atomic.LoadInt64(&recent.TracesDropped.DecodingError))
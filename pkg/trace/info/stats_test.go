@@ -83,3 +83,18 @@ func TestStatsTags(t *testing.T) {
 		"endpoint_version:v0.4",
 	})
 }
+
+func TestServiceRateLimited(t *testing.T) {
+	recent := NewReceiverStats()
+	recent.AddServiceRateLimited("service:web", 2)
+	recent.AddServiceRateLimited("service:web", 3)
+	recent.AddServiceRateLimited("service:db", 1)
+
+	acc := NewReceiverStats()
+	acc.AddServiceRateLimited("service:web", 1)
+	acc.Acc(recent)
+	assert.Equal(t, map[string]int64{"service:web": 6, "service:db": 1}, acc.ServiceRateLimited)
+
+	acc.Reset()
+	assert.Empty(t, acc.ServiceRateLimited)
+}
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+const awsTagPrefix = "aws."
+
+// awsSensitiveParams holds the AWS SDK request parameters which hold user data, such as
+// S3 object keys and DynamoDB keys and items. They are matched against the last segment
+// of the tags, lower cased and without underscores, so that both "aws.dynamodb.exclusive_start_key"
+// and "aws.dynamodb.ExclusiveStartKey" are obfuscated.
+var awsSensitiveParams = map[string]bool{
+	// S3
+	"key":        true,
+	"copysource": true,
+	"prefix":     true,
+	// DynamoDB
+	"keys":                      true,
+	"item":                      true,
+	"items":                     true,
+	"exclusivestartkey":         true,
+	"expressionattributevalues": true,
+	"keyconditions":             true,
+	"attributeupdates":          true,
+	// SQS, SNS and Kinesis
+	"messagebody": true,
+	"message":     true,
+	"data":        true,
+}
+
+// awsObfuscator replaces the values of the "aws.*" tags holding sensitive request parameters.
+type awsObfuscator struct {
+	keepValues map[string]bool // these tags will not be obfuscated
+}
+
+func newAWSObfuscator(cfg *config.AWSObfuscationConfig) *awsObfuscator {
+	keepValues := make(map[string]bool, len(cfg.KeepValues))
+	for _, k := range cfg.KeepValues {
+		keepValues[k] = true
+	}
+	return &awsObfuscator{keepValues: keepValues}
+}
+
+// obfuscate replaces with "?" the values of the sensitive "aws.*" tags of the span.
+func (o *awsObfuscator) obfuscate(span *pb.Span) {
+	for k, v := range span.Meta {
+		if v == "" || !strings.HasPrefix(k, awsTagPrefix) || o.keepValues[k] {
+			continue
+		}
+		if isAWSSensitiveParam(k) {
+			span.Meta[k] = "?"
+		}
+	}
+}
+
+// isAWSSensitiveParam reports whether the given tag holds a sensitive request parameter.
+func isAWSSensitiveParam(tag string) bool {
+	param := tag[strings.LastIndexByte(tag, '.')+1:]
+	param = strings.ToLower(strings.Replace(param, "_", "", -1))
+	return awsSensitiveParams[param]
+}
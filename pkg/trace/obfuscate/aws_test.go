@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateAWS(t *testing.T) {
+	span := &pb.Span{
+		Type: "http",
+		Meta: map[string]string{
+			"aws.operation":                    "GetObject",
+			"aws.region":                       "us-east-1",
+			"aws.bucket.name":                  "invoices",
+			"aws.object.key":                   "customers/jane/invoice.pdf",
+			"aws.s3.copy_source":               "invoices/customers/john/invoice.pdf",
+			"aws.table.name":                   "users",
+			"aws.dynamodb.Key":                 `{"email":{"S":"jane@example.com"}}`,
+			"aws.dynamodb.exclusive_start_key": `{"email":{"S":"john@example.com"}}`,
+			"aws.dynamodb.item":                `{"ssn":{"S":"078-05-1120"}}`,
+			"aws.sqs.message_body":             "hello",
+			"params.Key":                       "customers/jane/invoice.pdf",
+			"key":                              "kept",
+		},
+	}
+	newAWSObfuscator(&config.AWSObfuscationConfig{
+		Enabled:    true,
+		KeepValues: []string{"aws.sqs.message_body"},
+	}).obfuscate(span)
+	assert.Equal(t, map[string]string{
+		"aws.operation":                    "GetObject",
+		"aws.region":                       "us-east-1",
+		"aws.bucket.name":                  "invoices",
+		"aws.object.key":                   "?",
+		"aws.s3.copy_source":               "?",
+		"aws.table.name":                   "users",
+		"aws.dynamodb.Key":                 "?",
+		"aws.dynamodb.exclusive_start_key": "?",
+		"aws.dynamodb.item":                "?",
+		"aws.sqs.message_body":             "hello",
+		"params.Key":                       "customers/jane/invoice.pdf",
+		"key":                              "kept",
+	}, span.Meta)
+}
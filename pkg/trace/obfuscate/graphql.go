@@ -0,0 +1,296 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const graphqlSourceTag = "graphql.source"
+const nonParsableGraphQLResource = "Non-parsable GraphQL query"
+
+// obfuscateGraphQL obfuscates the resource and the "graphql.source" tag of the span,
+// replacing the literal values of the arguments with "?".
+func (*Obfuscator) obfuscateGraphQL(span *pb.Span) {
+	if span.Resource != "" {
+		span.Resource = obfuscateGraphQLQuery(span.Resource)
+	}
+	if span.Meta != nil && span.Meta[graphqlSourceTag] != "" {
+		span.Meta[graphqlSourceTag] = obfuscateGraphQLQuery(span.Meta[graphqlSourceTag])
+	}
+}
+
+// obfuscateGraphQLQuery returns the obfuscated query, or a placeholder if it can't be parsed.
+func obfuscateGraphQLQuery(query string) string {
+	out, err := ObfuscateGraphQLString(query)
+	if err != nil {
+		// discard the query to avoid leaking the values of its arguments
+		log.Debugf("Error parsing GraphQL query: %v. Query: %q", err, query)
+		return nonParsableGraphQLResource
+	}
+	return out
+}
+
+// ObfuscateGraphQLString obfuscates the given GraphQL document: the literal values of the
+// arguments, of the input objects fields and of the variables defaults are replaced with "?",
+// lists being replaced as a whole. The operations, selection sets, aliases, fragments, directives
+// and variables are kept, so that the shape of the operation remains. The comments are removed
+// and the whitespaces compacted.
+func ObfuscateGraphQLString(in string) (string, error) {
+	g := graphqlObfuscator{in: in}
+	if err := g.obfuscate(); err != nil {
+		return "", err
+	}
+	if g.out.Len() == 0 {
+		return "", errors.New("result is empty")
+	}
+	return g.out.String(), nil
+}
+
+// graphqlTokenKind is the kind of a GraphQL token. The punctuators are their own kind.
+type graphqlTokenKind rune
+
+const (
+	graphqlEOF graphqlTokenKind = -(iota + 1)
+	graphqlName
+	graphqlVariable
+	graphqlNumber
+	graphqlString
+	graphqlSpread
+)
+
+// graphqlClosing maps the opening punctuators to their closing counterparts.
+var graphqlClosing = map[graphqlTokenKind]graphqlTokenKind{'(': ')', '{': '}', '[': ']'}
+
+// graphqlObfuscator holds the state of the obfuscation of a GraphQL document.
+type graphqlObfuscator struct {
+	in  string
+	pos int // offset of the next token in in
+	out strings.Builder
+
+	// space is true if whitespaces or comments were skipped since the last token written
+	space bool
+}
+
+// obfuscate writes the obfuscated document to g.out.
+func (g *graphqlObfuscator) obfuscate() error {
+	// scopes holds the opening punctuator of the blocks being scanned
+	var scopes []graphqlTokenKind
+	// variable is true if the last token is a variable, in which case a ':' is followed
+	// by the type of a variable definition rather than by a value
+	variable := false
+	for {
+		kind, tok, err := g.scan()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case graphqlEOF:
+			if len(scopes) > 0 {
+				return fmt.Errorf("unexpected EOF, expected the closing of %q", scopes[len(scopes)-1])
+			}
+			return nil
+		case '(', '{', '[':
+			scopes = append(scopes, kind)
+		case ')', '}', ']':
+			if len(scopes) == 0 || graphqlClosing[scopes[len(scopes)-1]] != kind {
+				return fmt.Errorf("at position %d: unexpected %q", g.pos-1, tok)
+			}
+			scopes = scopes[:len(scopes)-1]
+		}
+		g.write(tok)
+		switch {
+		case kind == ':' && !variable && len(scopes) > 0 && scopes[len(scopes)-1] == '(':
+			// argument value
+			fallthrough
+		case kind == '=':
+			// default value of a variable
+			if err := g.value(); err != nil {
+				return err
+			}
+		}
+		variable = kind == graphqlVariable
+	}
+}
+
+// value scans a value and writes its obfuscated form: the variables are kept, the fields
+// of the input objects are obfuscated and all other values are replaced with "?".
+func (g *graphqlObfuscator) value() error {
+	kind, tok, err := g.scan()
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case graphqlVariable:
+		g.write(tok)
+		return nil
+	case graphqlName, graphqlNumber, graphqlString:
+		g.write("?")
+		return nil
+	case '[':
+		// the whitespaces of the list are skipped along with it
+		space := g.space
+		for depth := 1; depth > 0; {
+			kind, _, err := g.scan()
+			switch {
+			case err != nil:
+				return err
+			case kind == graphqlEOF:
+				return errors.New("unexpected EOF in list")
+			case kind == '[':
+				depth++
+			case kind == ']':
+				depth--
+			}
+		}
+		g.space = space
+		g.write("?")
+		return nil
+	case '{':
+		g.write(tok)
+		for {
+			kind, tok, err := g.scan()
+			if err != nil {
+				return err
+			}
+			switch kind {
+			case '}':
+				g.write(tok)
+				return nil
+			case ',':
+				g.write(tok)
+				continue
+			case graphqlName:
+				g.write(tok)
+			default:
+				return g.unexpected(kind, tok)
+			}
+			if kind, tok, err = g.scan(); err != nil {
+				return err
+			}
+			if kind != ':' {
+				return g.unexpected(kind, tok)
+			}
+			g.write(tok)
+			if err := g.value(); err != nil {
+				return err
+			}
+		}
+	default:
+		return g.unexpected(kind, tok)
+	}
+}
+
+func (g *graphqlObfuscator) unexpected(kind graphqlTokenKind, tok string) error {
+	if kind == graphqlEOF {
+		return errors.New("unexpected EOF in value")
+	}
+	return fmt.Errorf("at position %d: unexpected %q in value", g.pos, tok)
+}
+
+// write writes the token to the output, preceded by a space if whitespaces were skipped.
+func (g *graphqlObfuscator) write(tok string) {
+	if g.space && g.out.Len() > 0 {
+		g.out.WriteByte(' ')
+	}
+	g.space = false
+	g.out.WriteString(tok)
+}
+
+// scan returns the next token, skipping the whitespaces and the comments.
+func (g *graphqlObfuscator) scan() (graphqlTokenKind, string, error) {
+	for g.pos < len(g.in) {
+		switch c := g.in[g.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			g.pos++
+			g.space = true
+		case c == '#':
+			for g.pos < len(g.in) && g.in[g.pos] != '\n' && g.in[g.pos] != '\r' {
+				g.pos++
+			}
+			g.space = true
+		default:
+			return g.scanToken()
+		}
+	}
+	return graphqlEOF, "", nil
+}
+
+func (g *graphqlObfuscator) scanToken() (graphqlTokenKind, string, error) {
+	start := g.pos
+	c := g.in[g.pos]
+	switch {
+	case isGraphQLNameStart(c):
+		g.pos = g.scanName(g.pos)
+		return graphqlName, g.in[start:g.pos], nil
+	case c == '$':
+		if g.pos+1 >= len(g.in) || !isGraphQLNameStart(g.in[g.pos+1]) {
+			return 0, "", fmt.Errorf("at position %d: expected a variable name", g.pos)
+		}
+		g.pos = g.scanName(g.pos + 1)
+		return graphqlVariable, g.in[start:g.pos], nil
+	case c == '-' || isDigit(rune(c)):
+		g.pos++
+		for g.pos < len(g.in) && strings.IndexByte("0123456789.eE+-", g.in[g.pos]) >= 0 {
+			g.pos++
+		}
+		return graphqlNumber, g.in[start:g.pos], nil
+	case strings.HasPrefix(g.in[g.pos:], `"""`):
+		end := strings.Index(g.in[g.pos+3:], `"""`)
+		for end > 0 && g.in[g.pos+3+end-1] == '\\' {
+			// escaped triple quote
+			next := strings.Index(g.in[g.pos+3+end+3:], `"""`)
+			if next == -1 {
+				end = -1
+				break
+			}
+			end += 3 + next
+		}
+		if end == -1 {
+			return 0, "", errors.New("unexpected EOF in block string")
+		}
+		g.pos += 3 + end + 3
+		return graphqlString, g.in[start:g.pos], nil
+	case c == '"':
+		for g.pos++; g.pos < len(g.in); g.pos++ {
+			switch g.in[g.pos] {
+			case '\\':
+				g.pos++
+			case '"':
+				g.pos++
+				return graphqlString, g.in[start:g.pos], nil
+			case '\n', '\r':
+				return 0, "", fmt.Errorf("at position %d: unexpected end of line in string", g.pos)
+			}
+		}
+		return 0, "", errors.New("unexpected EOF in string")
+	case strings.HasPrefix(g.in[g.pos:], "..."):
+		g.pos += 3
+		return graphqlSpread, "...", nil
+	case strings.IndexByte("!&()=:@[]{}|,", c) >= 0:
+		g.pos++
+		return graphqlTokenKind(c), g.in[start:g.pos], nil
+	default:
+		return 0, "", fmt.Errorf("at position %d: unexpected byte %d", g.pos, c)
+	}
+}
+
+// scanName returns the offset of the end of the name starting at i.
+func (g *graphqlObfuscator) scanName(i int) int {
+	for i < len(g.in) && (isGraphQLNameStart(g.in[i]) || isDigit(rune(g.in[i]))) {
+		i++
+	}
+	return i
+}
+
+func isGraphQLNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateGraphQLString(t *testing.T) {
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{
+			`{ user(id: 42) { name } }`,
+			`{ user(id: ?) { name } }`,
+		},
+		{
+			`query GetUser($id: ID!, $active: Boolean = true) { user(id: $id, active: $active) { name email } }`,
+			`query GetUser($id: ID!, $active: Boolean = ?) { user(id: $id, active: $active) { name email } }`,
+		},
+		{
+			"mutation {\n  # set the password\n  login(email: \"jane@example.com\", password: \"hunter2\") {\n    token\n  }\n}",
+			"mutation { login(email: ?, password: ?) { token } }",
+		},
+		{
+			`mutation { createUser(input: {name: "Jane", age: 37, tags: ["a", "b"], address: {zip: "75001"}}) { id } }`,
+			`mutation { createUser(input: {name: ?, age: ?, tags: ?, address: {zip: ?}}) { id } }`,
+		},
+		{
+			`{ me: user(id:1) { friends(first: 10, orderBy: NAME_ASC, ids:[1, [2, 3]]) @include(if: false) { ...FriendFields } } }`,
+			`{ me: user(id:?) { friends(first: ?, orderBy: ?, ids:?) @include(if: ?) { ...FriendFields } } }`,
+		},
+		{
+			`{ search(text: """multi "quoted" \""" line""", score: -1.5e3) { ... on Page { title } } }`,
+			`{ search(text: ?, score: ?) { ... on Page { title } } }`,
+		},
+		{
+			`query($filter: [String!] = ["a"]) { items(filter: $filter) { id } }`,
+			`query($filter: [String!] = ?) { items(filter: $filter) { id } }`,
+		},
+	} {
+		out, err := ObfuscateGraphQLString(tt.in)
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.out, out)
+	}
+
+	for _, in := range []string{
+		``,
+		`# only a comment`,
+		`{ user(id: "42) { name } }`,
+		`{ user(id: 42 { name } }`,
+		`{ search(text: """never closed) }`,
+		`{ user(input: {id 42}) { name } }`,
+		`{ user(id: 42) { name } } %`,
+	} {
+		_, err := ObfuscateGraphQLString(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestObfuscateGraphQL(t *testing.T) {
+	span := &pb.Span{
+		Type:     "graphql",
+		Resource: `{ user(id: 42) { name } }`,
+		Meta:     map[string]string{graphqlSourceTag: `{ user(id: 42) { name } }`},
+	}
+	NewObfuscator(nil).obfuscateGraphQL(span)
+	assert.Equal(t, `{ user(id: ?) { name } }`, span.Resource)
+	assert.Equal(t, `{ user(id: ?) { name } }`, span.Meta[graphqlSourceTag])
+
+	span = &pb.Span{Type: "graphql", Resource: `{ user(id: "42) { name } }`}
+	NewObfuscator(nil).obfuscateGraphQL(span)
+	assert.Equal(t, nonParsableGraphQLResource, span.Resource)
+}
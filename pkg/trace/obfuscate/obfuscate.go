@@ -21,6 +21,10 @@ type Obfuscator struct {
 	opts  *config.ObfuscationConfig
 	es    *jsonObfuscator // nil if disabled
 	mongo *jsonObfuscator // nil if disabled
+	// sensitive detects the sensitive data in the tags of all spans, nil if disabled
+	sensitive *sensitiveDataScanner
+	// aws obfuscates the AWS SDK request parameters of all spans, nil if disabled
+	aws *awsObfuscator
 	// sqlLiteralEscapes reports whether we should treat escape characters literally or as escape characters.
 	// A non-zero value means 'yes'. Different SQL engines behave in different ways and the tokenizer needs
 	// to be generic.
@@ -54,6 +58,12 @@ func NewObfuscator(cfg *config.ObfuscationConfig) *Obfuscator {
 	if cfg.Mongo.Enabled {
 		o.mongo = newJSONObfuscator(&cfg.Mongo)
 	}
+	if cfg.SensitiveData.Enabled {
+		o.sensitive = newSensitiveDataScanner(&cfg.SensitiveData)
+	}
+	if cfg.AWS.Enabled {
+		o.aws = newAWSObfuscator(&cfg.AWS)
+	}
 	return &o
 }
 
@@ -78,6 +88,24 @@ func (o *Obfuscator) Obfuscate(span *pb.Span) {
 		o.obfuscateJSON(span, "mongodb.query", o.mongo)
 	case "elasticsearch":
 		o.obfuscateJSON(span, "elasticsearch.body", o.es)
+	case "graphql":
+		if o.opts.GraphQL.Enabled {
+			o.obfuscateGraphQL(span)
+		}
+	}
+	if o.aws != nil {
+		// the AWS SDK spans have the type of their transport, e.g. "http"
+		o.aws.obfuscate(span)
+	}
+	if o.sensitive != nil {
+		o.sensitive.scanSpan(span)
+	}
+}
+
+// ReportStats reports the telemetry of the obfuscator, it is safe for concurrent use.
+func (o *Obfuscator) ReportStats() {
+	if o.sensitive != nil {
+		o.sensitive.report()
 	}
 }
 
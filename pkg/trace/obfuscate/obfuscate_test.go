@@ -218,6 +218,38 @@ func TestObfuscateConfig(t *testing.T) {
 		"set key 0 0 0 noreply\r\nvalue",
 		&config.ObfuscationConfig{},
 	))
+
+	t.Run("graphql/enabled", testConfig(
+		"graphql",
+		"graphql.source",
+		`{ user(id: 42) { name } }`,
+		`{ user(id: ?) { name } }`,
+		&config.ObfuscationConfig{GraphQL: config.Enablable{Enabled: true}},
+	))
+
+	t.Run("graphql/disabled", testConfig(
+		"graphql",
+		"graphql.source",
+		`{ user(id: 42) { name } }`,
+		`{ user(id: 42) { name } }`,
+		&config.ObfuscationConfig{},
+	))
+
+	t.Run("aws/enabled", testConfig(
+		"http",
+		"aws.object.key",
+		"customers/jane/invoice.pdf",
+		"?",
+		&config.ObfuscationConfig{AWS: config.AWSObfuscationConfig{Enabled: true}},
+	))
+
+	t.Run("aws/disabled", testConfig(
+		"http",
+		"aws.object.key",
+		"customers/jane/invoice.pdf",
+		"customers/jane/invoice.pdf",
+		&config.ObfuscationConfig{},
+	))
 }
 
 func TestLiteralEscapes(t *testing.T) {
@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// sensitiveDataType is a type of sensitive data detected by the sensitiveDataScanner
+type sensitiveDataType int
+
+const (
+	sensitiveToken sensitiveDataType = iota
+	sensitiveJWT
+	sensitiveEmail
+	sensitiveCreditCard
+	numSensitiveDataTypes
+)
+
+var (
+	sensitiveDataNames = [numSensitiveDataTypes]string{"token", "jwt", "email", "credit_card"}
+
+	sensitiveDataPlaceholders = [numSensitiveDataTypes]string{
+		"?token",
+		"?jwt",
+		"?email",
+		"?credit_card",
+	}
+
+	// tokenRe matches the credentials of Authorization header style values, the scheme is kept.
+	tokenRe = regexp.MustCompile(`(?i)\b(bearer|basic|digest|token)(\s+)[A-Za-z0-9\-._~+/]{8,}=*`)
+	// jwtRe matches JSON Web Tokens, their header and payload are base64 encoded JSON objects.
+	jwtRe = regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	// emailRe matches email addresses
+	emailRe = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	// cardCandidateRe matches sequences of 13 to 19 digits, optionally separated by single spaces or dashes.
+	// The candidates are validated with the Luhn algorithm and the issuer prefixes.
+	cardCandidateRe = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+)
+
+// sensitiveDataDefaultKeepValues are the tags which are never scanned: their values are known
+// not to hold sensitive data or are already handled by the obfuscator.
+var sensitiveDataDefaultKeepValues = []string{
+	"component",
+	"env",
+	"error.stack",
+	"http.method",
+	"http.status_code",
+	"language",
+	"runtime-id",
+	"span.kind",
+	"sql.query",
+	"version",
+}
+
+// sensitiveDataScanner detects and replaces credit card numbers, email addresses, JWTs and
+// authorization tokens in the values of the span tags. It is safe for concurrent use.
+type sensitiveDataScanner struct {
+	keepValues map[string]bool
+	// counts holds the number of values replaced by type since the last report
+	counts [numSensitiveDataTypes]int64
+}
+
+func newSensitiveDataScanner(cfg *config.SensitiveDataObfuscationConfig) *sensitiveDataScanner {
+	keepValues := make(map[string]bool, len(sensitiveDataDefaultKeepValues)+len(cfg.KeepValues))
+	for _, k := range sensitiveDataDefaultKeepValues {
+		keepValues[k] = true
+	}
+	for _, k := range cfg.KeepValues {
+		keepValues[k] = true
+	}
+	return &sensitiveDataScanner{keepValues: keepValues}
+}
+
+// scanSpan replaces the sensitive data found in the tag values of the span
+func (s *sensitiveDataScanner) scanSpan(span *pb.Span) {
+	for k, v := range span.Meta {
+		// the internal tags are never scanned
+		if s.keepValues[k] || strings.HasPrefix(k, "_dd.") {
+			continue
+		}
+		if scanned, ok := s.scan(v); ok {
+			span.Meta[k] = scanned
+		}
+	}
+}
+
+// scan returns the value with its sensitive data replaced, and whether some were found
+func (s *sensitiveDataScanner) scan(v string) (string, bool) {
+	found := false
+	// The cheap checks skip the regular expressions when the value can't match them.
+	if strings.IndexAny(v, "\t\n\f\r ") != -1 {
+		v = tokenRe.ReplaceAllStringFunc(v, func(match string) string {
+			found = true
+			s.count(sensitiveToken)
+			sub := tokenRe.FindStringSubmatch(match)
+			return sub[1] + sub[2] + sensitiveDataPlaceholders[sensitiveToken]
+		})
+	}
+	if strings.Contains(v, "eyJ") {
+		v = jwtRe.ReplaceAllStringFunc(v, func(string) string {
+			found = true
+			s.count(sensitiveJWT)
+			return sensitiveDataPlaceholders[sensitiveJWT]
+		})
+	}
+	if strings.IndexByte(v, '@') != -1 {
+		v = emailRe.ReplaceAllStringFunc(v, func(string) string {
+			found = true
+			s.count(sensitiveEmail)
+			return sensitiveDataPlaceholders[sensitiveEmail]
+		})
+	}
+	if countDigits(v) >= 13 {
+		v = cardCandidateRe.ReplaceAllStringFunc(v, func(match string) string {
+			if !isCreditCardNumber(match) {
+				return match
+			}
+			found = true
+			s.count(sensitiveCreditCard)
+			return sensitiveDataPlaceholders[sensitiveCreditCard]
+		})
+	}
+	return v, found
+}
+
+func (s *sensitiveDataScanner) count(t sensitiveDataType) {
+	atomic.AddInt64(&s.counts[t], 1)
+}
+
+// report sends the number of values replaced by type since the last report
+func (s *sensitiveDataScanner) report() {
+	for t := sensitiveDataType(0); t < numSensitiveDataTypes; t++ {
+		if n := atomic.SwapInt64(&s.counts[t], 0); n > 0 {
+			metrics.Count("datadog.trace_agent.obfuscations.sensitive_data", n, []string{"type:" + sensitiveDataNames[t]}, 1)
+		}
+	}
+}
+
+func countDigits(v string) int {
+	n := 0
+	for i := 0; i < len(v); i++ {
+		if v[i] >= '0' && v[i] <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// isCreditCardNumber reports whether the candidate, digits with optional separators, is a
+// card number: 13 to 19 digits with the prefix of a major issuer and a valid Luhn checksum.
+func isCreditCardNumber(candidate string) bool {
+	digits := make([]byte, 0, len(candidate))
+	for i := 0; i < len(candidate); i++ {
+		if c := candidate[i]; c >= '0' && c <= '9' {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	return hasIssuerPrefix(digits) && luhnValid(digits)
+}
+
+// hasIssuerPrefix reports whether the number starts with the prefix of Visa, Mastercard,
+// American Express, Discover, JCB or Diners Club
+func hasIssuerPrefix(digits []byte) bool {
+	prefix := func(n int) int {
+		v := 0
+		for _, c := range digits[:n] {
+			v = v*10 + int(c-'0')
+		}
+		return v
+	}
+	switch p2 := prefix(2); {
+	case digits[0] == '4':
+		return true // Visa
+	case p2 >= 51 && p2 <= 55:
+		return true // Mastercard
+	case p2 == 34 || p2 == 37:
+		return true // American Express
+	case p2 == 36 || p2 == 38 || p2 == 65:
+		return true // Diners Club, Discover
+	}
+	switch p3 := prefix(3); {
+	case p3 >= 300 && p3 <= 305:
+		return true // Diners Club
+	case p3 >= 644 && p3 <= 649:
+		return true // Discover
+	}
+	switch p4 := prefix(4); {
+	case p4 == 6011:
+		return true // Discover
+	case p4 >= 2221 && p4 <= 2720:
+		return true // Mastercard
+	case p4 >= 3528 && p4 <= 3589:
+		return true // JCB
+	}
+	return false
+}
+
+// luhnValid reports whether the number has a valid Luhn checksum
+func luhnValid(digits []byte) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
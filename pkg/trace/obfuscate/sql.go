@@ -102,7 +102,7 @@ func (f *replaceFilter) Filter(token, lastToken TokenKind, buffer []byte) (token
 		}
 	}
 	switch token {
-	case String, Number, Null, Variable, PreparedStatement, BooleanLiteral, EscapeSequence:
+	case String, Number, Null, Variable, PreparedStatement, BooleanLiteral, EscapeSequence, Collection:
 		return FilteredGroupable, []byte("?"), nil
 	default:
 		return token, buffer, nil
@@ -165,13 +165,24 @@ func (f *groupingFilter) Reset() {
 // some elements such as comments and aliases and obfuscation attempts to hide sensitive information
 // in strings and numbers by redacting them.
 func (o *Obfuscator) ObfuscateSQLString(in string) (*ObfuscatedQuery, error) {
+	return o.obfuscateQueryString(in, NewSQLTokenizer)
+}
+
+// ObfuscateCQLString quantizes and obfuscates the given input Cassandra CQL query string, in the
+// same way as ObfuscateSQLString. The collection literals are obfuscated as a whole.
+func (o *Obfuscator) ObfuscateCQLString(in string) (*ObfuscatedQuery, error) {
+	return o.obfuscateQueryString(in, NewCQLTokenizer)
+}
+
+// obfuscateQueryString obfuscates the given query using the tokenizers returned by newTokenizer.
+func (o *Obfuscator) obfuscateQueryString(in string, newTokenizer func(string, bool) *SQLTokenizer) (*ObfuscatedQuery, error) {
 	lesc := o.SQLLiteralEscapes()
-	tok := NewSQLTokenizer(in, lesc)
+	tok := newTokenizer(in, lesc)
 	out, err := attemptObfuscation(tok)
 	if err != nil && tok.SeenEscape() {
 		// If the tokenizer failed, but saw an escape character in the process,
 		// try again treating escapes differently
-		tok = NewSQLTokenizer(in, !lesc)
+		tok = newTokenizer(in, !lesc)
 		if out, err2 := attemptObfuscation(tok); err2 == nil {
 			// If the second attempt succeeded, change the default behavior so that
 			// on the next run we get it right in the first run.
@@ -309,7 +320,11 @@ func (o *Obfuscator) obfuscateSQL(span *pb.Span) {
 	if span.Resource == "" {
 		return
 	}
-	oq, err := o.ObfuscateSQLString(span.Resource)
+	obfuscate := o.ObfuscateSQLString
+	if span.Type == "cassandra" && o.opts.Cassandra.Enabled {
+		obfuscate = o.ObfuscateCQLString
+	}
+	oq, err := obfuscate(span.Resource)
 	if err != nil {
 		// we have an error, discard the SQL to avoid polluting user resources.
 		log.Debugf("Error parsing SQL query: %v. Resource: %q", err, span.Resource)
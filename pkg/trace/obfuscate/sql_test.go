@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/stretchr/testify/assert"
 )
@@ -1076,6 +1077,46 @@ func TestCassQuantizer(t *testing.T) {
 	}
 }
 
+func TestCQLQuantizer(t *testing.T) {
+	assert := assert.New(t)
+	o := NewObfuscator(&config.ObfuscationConfig{Cassandra: config.Enablable{Enabled: true}})
+
+	for _, tt := range []struct{ in, expected string }{
+		{
+			"INSERT INTO users (id, emails, props) VALUES (42, {'jane@example.com', 'j@example.com'}, {'ssn': '078-05-1120', 'nested': {'a': '}'}})",
+			"INSERT INTO users ( id, emails, props ) VALUES ( ? )",
+		},
+		{
+			"UPDATE users SET phones = phones + ['+33 6 12 34 56 78'] WHERE id = 62c36092-82a1-3a00-93d1-46196ee77204",
+			"UPDATE users SET phones = phones + ? WHERE id = ?",
+		},
+		{
+			"SELECT name FROM users WHERE id IN (62c36092-82a1-3a00-93d1-46196ee77204, f47ac10b-58cc-4372-a567-0e02b2c3d479)",
+			"SELECT name FROM users WHERE id IN ( ? )",
+		},
+		{
+			"INSERT INTO scripts (id, body) VALUES (1, $$return 'secret' + $x;$$)",
+			"INSERT INTO scripts ( id, body ) VALUES ( ? )",
+		},
+		{
+			"SELECT key, status FROM org_check_run WHERE org_id = %s AND check IN (%s, %s)",
+			"SELECT key, status FROM org_check_run WHERE org_id = ? AND check IN ( ? )",
+		},
+	} {
+		s := CassSpan(tt.in)
+		o.Obfuscate(s)
+		assert.Equal(tt.expected, s.Resource)
+	}
+
+	// the collections are only recognized in CQL mode
+	s := CassSpan("UPDATE users SET props = {'a': {'b': 1}} WHERE id = 1")
+	NewObfuscator(nil).Obfuscate(s)
+	assert.Equal(nonParsableResource, s.Resource)
+
+	_, err := o.ObfuscateCQLString("UPDATE users SET props = {'a': 1 WHERE id = 1")
+	assert.Error(err)
+}
+
 func TestUnicodeDigit(t *testing.T) {
 	hangStr := "٩"
 	o := NewObfuscator(nil)
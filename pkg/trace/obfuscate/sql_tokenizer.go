@@ -60,6 +60,9 @@ const (
 	Join
 	ColonCast
 
+	// Collection specifies a CQL collection literal: a list, a set or a map.
+	Collection
+
 	// FilteredGroupable specifies that the given token has been discarded by one of the
 	// token filters and that it is groupable together with consecutive FilteredGroupable
 	// tokens.
@@ -87,6 +90,7 @@ type SQLTokenizer struct {
 
 	literalEscapes bool // indicates we should not treat backslashes as escape characters
 	seenEscape     bool // indicates whether this tokenizer has seen an escape character within a string
+	cql            bool // indicates the query is CQL, see NewCQLTokenizer
 }
 
 // NewSQLTokenizer creates a new SQLTokenizer for the given SQL string. The literalEscapes argument specifies
@@ -98,6 +102,15 @@ func NewSQLTokenizer(sql string, literalEscapes bool) *SQLTokenizer {
 	}
 }
 
+// NewCQLTokenizer creates a new SQLTokenizer for the given Cassandra CQL string. In addition to the
+// SQL tokens, it recognizes the CQL collection literals (e.g. {'k': 'v'}, ['a', 'b']), the unquoted
+// UUID literals and the $$ quoted strings, so that they can be obfuscated as a whole.
+func NewCQLTokenizer(cql string, literalEscapes bool) *SQLTokenizer {
+	tkn := NewSQLTokenizer(cql, literalEscapes)
+	tkn.cql = true
+	return tkn
+}
+
 // Reset the underlying buffer and positions
 func (tkn *SQLTokenizer) Reset(in string) {
 	tkn.rd.Reset(in)
@@ -140,6 +153,8 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 	tkn.skipBlank()
 
 	switch ch := tkn.lastChar; {
+	case tkn.cql && tkn.uuidAhead():
+		return tkn.scanUUID()
 	case isLeadingLetter(ch):
 		return tkn.scanIdentifier()
 	case isDigit(ch):
@@ -158,7 +173,12 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 				return tkn.scanBindVar()
 			}
 			fallthrough
-		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', '~', '[', ']', '?':
+		case '[':
+			if tkn.cql {
+				return tkn.scanCollection(ch, ']')
+			}
+			return TokenKind(ch), runeBytes(ch)
+		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', '~', ']', '?':
 			return TokenKind(ch), runeBytes(ch)
 		case '.':
 			if isDigit(tkn.lastChar) {
@@ -232,8 +252,15 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			// modulo operator (e.g. 'id % 8')
 			return TokenKind(ch), runeBytes(ch)
 		case '$':
+			if tkn.cql && tkn.lastChar == '$' {
+				tkn.next()
+				return tkn.scanDollarQuotedString()
+			}
 			return tkn.scanPreparedStatement('$')
 		case '{':
+			if tkn.cql {
+				return tkn.scanCollection(ch, '}')
+			}
 			return tkn.scanEscapeSequence('{')
 		default:
 			tkn.setErr(`unexpected byte %d`, ch)
@@ -354,6 +381,99 @@ func (tkn *SQLTokenizer) scanEscapeSequence(braces rune) (TokenKind, []byte) {
 	return EscapeSequence, buffer.Bytes()
 }
 
+// uuidAhead reports whether the input starting at the current character is an unquoted
+// UUID literal, such as 123e4567-e89b-12d3-a456-426655440000.
+func (tkn *SQLTokenizer) uuidAhead() bool {
+	if digitVal(tkn.lastChar) >= 16 {
+		return false
+	}
+	// the current character was already read from the reader, the rest of the literal follows
+	var buf [uuidLen]byte
+	n, _ := tkn.rd.ReadAt(buf[:], tkn.rd.Size()-int64(tkn.rd.Len()))
+	if n < uuidLen-1 {
+		return false
+	}
+	for i, c := range buf[:uuidLen-1] {
+		switch i {
+		case 7, 12, 17, 22:
+			if c != '-' {
+				return false
+			}
+		default:
+			if digitVal(rune(c)) >= 16 {
+				return false
+			}
+		}
+	}
+	// the literal must not be followed by an identifier character
+	after := rune(buf[uuidLen-1])
+	return n == uuidLen-1 || !isLetter(after) && !isDigit(after)
+}
+
+// uuidLen is the length of a UUID literal.
+const uuidLen = 36
+
+func (tkn *SQLTokenizer) scanUUID() (TokenKind, []byte) {
+	buffer := &bytes.Buffer{}
+	for i := 0; i < uuidLen; i++ {
+		tkn.consumeNext(buffer)
+	}
+	return Number, buffer.Bytes()
+}
+
+// scanCollection scans a CQL collection literal opened by the given delimiter, including the
+// nested collections and the quoted strings it holds.
+func (tkn *SQLTokenizer) scanCollection(open, close rune) (TokenKind, []byte) {
+	buffer := &bytes.Buffer{}
+	buffer.WriteRune(open)
+	depth := 1
+	for depth > 0 {
+		switch tkn.lastChar {
+		case EOFChar:
+			tkn.setErr("unexpected EOF in collection literal")
+			return LexError, buffer.Bytes()
+		case open:
+			depth++
+		case close:
+			depth--
+		case '\'', '"':
+			delim := tkn.lastChar
+			tkn.consumeNext(buffer)
+			kind, str := tkn.scanString(delim, String)
+			buffer.Write(str)
+			if kind == LexError {
+				return LexError, buffer.Bytes()
+			}
+			buffer.WriteRune(delim)
+			continue
+		}
+		tkn.consumeNext(buffer)
+	}
+	return Collection, buffer.Bytes()
+}
+
+// scanDollarQuotedString scans a CQL string constant enclosed in $$, the opening
+// delimiter having already been read.
+func (tkn *SQLTokenizer) scanDollarQuotedString() (TokenKind, []byte) {
+	buffer := &bytes.Buffer{}
+	for {
+		switch tkn.lastChar {
+		case EOFChar:
+			tkn.setErr("unexpected EOF in string")
+			return LexError, buffer.Bytes()
+		case '$':
+			tkn.next()
+			if tkn.lastChar == '$' {
+				tkn.next()
+				return String, buffer.Bytes()
+			}
+			buffer.WriteRune('$')
+			continue
+		}
+		tkn.consumeNext(buffer)
+	}
+}
+
 func (tkn *SQLTokenizer) scanBindVar() (TokenKind, []byte) {
 	buffer := bytes.NewBufferString(":")
 	token := ValueArg
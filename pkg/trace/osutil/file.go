@@ -21,7 +21,7 @@ func Exists(path string) bool {
 
 // Exit prints the message and exits the program with status code 1.
 func Exit(msg string) {
-	if flags.Info || flags.Version {
+	if flags.Info || flags.Version || flags.Tail {
 		fmt.Println(msg)
 	} else {
 		log.Error(msg)
@@ -32,7 +32,7 @@ func Exit(msg string) {
 
 // Exitf prints the formatted text and exits the program with status code 1.
 func Exitf(format string, args ...interface{}) {
-	if flags.Info || flags.Version {
+	if flags.Info || flags.Version || flags.Tail {
 		fmt.Printf(format, args...)
 		fmt.Println("")
 	} else {
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -26,6 +27,9 @@ const defaultBufferLen = 2
 type Concentrator struct {
 	// list of attributes to use for extra aggregation
 	aggregators []string
+	// InferPeerServices specifies whether the stats of the services called by the client spans
+	// are computed, see RawBucket.HandleInferredSpan. It must be set before starting.
+	InferPeerServices bool
 	// bucket duration in nanoseconds
 	bsize int64
 	// Timestamp of the oldest time bucket for which we allow data.
@@ -39,6 +43,8 @@ type Concentrator struct {
 
 	In  chan []*Input
 	Out chan []Bucket
+	// ClientIn receives the stats buckets computed by the tracers
+	ClientIn chan []Bucket
 
 	exit   chan struct{}
 	exitWG *sync.WaitGroup
@@ -59,8 +65,9 @@ func NewConcentrator(aggregators []string, bsize int64, out chan []Bucket) *Conc
 		// TODO: Move to configuration.
 		bufferLen: defaultBufferLen,
 
-		In:  make(chan []*Input, 100),
-		Out: out,
+		In:       make(chan []*Input, 100),
+		Out:      out,
+		ClientIn: make(chan []Bucket, 100),
 
 		exit:   make(chan struct{}),
 		exitWG: &sync.WaitGroup{},
@@ -94,6 +101,8 @@ func (c *Concentrator) Run() {
 			select {
 			case inputs := <-c.In:
 				c.Add(inputs)
+			case buckets := <-c.ClientIn:
+				c.AddClientBuckets(buckets)
 			}
 		}
 	}()
@@ -138,7 +147,14 @@ func (c *Concentrator) Add(inputs []*Input) {
 // Callers must guard!
 func (c *Concentrator) addNow(i *Input) {
 	for _, s := range i.Trace {
-		if !(s.TopLevel || s.Measured) {
+		var (
+			peer     string
+			inferred bool
+		)
+		if c.InferPeerServices {
+			peer, inferred = inferPeerService(s.Span)
+		}
+		if !(s.TopLevel || s.Measured || inferred) {
 			continue
 		}
 		end := s.Start + s.Duration
@@ -155,8 +171,41 @@ func (c *Concentrator) addNow(i *Input) {
 			c.buckets[btime] = b
 		}
 
-		subs, _ := i.Sublayers[s.Span]
-		b.HandleSpan(s, i.Env, c.aggregators, subs)
+		if s.TopLevel || s.Measured {
+			subs, _ := i.Sublayers[s.Span]
+			b.HandleSpan(s, i.Env, c.aggregators, subs)
+		}
+		if inferred {
+			b.HandleInferredSpan(s, i.Env, peer)
+		}
+	}
+}
+
+// AddClientBuckets merges the stats buckets computed by the tracers with the ones computed
+// by the concentrator. The traces of these buckets are expected to be excluded from the
+// inputs, so that they aren't counted twice.
+func (c *Concentrator) AddClientBuckets(buckets []Bucket) {
+	var dropped int64
+	c.mu.Lock()
+	for _, b := range buckets {
+		btime := alignTs(b.Start, c.bsize)
+		// If too far in the past, count in the oldest-allowed time bucket instead.
+		if btime < c.oldestTs {
+			btime = c.oldestTs
+		}
+
+		rb, ok := c.buckets[btime]
+		if !ok {
+			rb = NewRawBucket(btime, c.bsize)
+			c.buckets[btime] = rb
+		}
+		dropped += int64(rb.HandleClientBucket(b))
+	}
+	c.mu.Unlock()
+
+	if dropped > 0 {
+		log.Debugf("Dropped %d invalid stats computed by tracers", dropped)
+		metrics.Count("datadog.trace_agent.stats.client_dropped", dropped, nil, 1)
 	}
 }
 
@@ -576,3 +576,78 @@ func TestConcentratorAdd(t *testing.T) {
 		})
 	}
 }
+
+// TestConcentratorAddClientBuckets tests that the stats computed by the tracers are merged
+// with the ones computed by the concentrator.
+func TestConcentratorAddClientBuckets(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now().UnixNano()
+	c := NewConcentrator([]string{}, testBucketInterval, make(chan []Bucket))
+
+	trace := pb.Trace{testSpan(1, 0, 50, 0, "A1", "resource1", 0)}
+	traceutil.ComputeTopLevel(trace)
+	c.addNow(&Input{Env: "none", Trace: NewWeightedTrace(trace, traceutil.GetRoot(trace))})
+
+	aggr := "env:none,resource:resource1,service:A1"
+	tags := NewTagSetFromString(aggr)
+	client := NewBucket(alignTs(now, testBucketInterval)+1, testBucketInterval)
+	for key, count := range map[string]Count{
+		"query|hits|" + aggr:     {Name: "query", Measure: HITS, TagSet: tags, TopLevel: 2, Value: 2},
+		"query|duration|" + aggr: {Name: "query", Measure: DURATION, TagSet: tags, TopLevel: 2, Value: 30},
+		"other|hits|" + aggr:     {Name: "other", Measure: HITS, TagSet: tags, TopLevel: 1, Value: 1},
+		// invalid, the key doesn't match the name
+		"bad|hits|" + aggr: {Name: "query", Measure: HITS, TagSet: tags, Value: 100},
+	} {
+		count.Key = key
+		client.Counts[key] = count
+	}
+	distribution := NewDistribution(DURATION, "query|duration|"+aggr, "query", tags)
+	distribution.Add(10, 0)
+	distribution.Add(20, 0)
+	client.Distributions[distribution.Key] = distribution
+	c.AddClientBuckets([]Bucket{client})
+
+	stats := c.flushNow(now + int64(c.bufferLen)*testBucketInterval)
+	if !assert.Equal(1, len(stats), "We should get exactly 1 Bucket") {
+		t.FailNow()
+	}
+	countValsEq(t, map[string]float64{
+		"query|duration|" + aggr: 80,
+		"query|hits|" + aggr:     3,
+		"query|errors|" + aggr:   0,
+		"other|hits|" + aggr:     1,
+	}, stats[0].Counts)
+	assert.Equal(3.0, stats[0].Counts["query|hits|"+aggr].TopLevel)
+	assert.Equal(3, stats[0].Distributions["query|duration|"+aggr].Summary.N)
+}
+
+// TestConcentratorInferPeerServices tests that the stats of the services called by the client
+// spans are only computed when enabled.
+func TestConcentratorInferPeerServices(t *testing.T) {
+	now := time.Now().UnixNano()
+	for _, infer := range []bool{false, true} {
+		trace := pb.Trace{
+			testSpan(1, 0, 50, 0, "A1", "resource1", 0),
+			testSpan(2, 1, 40, 0, "A1", "SELECT ?", 1),
+		}
+		trace[1].Meta = map[string]string{"db.instance": "users"}
+		traceutil.ComputeTopLevel(trace)
+
+		c := NewConcentrator([]string{}, testBucketInterval, make(chan []Bucket))
+		c.InferPeerServices = infer
+		c.addNow(&Input{Env: "none", Trace: NewWeightedTrace(trace, traceutil.GetRoot(trace))})
+		stats := c.flushNow(now + int64(c.bufferLen)*testBucketInterval)
+
+		expected := map[string]float64{
+			"query|duration|env:none,resource:resource1,service:A1": 50,
+			"query|hits|env:none,resource:resource1,service:A1":     1,
+			"query|errors|env:none,resource:resource1,service:A1":   0,
+		}
+		if infer {
+			expected["query|duration|env:none,resource:SELECT ?,service:users,caller:A1,inferred:true"] = 40
+			expected["query|hits|env:none,resource:SELECT ?,service:users,caller:A1,inferred:true"] = 1
+			expected["query|errors|env:none,resource:SELECT ?,service:users,caller:A1,inferred:true"] = 1
+		}
+		countValsEq(t, expected, stats[0].Counts)
+	}
+}
@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package stats
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+const (
+	tagHTTPStatusCode = "http.status_code"
+	tagGRPCStatusCode = "rpc.grpc.status_code"
+
+	// maxHTTPStatusCodes is the maximum number of distinct HTTP status codes in a stats bucket
+	maxHTTPStatusCodes = 50
+
+	// TagErrorFingerprint is the span tag holding the fingerprint of its error, set by the agent
+	TagErrorFingerprint = "error.fingerprint"
+	// errorsByFingerprint is the measure of the counts of errors by fingerprint
+	errorsByFingerprint = "_errors.by_fingerprint"
+	// maxErrorFingerprints is the maximum number of distinct error fingerprints in a stats bucket
+	maxErrorFingerprints = 100
+
+	tagSpanKind    = "span.kind"
+	tagPeerService = "peer.service"
+	tagAWSService  = "aws.service"
+	// tagInferred flags the stats of the services inferred from the client spans of their callers
+	tagInferred = "inferred"
+	// tagCaller is the service calling an inferred service
+	tagCaller = "caller"
+	// maxInferredServices is the maximum number of distinct inferred services in a stats bucket
+	maxInferredServices = 100
+)
+
+// peerServiceTags are the span tags naming the service called by a client span, by priority.
+var peerServiceTags = []string{tagPeerService, tagAWSService, "db.instance", "out.host"}
+
+// clientSpanTypes are the types of the spans which are client spans when they don't have a
+// span.kind tag.
+var clientSpanTypes = map[string]bool{
+	"sql":           true,
+	"cassandra":     true,
+	"redis":         true,
+	"memcached":     true,
+	"mongodb":       true,
+	"elasticsearch": true,
+	"http":          true,
+	"db":            true,
+	"cache":         true,
+}
+
+// inferPeerService returns the service called by the span if it's a client span, e.g. a database
+// or a SaaS API which isn't instrumented and only appears in the traces through its callers.
+func inferPeerService(s *pb.Span) (string, bool) {
+	switch s.Meta[tagSpanKind] {
+	case "client", "producer":
+	case "":
+		if !clientSpanTypes[s.Type] {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	for _, tag := range peerServiceTags {
+		peer := strings.ToLower(strings.TrimSpace(s.Meta[tag]))
+		if peer == "" {
+			continue
+		}
+		if tag == tagAWSService && !strings.HasPrefix(peer, "aws.") {
+			// e.g. aws.s3, the bare names of the AWS services are ambiguous
+			peer = "aws." + peer
+		}
+		if peer == s.Service {
+			return "", false
+		}
+		return peer, true
+	}
+	return "", false
+}
+
+// statusCodeDimension is a span tag holding a status code, the stats are always grouped by
+// its value like they are by env, resource and service.
+type statusCodeDimension struct {
+	// tag is the name of the span meta or metric holding the status code
+	tag string
+	// maxValues is the maximum number of distinct values of the dimension in a stats bucket
+	maxValues int
+	// normalize returns the canonical value of the status code, and false if it's invalid
+	normalize func(v string) (string, bool)
+	// overflow returns the value used once maxValues is reached, the dimension is
+	// dropped from the stats of the span if it's nil
+	overflow func(v string) string
+}
+
+// statusCodeDimensions are the dimensions used to group the stats of all the top-level and
+// measured spans, they allow to compute error rates by status code from the stats.
+var statusCodeDimensions = []statusCodeDimension{
+	{
+		tag:       tagHTTPStatusCode,
+		maxValues: maxHTTPStatusCodes,
+		normalize: normalizeHTTPStatusCode,
+		overflow:  httpStatusClass,
+	},
+	{
+		tag:       tagGRPCStatusCode,
+		maxValues: len(grpcStatusCodes),
+		normalize: normalizeGRPCStatusCode,
+	},
+}
+
+// isStatusCodeDimension reports whether the tag is one of the statusCodeDimensions
+func isStatusCodeDimension(tag string) bool {
+	for _, d := range statusCodeDimensions {
+		if d.tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// spanTag returns the value of the span meta, or metric, with the given name
+func spanTag(s *pb.Span, name string) (string, bool) {
+	if v, ok := s.Meta[name]; ok {
+		return v, true
+	}
+	if v, ok := s.Metrics[name]; ok {
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// normalizeHTTPStatusCode returns the status code if it's a valid one, between 100 and 599
+func normalizeHTTPStatusCode(v string) (string, bool) {
+	code, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || code < 100 || code > 599 {
+		return "", false
+	}
+	return strconv.Itoa(code), true
+}
+
+// httpStatusClass returns the class of a normalized HTTP status code, e.g. 5xx for 503
+func httpStatusClass(v string) string {
+	return v[:1] + "xx"
+}
+
+// grpcStatusCodes maps the names of the gRPC status codes, in upper case and without
+// underscores, to their value
+var grpcStatusCodes = map[string]int{
+	"OK":                 0,
+	"CANCELLED":          1,
+	"UNKNOWN":            2,
+	"INVALIDARGUMENT":    3,
+	"DEADLINEEXCEEDED":   4,
+	"NOTFOUND":           5,
+	"ALREADYEXISTS":      6,
+	"PERMISSIONDENIED":   7,
+	"RESOURCEEXHAUSTED":  8,
+	"FAILEDPRECONDITION": 9,
+	"ABORTED":            10,
+	"OUTOFRANGE":         11,
+	"UNIMPLEMENTED":      12,
+	"INTERNAL":           13,
+	"UNAVAILABLE":        14,
+	"DATALOSS":           15,
+	"UNAUTHENTICATED":    16,
+}
+
+// normalizeGRPCStatusCode returns the value of a gRPC status code given by value or by name,
+// e.g. "NOT_FOUND", "NotFound" and "StatusCode.NOT_FOUND" all return "5"
+func normalizeGRPCStatusCode(v string) (string, bool) {
+	v = strings.TrimSpace(v)
+	if code, err := strconv.Atoi(v); err == nil {
+		if code < 0 || code >= len(grpcStatusCodes) {
+			return "", false
+		}
+		return strconv.Itoa(code), true
+	}
+	name := strings.ToUpper(strings.Replace(v, "_", "", -1))
+	name = strings.TrimPrefix(name, "STATUSCODE.")
+	if name == "CANCELED" {
+		name = "CANCELLED"
+	}
+	code, ok := grpcStatusCodes[name]
+	if !ok {
+		return "", false
+	}
+	return strconv.Itoa(code), true
+}
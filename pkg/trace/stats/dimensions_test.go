@@ -0,0 +1,218 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package stats
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeHTTPStatusCode(t *testing.T) {
+	for in, out := range map[string]string{
+		"200":   "200",
+		" 404 ": "404",
+		"599":   "599",
+		"99":    "",
+		"600":   "",
+		"":      "",
+		"OK":    "",
+		"200.5": "",
+	} {
+		v, ok := normalizeHTTPStatusCode(in)
+		assert.Equal(t, out != "", ok, in)
+		assert.Equal(t, out, v, in)
+	}
+}
+
+func TestNormalizeGRPCStatusCode(t *testing.T) {
+	for in, out := range map[string]string{
+		"0":                    "0",
+		"05":                   "5",
+		"16":                   "16",
+		"17":                   "",
+		"-1":                   "",
+		"OK":                   "0",
+		"NOT_FOUND":            "5",
+		"NotFound":             "5",
+		"StatusCode.NOT_FOUND": "5",
+		"Canceled":             "1",
+		"unavailable":          "14",
+		"NOPE":                 "",
+	} {
+		v, ok := normalizeGRPCStatusCode(in)
+		assert.Equal(t, out != "", ok, in)
+		assert.Equal(t, out, v, in)
+	}
+}
+
+func TestBucketStatusCodes(t *testing.T) {
+	assert := assert.New(t)
+	srb := NewRawBucket(0, 1e9)
+
+	span := func(meta map[string]string, metrics map[string]float64) *WeightedSpan {
+		return &WeightedSpan{
+			Weight:   1,
+			TopLevel: true,
+			Span:     &pb.Span{Service: "web", Name: "http.request", Resource: "/", Meta: meta, Metrics: metrics},
+		}
+	}
+	for _, s := range []*WeightedSpan{
+		span(map[string]string{"http.status_code": "200"}, nil),
+		span(nil, map[string]float64{"http.status_code": 200}),
+		span(map[string]string{"http.status_code": "503"}, nil),
+		span(map[string]string{"http.status_code": "invalid"}, nil),
+		span(map[string]string{"rpc.grpc.status_code": "UNAVAILABLE"}, nil),
+	} {
+		srb.HandleSpan(s, "prod", nil, nil)
+	}
+
+	countValsEq(t, map[string]float64{
+		"http.request|hits|env:prod,resource:/,service:web,http.status_code:200":    2,
+		"http.request|hits|env:prod,resource:/,service:web,http.status_code:503":    1,
+		"http.request|hits|env:prod,resource:/,service:web":                         1,
+		"http.request|hits|env:prod,resource:/,service:web,rpc.grpc.status_code:14": 1,
+	}, filterCounts(srb.Export().Counts, HITS))
+
+	// once the maximum number of status codes is reached, the new ones are replaced by their class
+	srb = NewRawBucket(0, 1e9)
+	for i := 0; i < maxHTTPStatusCodes; i++ {
+		srb.HandleSpan(span(map[string]string{"http.status_code": strconv.Itoa(200 + i)}, nil), "prod", nil, nil)
+	}
+	srb.HandleSpan(span(map[string]string{"http.status_code": "503"}, nil), "prod", nil, nil)
+	srb.HandleSpan(span(map[string]string{"http.status_code": "200"}, nil), "prod", nil, nil)
+	counts := filterCounts(srb.Export().Counts, HITS)
+	assert.Len(counts, maxHTTPStatusCodes+1)
+	assert.Equal(1.0, counts["http.request|hits|env:prod,resource:/,service:web,http.status_code:5xx"].Value)
+	assert.Equal(2.0, counts["http.request|hits|env:prod,resource:/,service:web,http.status_code:200"].Value)
+
+	// an extra aggregator on a status code doesn't bypass the normalization
+	srb = NewRawBucket(0, 1e9)
+	srb.HandleSpan(span(map[string]string{"http.status_code": "abc"}, nil), "prod", []string{"http.status_code"}, nil)
+	countValsEq(t, map[string]float64{
+		"http.request|hits|env:prod,resource:/,service:web": 1,
+	}, filterCounts(srb.Export().Counts, HITS))
+}
+
+// filterCounts returns the counts of the given measure
+func filterCounts(counts map[string]Count, measure string) map[string]Count {
+	filtered := make(map[string]Count)
+	for k, c := range counts {
+		if c.Measure == measure {
+			filtered[k] = c
+		}
+	}
+	return filtered
+}
+
+func TestBucketErrorFingerprints(t *testing.T) {
+	assert := assert.New(t)
+	srb := NewRawBucket(0, 1e9)
+
+	span := func(fp string, err int32) *WeightedSpan {
+		s := &pb.Span{Service: "web", Name: "http.request", Resource: "/", Error: err}
+		if fp != "" {
+			s.Meta = map[string]string{"error.fingerprint": fp}
+		}
+		return &WeightedSpan{Weight: 2, TopLevel: true, Span: s}
+	}
+	for _, s := range []*WeightedSpan{
+		span("a1", 1),
+		span("a1", 1),
+		span("b2", 1),
+		span("", 1),
+		span("a1", 0), // not an error, not counted
+	} {
+		srb.HandleSpan(s, "prod", nil, nil)
+	}
+	counts := srb.Export().Counts
+	countValsEq(t, map[string]float64{
+		"http.request|_errors.by_fingerprint|env:prod,resource:/,service:web,error.fingerprint:a1": 4,
+		"http.request|_errors.by_fingerprint|env:prod,resource:/,service:web,error.fingerprint:b2": 2,
+	}, filterCounts(counts, errorsByFingerprint))
+	assert.Equal(8.0, counts["http.request|errors|env:prod,resource:/,service:web"].Value)
+
+	// once the maximum number of fingerprints is reached, the new ones are only counted as errors
+	srb = NewRawBucket(0, 1e9)
+	for i := 0; i < maxErrorFingerprints+1; i++ {
+		srb.HandleSpan(span(strconv.Itoa(i), 1), "prod", nil, nil)
+	}
+	srb.HandleSpan(span("0", 1), "prod", nil, nil)
+	counts = srb.Export().Counts
+	assert.Len(filterCounts(counts, errorsByFingerprint), maxErrorFingerprints)
+	assert.Equal(4.0, counts["http.request|_errors.by_fingerprint|env:prod,resource:/,service:web,error.fingerprint:0"].Value)
+	assert.Equal(float64(2*(maxErrorFingerprints+2)), counts["http.request|errors|env:prod,resource:/,service:web"].Value)
+}
+
+func TestInferPeerService(t *testing.T) {
+	for _, tt := range []struct {
+		typ  string
+		meta map[string]string
+		peer string
+	}{
+		{"sql", map[string]string{"db.instance": "users", "out.host": "10.0.0.1"}, "users"},
+		{"sql", map[string]string{"peer.service": "Postgres ", "db.instance": "users"}, "postgres"},
+		{"http", map[string]string{"aws.service": "S3", "out.host": "s3.amazonaws.com"}, "aws.s3"},
+		{"http", map[string]string{"aws.service": "aws.sqs"}, "aws.sqs"},
+		{"custom", map[string]string{"span.kind": "client", "out.host": "api.stripe.com"}, "api.stripe.com"},
+		{"queue", map[string]string{"span.kind": "producer", "peer.service": "kafka"}, "kafka"},
+		// not client spans
+		{"web", map[string]string{"out.host": "localhost"}, ""},
+		{"http", map[string]string{"span.kind": "server", "out.host": "localhost"}, ""},
+		// no peer service, or the service itself
+		{"redis", nil, ""},
+		{"redis", map[string]string{"peer.service": "web"}, ""},
+	} {
+		peer, ok := inferPeerService(&pb.Span{Service: "web", Type: tt.typ, Meta: tt.meta})
+		assert.Equal(t, tt.peer != "", ok, tt)
+		assert.Equal(t, tt.peer, peer, tt)
+	}
+}
+
+func TestBucketInferredServices(t *testing.T) {
+	assert := assert.New(t)
+	srb := NewRawBucket(0, 1e9)
+
+	span := func(peer string, duration int64, err int32) *WeightedSpan {
+		return &WeightedSpan{Weight: 2, Span: &pb.Span{
+			Service:  "web",
+			Name:     "postgres.query",
+			Resource: "SELECT ?",
+			Duration: duration,
+			Error:    err,
+		}}
+	}
+	srb.HandleInferredSpan(span("postgres", 100, 0), "prod", "postgres")
+	srb.HandleInferredSpan(span("postgres", 300, 1), "prod", "postgres")
+	counts := srb.Export().Counts
+	countValsEq(t, map[string]float64{
+		"postgres.query|hits|env:prod,resource:SELECT ?,service:postgres,caller:web,inferred:true":     4,
+		"postgres.query|errors|env:prod,resource:SELECT ?,service:postgres,caller:web,inferred:true":   2,
+		"postgres.query|duration|env:prod,resource:SELECT ?,service:postgres,caller:web,inferred:true": 800,
+	}, counts)
+	c := counts["postgres.query|hits|env:prod,resource:SELECT ?,service:postgres,caller:web,inferred:true"]
+	assert.Equal(4.0, c.TopLevel)
+	assert.Equal(TagSet{
+		{"env", "prod"},
+		{"resource", "SELECT ?"},
+		{"service", "postgres"},
+		{"caller", "web"},
+		{"inferred", "true"},
+	}, c.TagSet)
+
+	// once the maximum number of inferred services is reached, the new ones are ignored
+	srb = NewRawBucket(0, 1e9)
+	for i := 0; i < maxInferredServices+1; i++ {
+		srb.HandleInferredSpan(span("", 100, 0), "prod", strconv.Itoa(i))
+	}
+	srb.HandleInferredSpan(span("", 100, 0), "prod", "0")
+	counts = srb.Export().Counts
+	assert.Len(filterCounts(counts, HITS), maxInferredServices)
+	assert.Equal(4.0, counts["postgres.query|hits|env:prod,resource:SELECT ?,service:0,caller:web,inferred:true"].Value)
+}
@@ -6,8 +6,11 @@
 package stats
 
 import (
+	"encoding/json"
 	"fmt"
 
+	ddsketch "github.com/DataDog/datadog-agent/pkg/quantile"
+	"github.com/DataDog/datadog-agent/pkg/quantile/summary"
 	"github.com/DataDog/datadog-agent/pkg/trace/stats/quantile"
 )
 
@@ -61,6 +64,96 @@ type Distribution struct {
 	Summary *quantile.SliceSummary `json:"summary"` // actual representation of data
 }
 
+// Sketch represents the distribution of the durations for a given tagset with a DDSketch.
+// Unlike the summary of a Distribution, it has a relative error guarantee on all the quantiles,
+// including the long tail ones, and merging two sketches doesn't lose any precision.
+type Sketch struct {
+	Key     string // the key of the duration count, see GrainKey
+	Name    string // the name of the trace/spans we count
+	Measure string // represents the entity we count, always "duration" for now
+	TagSet  TagSet // set of tags for which we account this Sketch
+
+	TopLevel float64 // number of top-level spans contributing to this sketch
+
+	Sketch *ddsketch.Sketch // the bins of the sketch are serialized too, see MarshalJSON
+}
+
+// sketchConfig is the configuration of all the sketches, sketches with different configurations
+// can't be merged.
+var sketchConfig = ddsketch.Default()
+
+// encodedSketch is the JSON representation of a Sketch
+type encodedSketch struct {
+	Key      string  `json:"key"`
+	Name     string  `json:"name"`
+	Measure  string  `json:"measure"`
+	TagSet   TagSet  `json:"tagset"`
+	TopLevel float64 `json:"top_level"`
+
+	Summary summary.Summary `json:"summary"`
+	Keys    []int32         `json:"keys"`
+	Counts  []uint32        `json:"counts"`
+}
+
+// NewSketch returns a new empty Sketch for a metric and a given tag set
+func NewSketch(m, ckey, name string, tgs TagSet) Sketch {
+	return Sketch{
+		Key:     ckey,
+		Name:    name,
+		Measure: m,
+		TagSet:  tgs,
+		Sketch:  &ddsketch.Sketch{},
+	}
+}
+
+// Merge is used when 2 Sketches represent the same thing and it merges the 2 underlying sketches
+func (s Sketch) Merge(s2 Sketch) {
+	s.Sketch.Merge(sketchConfig, s2.Sketch)
+}
+
+// Quantile returns the value of the quantile q of the sketch
+func (s Sketch) Quantile(q float64) float64 {
+	return s.Sketch.Quantile(sketchConfig, q)
+}
+
+// MarshalJSON implements json.Marshaler. The bins of the sketch are encoded as
+// 2 columns of keys and counts, so that the sketch can be decoded without loss.
+func (s Sketch) MarshalJSON() ([]byte, error) {
+	es := encodedSketch{
+		Key:      s.Key,
+		Name:     s.Name,
+		Measure:  s.Measure,
+		TagSet:   s.TagSet,
+		TopLevel: s.TopLevel,
+	}
+	if s.Sketch != nil {
+		es.Summary = s.Sketch.Basic
+		es.Keys, es.Counts = s.Sketch.Cols()
+	}
+	return json.Marshal(es)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Sketch) UnmarshalJSON(data []byte) error {
+	var es encodedSketch
+	if err := json.Unmarshal(data, &es); err != nil {
+		return err
+	}
+	sketch, err := ddsketch.FromCols(sketchConfig, es.Summary, es.Keys, es.Counts)
+	if err != nil {
+		return fmt.Errorf("invalid sketch %q: %v", es.Key, err)
+	}
+	*s = Sketch{
+		Key:      es.Key,
+		Name:     es.Name,
+		Measure:  es.Measure,
+		TagSet:   es.TagSet,
+		TopLevel: es.TopLevel,
+		Sketch:   sketch,
+	}
+	return nil
+}
+
 // GrainKey generates the key used to aggregate counts and distributions
 // which is of the form: name|measure|aggr
 // for example: serve|duration|service:webserver
@@ -142,6 +235,10 @@ type Bucket struct {
 	Counts           map[string]Count        // All the counts
 	Distributions    map[string]Distribution // All the distributions (e.g.: for quantile queries)
 	ErrDistributions map[string]Distribution // All the error distributions (e.g.: for apdex, as they account for frustrated)
+
+	// Sketches of the durations, indexed by the keys of the duration counts
+	OkSketches  map[string]Sketch // Sketches of the durations of the spans without error
+	ErrSketches map[string]Sketch // Sketches of the durations of the spans with an error
 }
 
 // NewBucket opens a new bucket for time ts and initializes it properly
@@ -153,10 +250,13 @@ func NewBucket(ts, d int64) Bucket {
 		Counts:           make(map[string]Count),
 		Distributions:    make(map[string]Distribution),
 		ErrDistributions: make(map[string]Distribution),
+		OkSketches:       make(map[string]Sketch),
+		ErrSketches:      make(map[string]Sketch),
 	}
 }
 
 // IsEmpty just says if this stats bucket has no information (in which case it's useless)
 func (sb Bucket) IsEmpty() bool {
-	return len(sb.Counts) == 0 && len(sb.Distributions) == 0 && len(sb.ErrDistributions) == 0
+	return len(sb.Counts) == 0 && len(sb.Distributions) == 0 && len(sb.ErrDistributions) == 0 &&
+		len(sb.OkSketches) == 0 && len(sb.ErrSketches) == 0
 }
@@ -7,6 +7,7 @@ package stats
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -111,6 +112,30 @@ type expectedDistribution struct {
 	topLevel float64
 }
 
+func TestSketchJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewSketch(DURATION, "query|duration|env:prod,service:db", "query", NewTagSetFromString("env:prod,service:db"))
+	for i := 1; i <= 1000; i++ {
+		s.Sketch.Insert(sketchConfig, float64(i))
+	}
+	s.TopLevel = 12
+
+	data, err := json.Marshal(s)
+	assert.NoError(err)
+	var decoded Sketch
+	assert.NoError(json.Unmarshal(data, &decoded))
+
+	assert.Equal(s.Key, decoded.Key)
+	assert.Equal(s.Name, decoded.Name)
+	assert.Equal(s.Measure, decoded.Measure)
+	assert.Equal(s.TagSet, decoded.TagSet)
+	assert.Equal(s.TopLevel, decoded.TopLevel)
+	assert.True(s.Sketch.Equals(decoded.Sketch), "the sketch should be decoded without loss")
+
+	assert.Error(json.Unmarshal([]byte(`{"keys":[1,2],"counts":[1]}`), &decoded))
+}
+
 func TestBucketDefault(t *testing.T) {
 	assert := assert.New(t)
 
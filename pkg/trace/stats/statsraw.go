@@ -8,7 +8,9 @@ package stats
 import (
 	"bytes"
 	"sort"
+	"strings"
 
+	ddsketch "github.com/DataDog/datadog-agent/pkg/quantile"
 	"github.com/DataDog/datadog-agent/pkg/trace/stats/quantile"
 )
 
@@ -25,6 +27,16 @@ type groupedStats struct {
 	duration                float64
 	durationDistribution    *quantile.SliceSummary
 	errDurationDistribution *quantile.SliceSummary
+	okDurationSketch        *ddsketch.Agent
+	errDurationSketch       *ddsketch.Agent
+}
+
+type fingerprintStats struct {
+	tags TagSet
+
+	topLevel float64
+
+	errors float64
 }
 
 type sublayerStats struct {
@@ -40,6 +52,8 @@ func newGroupedStats(tags TagSet) groupedStats {
 		tags:                    tags,
 		durationDistribution:    quantile.NewSliceSummary(),
 		errDurationDistribution: quantile.NewSliceSummary(),
+		okDurationSketch:        &ddsketch.Agent{},
+		errDurationSketch:       &ddsketch.Agent{},
 	}
 }
 
@@ -72,6 +86,18 @@ type RawBucket struct {
 	// this should really remain private as it's subject to refactoring
 	data         map[statsKey]groupedStats
 	sublayerData map[statsSubKey]sublayerStats
+	// errors counted by fingerprint, the measure of the keys is always errorsByFingerprint
+	fingerprintData map[statsSubKey]fingerprintStats
+
+	// stats computed by the tracers, merged with the ones computed by the agent on export
+	clientCounts           map[string]Count
+	clientDistributions    map[string]Distribution
+	clientErrDistributions map[string]Distribution
+	clientOkSketches       map[string]Sketch
+	clientErrSketches      map[string]Sketch
+
+	// distinct values of each status code dimension, to cap their cardinality
+	dimensionValues map[string]map[string]struct{}
 
 	// internal buffer for aggregate strings - not threadsafe
 	keyBuf bytes.Buffer
@@ -81,10 +107,19 @@ type RawBucket struct {
 func NewRawBucket(ts, d int64) *RawBucket {
 	// The only non-initialized value is the Duration which should be set by whoever closes that bucket
 	return &RawBucket{
-		start:        ts,
-		duration:     d,
-		data:         make(map[statsKey]groupedStats),
-		sublayerData: make(map[statsSubKey]sublayerStats),
+		start:           ts,
+		duration:        d,
+		data:            make(map[statsKey]groupedStats),
+		sublayerData:    make(map[statsSubKey]sublayerStats),
+		fingerprintData: make(map[statsSubKey]fingerprintStats),
+
+		clientCounts:           make(map[string]Count),
+		clientDistributions:    make(map[string]Distribution),
+		clientErrDistributions: make(map[string]Distribution),
+		clientOkSketches:       make(map[string]Sketch),
+		clientErrSketches:      make(map[string]Sketch),
+
+		dimensionValues: make(map[string]map[string]struct{}),
 	}
 }
 
@@ -137,6 +172,26 @@ func (sb *RawBucket) Export() Bucket {
 			TopLevel: v.topLevel,
 			Summary:  v.errDurationDistribution,
 		}
+		if sketch := v.okDurationSketch.Finish(); sketch != nil {
+			ret.OkSketches[durationKey] = Sketch{
+				Key:      durationKey,
+				Name:     k.name,
+				Measure:  DURATION,
+				TagSet:   v.tags,
+				TopLevel: v.topLevel,
+				Sketch:   sketch,
+			}
+		}
+		if sketch := v.errDurationSketch.Finish(); sketch != nil {
+			ret.ErrSketches[durationKey] = Sketch{
+				Key:      durationKey,
+				Name:     k.name,
+				Measure:  DURATION,
+				TagSet:   v.tags,
+				TopLevel: v.topLevel,
+				Sketch:   sketch,
+			}
+		}
 	}
 	for k, v := range sb.sublayerData {
 		key := GrainKey(k.name, k.measure, k.aggr)
@@ -149,9 +204,113 @@ func (sb *RawBucket) Export() Bucket {
 			Value:    float64(v.value),
 		}
 	}
+	for k, v := range sb.fingerprintData {
+		key := GrainKey(k.name, k.measure, k.aggr)
+		ret.Counts[key] = Count{
+			Key:      key,
+			Name:     k.name,
+			Measure:  k.measure,
+			TagSet:   v.tags,
+			TopLevel: v.topLevel,
+			Value:    v.errors,
+		}
+	}
+	for k, c := range sb.clientCounts {
+		if existing, ok := ret.Counts[k]; ok {
+			c.TopLevel += existing.TopLevel
+			c = c.Merge(existing)
+		}
+		ret.Counts[k] = c
+	}
+	mergeDistributions(ret.Distributions, sb.clientDistributions)
+	mergeDistributions(ret.ErrDistributions, sb.clientErrDistributions)
+	mergeSketches(ret.OkSketches, sb.clientOkSketches)
+	mergeSketches(ret.ErrSketches, sb.clientErrSketches)
 	return ret
 }
 
+// mergeDistributions merges the distributions of src into the ones of dst with the same key
+func mergeDistributions(dst, src map[string]Distribution) {
+	for k, d := range src {
+		if existing, ok := dst[k]; ok {
+			existing.Merge(d)
+			continue
+		}
+		dst[k] = d
+	}
+}
+
+// mergeSketches merges the sketches of src into the ones of dst with the same key
+func mergeSketches(dst, src map[string]Sketch) {
+	for k, s := range src {
+		if existing, ok := dst[k]; ok {
+			existing.Merge(s)
+			continue
+		}
+		dst[k] = s
+	}
+}
+
+// HandleClientBucket merges the stats of a bucket computed by a tracer into this bucket.
+// It returns the number of counts and distributions which were dropped for being invalid.
+func (sb *RawBucket) HandleClientBucket(b Bucket) (dropped int) {
+	for k, c := range b.Counts {
+		if !validGrain(k, c.Key, c.Name, c.Measure) {
+			dropped++
+			continue
+		}
+		if existing, ok := sb.clientCounts[k]; ok {
+			c.TopLevel += existing.TopLevel
+			c = c.Merge(existing)
+		}
+		sb.clientCounts[k] = c
+	}
+	dropped += handleClientDistributions(sb.clientDistributions, b.Distributions)
+	dropped += handleClientDistributions(sb.clientErrDistributions, b.ErrDistributions)
+	dropped += handleClientSketches(sb.clientOkSketches, b.OkSketches)
+	dropped += handleClientSketches(sb.clientErrSketches, b.ErrSketches)
+	return dropped
+}
+
+func handleClientDistributions(dst, src map[string]Distribution) (dropped int) {
+	for k, d := range src {
+		if !validGrain(k, d.Key, d.Name, d.Measure) || d.Summary == nil {
+			dropped++
+			continue
+		}
+		if existing, ok := dst[k]; ok {
+			existing.Merge(d)
+			continue
+		}
+		dst[k] = d
+	}
+	return dropped
+}
+
+func handleClientSketches(dst, src map[string]Sketch) (dropped int) {
+	for k, s := range src {
+		if !validGrain(k, s.Key, s.Name, s.Measure) || s.Sketch == nil {
+			dropped++
+			continue
+		}
+		if existing, ok := dst[k]; ok {
+			existing.Merge(s)
+			continue
+		}
+		dst[k] = s
+	}
+	return dropped
+}
+
+// validGrain reports whether the stats indexed by mapKey have a consistent grain key
+// of the form name|measure|aggr, see GrainKey
+func validGrain(mapKey, key, name, measure string) bool {
+	if name == "" || measure == "" || mapKey != key {
+		return false
+	}
+	return strings.HasPrefix(key, GrainKey(name, measure, ""))
+}
+
 func assembleGrain(b *bytes.Buffer, env, resource, service string, m map[string]string) (string, TagSet) {
 	b.Reset()
 
@@ -197,21 +356,84 @@ func (sb *RawBucket) HandleSpan(s *WeightedSpan, env string, aggregators []strin
 	m := make(map[string]string)
 
 	for _, agg := range aggregators {
-		if agg != "env" && agg != "resource" && agg != "service" {
+		if agg != "env" && agg != "resource" && agg != "service" && !isStatusCodeDimension(agg) {
 			if v, ok := s.Meta[agg]; ok {
 				m[agg] = v
 			}
 		}
 	}
+	sb.addStatusCodes(s, m)
 
 	grain, tags := assembleGrain(&sb.keyBuf, env, s.Resource, s.Service, m)
 	sb.add(s, grain, tags)
+	if s.Error != 0 {
+		sb.addErrorFingerprint(s, grain, tags)
+	}
 
 	for _, sub := range sublayers {
 		sb.addSublayer(s, grain, tags, sub)
 	}
 }
 
+// HandleInferredSpan adds the client span to the stats of the service it calls, inferred from
+// its tags, see inferPeerService. These stats are flagged by the inferred tag and the span
+// counts as a top-level span of the inferred service. Once the maximum number of inferred
+// services is reached in the bucket, the spans calling new services are ignored.
+func (sb *RawBucket) HandleInferredSpan(s *WeightedSpan, env, peer string) {
+	if env == "" {
+		panic("env should never be empty")
+	}
+	values, ok := sb.dimensionValues[tagPeerService]
+	if !ok {
+		values = make(map[string]struct{})
+		sb.dimensionValues[tagPeerService] = values
+	}
+	if _, ok := values[peer]; !ok {
+		if len(values) >= maxInferredServices {
+			return
+		}
+		values[peer] = struct{}{}
+	}
+
+	m := map[string]string{tagInferred: "true", tagCaller: s.Service}
+	grain, tags := assembleGrain(&sb.keyBuf, env, s.Resource, peer, m)
+	inferred := *s
+	inferred.TopLevel = true
+	sb.add(&inferred, grain, tags)
+}
+
+// addStatusCodes adds the values of the status code dimensions of the span to m. Once a
+// dimension reaches its maximum number of values in the bucket, the new values are replaced
+// by their overflow value.
+func (sb *RawBucket) addStatusCodes(s *WeightedSpan, m map[string]string) {
+	for _, d := range statusCodeDimensions {
+		v, ok := spanTag(s.Span, d.tag)
+		if !ok {
+			continue
+		}
+		if v, ok = d.normalize(v); !ok {
+			continue
+		}
+
+		values, ok := sb.dimensionValues[d.tag]
+		if !ok {
+			values = make(map[string]struct{})
+			sb.dimensionValues[d.tag] = values
+		}
+		if _, ok := values[v]; !ok {
+			if len(values) >= d.maxValues {
+				if d.overflow == nil {
+					continue
+				}
+				v = d.overflow(v)
+			} else {
+				values[v] = struct{}{}
+			}
+		}
+		m[d.tag] = v
+	}
+}
+
 func (sb *RawBucket) add(s *WeightedSpan, aggr string, tags TagSet) {
 	var gs groupedStats
 	var ok bool
@@ -236,13 +458,52 @@ func (sb *RawBucket) add(s *WeightedSpan, aggr string, tags TagSet) {
 	trundur := nsTimestampToFloat(s.Duration)
 	gs.durationDistribution.Insert(trundur, s.SpanID)
 
+	// the sketches count the span as many times as it represents, the weight is the inverse of the sample rate
 	if s.Error != 0 {
 		gs.errDurationDistribution.Insert(trundur, s.SpanID)
+		gs.errDurationSketch.Insert(float64(s.Duration), 1/s.Weight)
+	} else {
+		gs.okDurationSketch.Insert(float64(s.Duration), 1/s.Weight)
 	}
 
 	sb.data[key] = gs
 }
 
+// addErrorFingerprint counts the error of the span by its fingerprint. Once the maximum number
+// of fingerprints is reached in the bucket, the errors with new fingerprints are only counted
+// in the errors measure.
+func (sb *RawBucket) addErrorFingerprint(s *WeightedSpan, aggr string, tags TagSet) {
+	fp, ok := s.Meta[TagErrorFingerprint]
+	if !ok || fp == "" {
+		return
+	}
+	values, ok := sb.dimensionValues[TagErrorFingerprint]
+	if !ok {
+		values = make(map[string]struct{})
+		sb.dimensionValues[TagErrorFingerprint] = values
+	}
+	if _, ok := values[fp]; !ok {
+		if len(values) >= maxErrorFingerprints {
+			return
+		}
+		values[fp] = struct{}{}
+	}
+
+	key := statsSubKey{name: s.Name, measure: errorsByFingerprint, aggr: aggr + "," + TagErrorFingerprint + ":" + fp}
+	fs, ok := sb.fingerprintData[key]
+	if !ok {
+		fpTags := make(TagSet, len(tags)+1)
+		copy(fpTags, tags)
+		fpTags[len(tags)] = Tag{Name: TagErrorFingerprint, Value: fp}
+		fs = fingerprintStats{tags: fpTags}
+	}
+	if s.TopLevel {
+		fs.topLevel += s.Weight
+	}
+	fs.errors += s.Weight
+	sb.fingerprintData[key] = fs
+}
+
 func (sb *RawBucket) addSublayer(s *WeightedSpan, aggr string, tags TagSet, sub SublayerValue) {
 	// This is not as efficient as a "regular" add as we don't update
 	// all sublayers at once (one call for HITS, and another one for ERRORS, DURATION...)
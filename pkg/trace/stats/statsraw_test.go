@@ -36,6 +36,49 @@ func TestGrainWithExtraTags(t *testing.T) {
 	assert.Equal(TagSet{Tag{"env", "default"}, Tag{"resource", "yo"}, Tag{"service", "thing"}, Tag{"meta1", "ONE"}, Tag{"meta2", "two"}}, tgs)
 }
 
+func TestHandleSpanSketches(t *testing.T) {
+	assert := assert.New(t)
+	srb := NewRawBucket(0, 1e9)
+
+	for i := 1; i <= 100; i++ {
+		span := &WeightedSpan{
+			Weight:   1,
+			TopLevel: true,
+			Span:     &pb.Span{Service: "thing", Name: "other", Resource: "yo", Duration: int64(i) * 1e6},
+		}
+		if i > 90 {
+			span.Error = 1
+		}
+		srb.HandleSpan(span, "default", nil, nil)
+	}
+	// a span sampled at 25% counts for 4 spans
+	srb.HandleSpan(&WeightedSpan{
+		Weight:   4,
+		TopLevel: true,
+		Span:     &pb.Span{Service: "thing", Name: "other", Resource: "yo", Duration: 1e6},
+	}, "default", nil, nil)
+
+	key := "other|duration|env:default,resource:yo,service:thing"
+	b := srb.Export()
+	ok, err := b.OkSketches[key], b.ErrSketches[key]
+	assert.EqualValues(94, ok.Sketch.Basic.Cnt)
+	assert.EqualValues(10, err.Sketch.Basic.Cnt)
+	assert.InEpsilon(91e6, err.Quantile(0), 0.01)
+	assert.InEpsilon(100e6, err.Quantile(1), 0.01)
+	assert.InEpsilon(90e6, ok.Quantile(1), 0.01)
+	assert.Equal(104.0, ok.TopLevel)
+
+	// the sketches computed by the tracers are merged with the ones of the agent
+	client := NewBucket(0, 1e9)
+	clientSketch := NewSketch(DURATION, key, "other", ok.TagSet)
+	clientSketch.Sketch.Insert(sketchConfig, 200e6)
+	client.ErrSketches[key] = clientSketch
+	assert.Equal(0, srb.HandleClientBucket(client))
+	b = srb.Export()
+	assert.EqualValues(11, b.ErrSketches[key].Sketch.Basic.Cnt)
+	assert.InEpsilon(200e6, b.ErrSketches[key].Quantile(1), 0.01)
+}
+
 func BenchmarkHandleSpanRandom(b *testing.B) {
 	sb := NewRawBucket(0, 1e9)
 	aggr := []string{}
@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// Follow connects to the tail endpoint of the agent at addr and pretty-prints the traces
+// of the given services, all of them if empty, to w until ctx is cancelled.
+func Follow(ctx context.Context, w io.Writer, addr string, services []string) error {
+	u := fmt.Sprintf("http://%s/debug/traces", addr)
+	if len(services) > 0 {
+		u += "?service=" + url.QueryEscape(strings.Join(services, ","))
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("could not connect to the trace-agent at %s, is it running? %v", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s is disabled, set apm_config.debug_traces_enabled to true to enable it", u)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response from %s: %s", u, resp.Status)
+	}
+
+	fmt.Fprintf(w, "Tailing the traces received by the trace-agent at %s, press Ctrl-C to stop.\n\n", addr)
+	if err := Print(w, resp.Body); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// Print pretty-prints the stream of traces read from r to w.
+func Print(w io.Writer, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for {
+		var trace Trace
+		if err := dec.Decode(&trace); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		printTrace(w, &trace)
+	}
+}
+
+func printTrace(w io.Writer, t *Trace) {
+	fmt.Fprintf(w, "%s trace:%d service:%s", t.Time.Format(time.RFC3339), t.TraceID, t.Service)
+	if t.Env != "" {
+		fmt.Fprintf(w, " env:%s", t.Env)
+	}
+	switch {
+	case t.Dropped != "":
+		fmt.Fprintf(w, " DROPPED (%s)", t.Dropped)
+	case t.Sampled:
+		fmt.Fprintf(w, " priority:%s KEPT (%s, rate:%g)", t.Priority, t.SamplingReason, t.SampleRate)
+	default:
+		fmt.Fprintf(w, " priority:%s SAMPLED OUT (%s)", t.Priority, t.SamplingReason)
+	}
+	if t.Events > 0 {
+		fmt.Fprintf(w, " events:%d", t.Events)
+	}
+	fmt.Fprintln(w)
+
+	if len(t.NormalizationIssues) > 0 {
+		reasons := make([]string, 0, len(t.NormalizationIssues))
+		for reason, count := range t.NormalizationIssues {
+			reasons = append(reasons, fmt.Sprintf("%s:%d", reason, count))
+		}
+		sort.Strings(reasons)
+		fmt.Fprintf(w, "  normalization: %s\n", strings.Join(reasons, ", "))
+	}
+
+	// the spans are printed as a tree, each span below its parent
+	ids := make(map[uint64]bool, len(t.Spans))
+	for _, s := range t.Spans {
+		ids[s.SpanID] = true
+	}
+	children := make(map[uint64][]*pb.Span)
+	var roots []*pb.Span
+	for _, s := range t.Spans {
+		if s.ParentID == 0 || !ids[s.ParentID] {
+			roots = append(roots, s)
+			continue
+		}
+		children[s.ParentID] = append(children[s.ParentID], s)
+	}
+	var printSpans func(spans []*pb.Span, depth int)
+	printSpans = func(spans []*pb.Span, depth int) {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+		for _, s := range spans {
+			fmt.Fprintf(w, "%s%s %s %q %s", strings.Repeat("  ", depth+1), s.Service, s.Name, s.Resource, time.Duration(s.Duration))
+			if s.Error != 0 {
+				fmt.Fprint(w, " [error]")
+			}
+			fmt.Fprintln(w)
+			printSpans(children[s.SpanID], depth+1)
+		}
+	}
+	printSpans(roots, 0)
+	fmt.Fprintln(w)
+}
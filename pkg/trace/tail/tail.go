@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package tail streams the traces processed by the trace-agent, along with what the agent
+// did with them, to help debugging tracers pointed at a local agent.
+package tail
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// subscriberBufferLen is the number of traces buffered for each client, the traces are
+// dropped for the clients which don't keep up.
+const subscriberBufferLen = 100
+
+// Trace describes what the agent did with a trace it received.
+type Trace struct {
+	// Time is when the trace was processed
+	Time    time.Time `json:"time"`
+	TraceID uint64    `json:"trace_id"`
+	// Service is the service of the root span
+	Service string `json:"service"`
+	Env     string `json:"env,omitempty"`
+
+	// Dropped is the reason why the trace was dropped before being sampled, if it was
+	Dropped string `json:"dropped,omitempty"`
+	// NormalizationIssues counts the dropped traces and the malformed spans by reason
+	NormalizationIssues map[string]int64 `json:"normalization_issues,omitempty"`
+
+	// Priority is the sampling priority of the trace, "none" if it has none
+	Priority string `json:"priority,omitempty"`
+	// Sampled reports whether the trace was kept
+	Sampled bool `json:"sampled"`
+	// SamplingReason is the sampler which took the decision
+	SamplingReason string  `json:"sampling_reason,omitempty"`
+	SampleRate     float64 `json:"sample_rate,omitempty"`
+	// Events is the number of APM events extracted from the trace
+	Events int `json:"events"`
+
+	// Spans are the spans of the trace after their normalization and obfuscation. The spans of
+	// the traces dropped before their obfuscation have no resource and meta.
+	Spans []*pb.Span `json:"spans"`
+}
+
+// hasService reports whether one of the spans of the trace belongs to one of the services
+func (t *Trace) hasService(services map[string]bool) bool {
+	if services[t.Service] {
+		return true
+	}
+	for _, s := range t.Spans {
+		if services[s.Service] {
+			return true
+		}
+	}
+	return false
+}
+
+type subscriber struct {
+	// services are the services of the traces sent to the subscriber, all of them if empty
+	services map[string]bool
+	traces   chan *Trace
+}
+
+// Tail broadcasts the traces processed by the agent to the HTTP clients tailing them.
+type Tail struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	active      int32 // number of subscribers, read atomically
+
+	exit chan struct{}
+}
+
+// New returns a new Tail without any subscriber.
+func New() *Tail {
+	return &Tail{
+		subscribers: make(map[*subscriber]struct{}),
+		exit:        make(chan struct{}),
+	}
+}
+
+// Active reports whether clients are tailing the traces. The traces should only be
+// built and published when it's the case.
+func (t *Tail) Active() bool {
+	return atomic.LoadInt32(&t.active) > 0
+}
+
+// Publish sends the trace to the subscribers interested in its services. It never blocks:
+// the trace is dropped for the subscribers which don't keep up.
+func (t *Tail) Publish(trace *Trace) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for s := range t.subscribers {
+		if len(s.services) > 0 && !trace.hasService(s.services) {
+			continue
+		}
+		select {
+		case s.traces <- trace:
+		default:
+			log.Debugf("Dropping trace %d for a tail client which isn't keeping up", trace.TraceID)
+		}
+	}
+}
+
+// Close disconnects all the clients.
+func (t *Tail) Close() {
+	close(t.exit)
+}
+
+func (t *Tail) subscribe(services []string) *subscriber {
+	s := &subscriber{
+		services: make(map[string]bool, len(services)),
+		traces:   make(chan *Trace, subscriberBufferLen),
+	}
+	for _, service := range services {
+		s.services[service] = true
+	}
+
+	t.mu.Lock()
+	t.subscribers[s] = struct{}{}
+	atomic.StoreInt32(&t.active, int32(len(t.subscribers)))
+	t.mu.Unlock()
+	return s
+}
+
+func (t *Tail) unsubscribe(s *subscriber) {
+	t.mu.Lock()
+	delete(t.subscribers, s)
+	atomic.StoreInt32(&t.active, int32(len(t.subscribers)))
+	t.mu.Unlock()
+}
+
+// ServeHTTP streams the traces as they are processed, one JSON object per line, until the
+// client disconnects. The traces can be filtered with the comma separated list of services
+// of the "service" query parameter.
+func (t *Tail) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var services []string
+	for _, v := range req.URL.Query()["service"] {
+		for _, service := range strings.Split(v, ",") {
+			if service = strings.TrimSpace(service); service != "" {
+				services = append(services, service)
+			}
+		}
+	}
+
+	// The connection is hijacked, otherwise the stream would be cut by the write timeout
+	// of the receiver.
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Time{})
+
+	rw.WriteString("HTTP/1.1 200 OK\r\nContent-Type: application/x-ndjson\r\nCache-Control: no-cache\r\nConnection: close\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	s := t.subscribe(services)
+	defer t.unsubscribe(s)
+
+	// the client never sends anything once the request is sent, reading stops when it disconnects
+	disconnected := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, rw)
+		close(disconnected)
+	}()
+
+	enc := json.NewEncoder(rw)
+	for {
+		select {
+		case trace := <-s.traces:
+			if err := enc.Encode(trace); err != nil {
+				return
+			}
+			if err := rw.Flush(); err != nil {
+				return
+			}
+		case <-disconnected:
+			return
+		case <-t.exit:
+			return
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tail
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testTrace(id uint64, service string) *Trace {
+	return &Trace{
+		TraceID: id,
+		Service: service,
+		Spans:   []*pb.Span{{TraceID: id, SpanID: 1, Service: service, Name: "http.request"}},
+	}
+}
+
+// waitActive waits until the tail has the given number of subscribers
+func waitActive(t *testing.T, tl *Tail, n int) {
+	for i := 0; i < 100; i++ {
+		tl.mu.RLock()
+		l := len(tl.subscribers)
+		tl.mu.RUnlock()
+		if l == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d subscribers", n)
+}
+
+func TestTail(t *testing.T) {
+	assert := assert.New(t)
+	tl := New()
+	defer tl.Close()
+	srv := httptest.NewServer(tl)
+	defer srv.Close()
+
+	assert.False(tl.Active())
+	tl.Publish(testTrace(1, "web")) // no subscriber, nothing happens
+
+	resp, err := http.Get(srv.URL + "?service=db,cache&service=queue")
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("application/x-ndjson", resp.Header.Get("Content-Type"))
+	waitActive(t, tl, 1)
+	assert.True(tl.Active())
+
+	tl.Publish(testTrace(2, "web"))
+	tl.Publish(testTrace(3, "db"))
+	tl.Publish(testTrace(4, "queue"))
+
+	dec := json.NewDecoder(bufio.NewReader(resp.Body))
+	for _, id := range []uint64{3, 4} {
+		var trace Trace
+		assert.NoError(dec.Decode(&trace))
+		assert.Equal(id, trace.TraceID)
+		assert.Len(trace.Spans, 1)
+	}
+
+	// the subscriber is removed once the client disconnects
+	resp.Body.Close()
+	waitActive(t, tl, 0)
+	assert.False(tl.Active())
+}
+
+func TestTailSlowSubscriber(t *testing.T) {
+	tl := New()
+	s := tl.subscribe(nil)
+	for i := 0; i < subscriberBufferLen*2; i++ {
+		tl.Publish(testTrace(uint64(i), "web")) // never blocks
+	}
+	assert.Len(t, s.traces, subscriberBufferLen)
+}
+
+func TestPrint(t *testing.T) {
+	var in bytes.Buffer
+	enc := json.NewEncoder(&in)
+	now := time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC)
+	enc.Encode(&Trace{
+		Time:           now,
+		TraceID:        42,
+		Service:        "web",
+		Env:            "prod",
+		Priority:       "1",
+		Sampled:        true,
+		SamplingReason: "priority",
+		SampleRate:     1,
+		Events:         1,
+		Spans: []*pb.Span{
+			{SpanID: 3, ParentID: 2, Service: "db", Name: "sql.query", Resource: "SELECT ?", Start: 20, Duration: int64(time.Millisecond), Error: 1},
+			{SpanID: 1, Service: "web", Name: "http.request", Resource: "GET /", Start: 0, Duration: int64(5 * time.Millisecond)},
+			{SpanID: 2, ParentID: 1, Service: "web", Name: "handler", Resource: "GET /", Start: 10, Duration: int64(2 * time.Millisecond)},
+		},
+	})
+	enc.Encode(&Trace{
+		Time:                now,
+		TraceID:             43,
+		Service:             "web",
+		Dropped:             "normalization",
+		NormalizationIssues: map[string]int64{"span_id_zero": 1},
+	})
+
+	var out bytes.Buffer
+	assert.NoError(t, Print(&out, &in))
+	assert.Equal(t, `2020-03-04T10:00:00Z trace:42 service:web env:prod priority:1 KEPT (priority, rate:1) events:1
+  web http.request "GET /" 5ms
+    web handler "GET /" 2ms
+      db sql.query "SELECT ?" 1ms [error]
+
+2020-03-04T10:00:00Z trace:43 service:web DROPPED (normalization)
+  normalization: span_id_zero:1
+
+`, out.String())
+}
@@ -208,6 +208,20 @@ func (w *StatsWriter) buildPayloads(s []stats.Bucket, maxEntriesPerPayloads int)
 					newsb.ErrDistributions[ekey] = b.ErrDistributions[ekey]
 				}
 			}
+			if _, ok := b.OkSketches[ekey]; ok {
+				if _, ok := newsb.OkSketches[ekey]; ok {
+					newsb.OkSketches[ekey].Merge(b.OkSketches[ekey])
+				} else {
+					newsb.OkSketches[ekey] = b.OkSketches[ekey]
+				}
+			}
+			if _, ok := b.ErrSketches[ekey]; ok {
+				if _, ok := newsb.ErrSketches[ekey]; ok {
+					newsb.ErrSketches[ekey].Merge(b.ErrSketches[ekey])
+				} else {
+					newsb.ErrSketches[ekey] = b.ErrSketches[ekey]
+				}
+			}
 			i++
 		}
 	}
@@ -9,11 +9,14 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
 	"github.com/DataDog/datadog-agent/pkg/util/alibaba"
 	"github.com/DataDog/datadog-agent/pkg/util/azure"
+	"github.com/DataDog/datadog-agent/pkg/util/digitalocean"
 	"github.com/DataDog/datadog-agent/pkg/util/ec2"
 	"github.com/DataDog/datadog-agent/pkg/util/ecs"
 	ecscommon "github.com/DataDog/datadog-agent/pkg/util/ecs/common"
 	"github.com/DataDog/datadog-agent/pkg/util/gce"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/openstack"
+	"github.com/DataDog/datadog-agent/pkg/util/oracle"
 	"github.com/DataDog/datadog-agent/pkg/util/tencent"
 )
 
@@ -24,19 +27,27 @@ type cloudProviderDetector struct {
 
 // DetectCloudProvider detects the cloud provider where the agent is running in order:
 // * AWS ECS/Fargate
+// * Oracle Cloud
+// * OpenStack
 // * AWS EC2
 // * GCE
 // * Azure
 // * Alibaba
 // * Tencent
+// * DigitalOcean
+//
+// Oracle Cloud and OpenStack serve an EC2 compatible metadata API, they are detected before EC2.
 func DetectCloudProvider() {
 	detectors := []cloudProviderDetector{
 		{name: ecscommon.CloudProviderName, callback: ecs.IsRunningOn},
+		{name: oracle.CloudProviderName, callback: oracle.IsRunningOn},
+		{name: openstack.CloudProviderName, callback: openstack.IsRunningOn},
 		{name: ec2.CloudProviderName, callback: ec2.IsRunningOn},
 		{name: gce.CloudProviderName, callback: gce.IsRunningOn},
 		{name: azure.CloudProviderName, callback: azure.IsRunningOn},
 		{name: alibaba.CloudProviderName, callback: alibaba.IsRunningOn},
 		{name: tencent.CloudProviderName, callback: tencent.IsRunningOn},
+		{name: digitalocean.CloudProviderName, callback: digitalocean.IsRunningOn},
 	}
 
 	for _, cloudDetector := range detectors {
@@ -55,6 +55,8 @@ type DCAClientInterface interface {
 	PostClusterCheckStatus(nodeName string, status types.NodeStatus) (types.StatusResponse, error)
 	GetClusterCheckConfigs(nodeName string) (types.ConfigResponse, error)
 	GetEndpointsCheckConfigs(nodeName string) (types.ConfigResponse, error)
+
+	PostSeries(series json.Marshaler) (apiv1.SeriesResponse, error)
 }
 
 // DCAClient is required to query the API of Datadog cluster agent
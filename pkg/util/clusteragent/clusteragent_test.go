@@ -6,6 +6,7 @@
 package clusteragent
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -112,6 +113,9 @@ func newDummyClusterAgentWithCFMetadata() (*dummyClusterAgent, error) {
 
 func (d *dummyClusterAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log.Debugf("dummyDCA received %s on %s", r.Method, r.URL.Path)
+	// keep the body readable from the requests popped once they are served
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
 	d.requests <- r
 
 	token := r.Header.Get("Authorization")
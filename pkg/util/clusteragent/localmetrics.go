@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const dcaSeriesPath = "api/v1/series"
+
+// PostSeries submits series to the local external metrics backend of the cluster agent.
+// The series must be marshalled as {"series": [...]}, like metrics.Series.
+func (c *DCAClient) PostSeries(series json.Marshaler) (apiv1.SeriesResponse, error) {
+	// Retry on the main URL if the leader fails
+	willRetry := c.leaderClient.hasLeader()
+
+	result, err := c.doPostSeries(series)
+	if err != nil && willRetry {
+		log.Debugf("Got error on leader, retrying via the service: %s", err)
+		c.leaderClient.resetURL()
+		return c.doPostSeries(series)
+	}
+	return result, err
+}
+
+func (c *DCAClient) doPostSeries(series json.Marshaler) (apiv1.SeriesResponse, error) {
+	var response apiv1.SeriesResponse
+
+	queryBody, err := series.MarshalJSON()
+	if err != nil {
+		return response, err
+	}
+
+	// https://host:port/api/v1/series
+	rawURL := c.leaderClient.buildURL(dcaSeriesPath)
+	req, err := http.NewRequest("POST", rawURL, bytes.NewBuffer(queryBody))
+	if err != nil {
+		return response, err
+	}
+	req.Header = c.clusterAgentAPIRequestHeaders
+
+	resp, err := c.leaderClient.Do(req)
+	if err != nil {
+		return response, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return response, fmt.Errorf("unexpected response: %d - %s", resp.StatusCode, resp.Status)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return response, err
+	}
+	err = json.Unmarshal(b, &response)
+	return response, err
+}
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rawSeries is a series payload marshalled as is
+type rawSeries string
+
+func (s rawSeries) MarshalJSON() ([]byte, error) {
+	return []byte(s), nil
+}
+
+func (suite *clusterAgentSuite) TestPostSeries() {
+	dca, err := newDummyClusterAgent()
+	require.NoError(suite.T(), err)
+
+	dca.rawResponses["/api/v1/series"] = `{"accepted": 1, "dropped": 1}`
+
+	ts, p, err := dca.StartTLS()
+	defer ts.Close()
+	require.NoError(suite.T(), err)
+	mockConfig.Set("cluster_agent.url", fmt.Sprintf("https://127.0.0.1:%d", p))
+
+	ca, err := GetClusterAgentClient()
+	require.NoError(suite.T(), err)
+
+	response, err := ca.PostSeries(rawSeries(`{"series":[{"metric":"foo"},{"metric":"bar"}]}`))
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, response.Accepted)
+	assert.Equal(suite.T(), 1, response.Dropped)
+
+	for r := dca.PopRequest(); r != nil; r = dca.PopRequest() {
+		if r.URL.Path != "/api/v1/series" {
+			continue
+		}
+		assert.Equal(suite.T(), "POST", r.Method)
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(suite.T(), err)
+		var payload struct {
+			Series []struct {
+				Metric string `json:"metric"`
+			} `json:"series"`
+		}
+		require.NoError(suite.T(), json.Unmarshal(body, &payload))
+		require.Len(suite.T(), payload.Series, 2)
+		assert.Equal(suite.T(), "foo", payload.Series[0].Metric)
+		assert.Equal(suite.T(), "bar", payload.Series[1].Metric)
+		return
+	}
+	assert.Fail(suite.T(), "the series weren't posted to the cluster agent")
+}
@@ -38,6 +38,7 @@ type ContainerdItf interface {
 	Containers() ([]containerd.Container, error)
 	GetEvents() containerd.EventService
 	Info(ctn containerd.Container) (containers.Container, error)
+	LoadContainer(id string) (containerd.Container, error)
 	ImageSize(ctn containerd.Container) (int64, error)
 	Spec(ctn containerd.Container) (*oci.Spec, error)
 	Metadata() (containerd.Version, error)
@@ -142,6 +143,14 @@ func (c *ContainerdUtil) Containers() ([]containerd.Container, error) {
 	return c.cl.Containers(ctxNamespace)
 }
 
+// LoadContainer interfaces with the containerd api to get a Container from its ID.
+func (c *ContainerdUtil) LoadContainer(id string) (containerd.Container, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	ctxNamespace := namespaces.WithNamespace(ctx, c.namespace)
+	return c.cl.LoadContainer(ctxNamespace, id)
+}
+
 // ImageSize interfaces with the containerd api to get the size of an image
 func (c *ContainerdUtil) ImageSize(ctn containerd.Container) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package digitalocean
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// declare these as vars not const to ease testing
+var (
+	metadataURL  = "http://169.254.169.254/metadata/v1"
+	timeout      = 300 * time.Millisecond
+	tagsCacheKey = cache.BuildAgentKey("digitalocean", "GetTags")
+
+	// CloudProviderName contains the inventory name of for DigitalOcean
+	CloudProviderName = "DigitalOcean"
+)
+
+// dropletMetadata is the subset of the droplet metadata used by the Agent
+type dropletMetadata struct {
+	DropletID int64    `json:"droplet_id"`
+	Region    string   `json:"region"`
+	Tags      []string `json:"tags"`
+}
+
+// IsRunningOn returns true if the agent is running on DigitalOcean
+func IsRunningOn() bool {
+	if _, err := GetInstanceID(); err == nil {
+		return true
+	}
+	return false
+}
+
+// GetHostAlias returns the droplet ID from the DigitalOcean metadata API
+func GetHostAlias() (string, error) {
+	return GetInstanceID()
+}
+
+// GetInstanceID fetches the droplet ID for current host from the DigitalOcean metadata API
+func GetInstanceID() (string, error) {
+	if !config.IsCloudProviderEnabled(CloudProviderName) {
+		return "", fmt.Errorf("cloud provider is disabled by configuration")
+	}
+	res, err := getMetadataItem(metadataURL + "/id")
+	if err != nil {
+		return "", fmt.Errorf("unable to get DigitalOcean droplet ID: %s", err)
+	}
+	if maxLength := config.Datadog.GetInt("metadata_endpoints_max_hostname_size"); len(res) > maxLength {
+		return "", fmt.Errorf("the DigitalOcean droplet ID has a length > to %v", maxLength)
+	}
+	return res, nil
+}
+
+// HostnameProvider gets the hostname
+func HostnameProvider() (string, error) {
+	log.Debug("GetHostname trying DigitalOcean metadata...")
+	return GetInstanceID()
+}
+
+// GetTags gets the host tags from the DigitalOcean metadata API: the region and the tags of the droplet
+func GetTags() ([]string, error) {
+	if !config.IsCloudProviderEnabled(CloudProviderName) {
+		return nil, fmt.Errorf("cloud provider is disabled by configuration")
+	}
+
+	res, err := getMetadataItem(metadataURL + ".json")
+	if err != nil {
+		return getCachedTags(err)
+	}
+	metadata := dropletMetadata{}
+	if err := json.Unmarshal([]byte(res), &metadata); err != nil {
+		return getCachedTags(err)
+	}
+
+	tags := []string{}
+	if metadata.DropletID != 0 {
+		tags = append(tags, fmt.Sprintf("instance-id:%d", metadata.DropletID))
+	}
+	if metadata.Region != "" {
+		tags = append(tags, fmt.Sprintf("region:%s", metadata.Region))
+	}
+	// Droplet tags are already formatted as "key" or "key:value"
+	tags = append(tags, metadata.Tags...)
+
+	// save tags to the cache in case we exceed quotas later
+	cache.Cache.Set(tagsCacheKey, tags, cache.NoExpiration)
+
+	return tags, nil
+}
+
+func getCachedTags(err error) ([]string, error) {
+	if tags, found := cache.Cache.Get(tagsCacheKey); found {
+		log.Infof("unable to get tags from DigitalOcean, returning cached tags: %s", err)
+		return tags.([]string), nil
+	}
+	return nil, log.Warnf("unable to get tags from DigitalOcean and cache is empty: %s", err)
+}
+
+func getMetadataItem(endpoint string) (string, error) {
+	client := http.Client{
+		Timeout: timeout,
+	}
+	res, err := client.Get(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch DigitalOcean Metadata API, %s", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("status code %d trying to fetch %s", res.StatusCode, endpoint)
+	}
+
+	all, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read response body, %s", err)
+	}
+	return string(all), nil
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package digitalocean
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func newMetadataServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metadata/v1/id":
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, "2756294")
+		case "/metadata/v1.json":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{
+				"droplet_id": 2756294,
+				"hostname": "sample-droplet",
+				"region": "nyc3",
+				"tags": ["web", "team:containers"]
+			}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGetInstanceID(t *testing.T) {
+	holdValue := config.Datadog.Get("cloud_provider_metadata")
+	defer config.Datadog.Set("cloud_provider_metadata", holdValue)
+	config.Datadog.Set("cloud_provider_metadata", []string{"digitalocean"})
+
+	ts := newMetadataServer(t)
+	defer ts.Close()
+	metadataURL = ts.URL + "/metadata/v1"
+
+	val, err := GetInstanceID()
+	assert.Nil(t, err)
+	assert.Equal(t, "2756294", val)
+	assert.True(t, IsRunningOn())
+
+	config.Datadog.Set("cloud_provider_metadata", []string{"aws"})
+	_, err = GetInstanceID()
+	assert.Error(t, err)
+	assert.False(t, IsRunningOn())
+}
+
+func TestGetTags(t *testing.T) {
+	holdValue := config.Datadog.Get("cloud_provider_metadata")
+	defer config.Datadog.Set("cloud_provider_metadata", holdValue)
+	config.Datadog.Set("cloud_provider_metadata", []string{"digitalocean"})
+
+	ts := newMetadataServer(t)
+	metadataURL = ts.URL + "/metadata/v1"
+
+	expected := []string{
+		"instance-id:2756294",
+		"region:nyc3",
+		"web",
+		"team:containers",
+	}
+	tags, err := GetTags()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, tags)
+
+	// The tags are cached in case the metadata service becomes unavailable
+	ts.Close()
+	tags, err = GetTags()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, tags)
+}
@@ -125,6 +125,7 @@ func saveHostnameData(cacheHostnameKey string, hostname string, provider string)
 // GetHostnameData retrieves the host name for the Agent and hostname provider, trying to query these
 // environments/api, in order:
 // * GCE
+// * Oracle Cloud, OpenStack and DigitalOcean
 // * Docker
 // * kubernetes
 // * os
@@ -177,6 +178,26 @@ func GetHostnameData() (HostnameData, error) {
 		log.Debug("Unable to get hostname from GCE: ", err)
 	}
 
+	// Oracle Cloud, OpenStack and DigitalOcean metadata, only queried when enabled in cloud_provider_metadata
+	for _, name := range []string{"oracle", "openstack", "digitalocean"} {
+		getCloudHostname, found := hostname.ProviderCatalog[name]
+		if !found {
+			continue
+		}
+		cloudName, err := getCloudHostname()
+		if err == nil {
+			err = validate.ValidHostname(cloudName)
+		}
+		if err == nil {
+			hostnameData := saveHostnameData(cacheHostnameKey, cloudName, name)
+			return hostnameData, err
+		}
+		expErr := new(expvar.String)
+		expErr.Set(err.Error())
+		hostnameErrors.Set(name, expErr)
+		log.Debugf("Unable to get hostname from %s: %s", name, err)
+	}
+
 	// FQDN
 	var fqdn string
 	canUseOSHostname := isOSHostnameUsable()
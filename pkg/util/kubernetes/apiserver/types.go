@@ -38,4 +38,18 @@ const (
 	ReplicaSetsInformer InformerName = "replicaSets"
 	// ServicesInformer holds the name of the informer
 	ServicesInformer InformerName = "services"
+	// NodesInformer holds the name of the informer
+	NodesInformer InformerName = "nodes"
+	// DaemonSetsInformer holds the name of the informer
+	DaemonSetsInformer InformerName = "daemonSets"
+	// StatefulSetsInformer holds the name of the informer
+	StatefulSetsInformer InformerName = "statefulSets"
+	// JobsInformer holds the name of the informer
+	JobsInformer InformerName = "jobs"
+	// CronJobsInformer holds the name of the informer
+	CronJobsInformer InformerName = "cronJobs"
+	// PersistentVolumesInformer holds the name of the informer
+	PersistentVolumesInformer InformerName = "persistentVolumes"
+	// PersistentVolumeClaimsInformer holds the name of the informer
+	PersistentVolumeClaimsInformer InformerName = "persistentVolumeClaims"
 )
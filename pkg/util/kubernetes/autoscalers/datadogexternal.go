@@ -17,6 +17,7 @@ import (
 	"gopkg.in/zorkian/go-datadog-api.v2"
 	utilserror "k8s.io/apimachinery/pkg/util/errors"
 
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/localmetrics"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
@@ -168,7 +169,11 @@ func setTelemetryMetric(val string, metric telemetry.Gauge) error {
 
 func (p *Processor) updateRateLimitingMetrics() error {
 	updateMap := p.datadogClient.GetRateLimitStats()
-	queryLimits := updateMap[queryEndpoint]
+	queryLimits, found := updateMap[queryEndpoint]
+	if !found {
+		// No query sent yet, or the backend isn't rate limited
+		return nil
+	}
 
 	errors := []error{
 		setTelemetryMetric(queryLimits.Limit, rateLimitsLimit),
@@ -180,6 +185,16 @@ func (p *Processor) updateRateLimitingMetrics() error {
 	return utilserror.NewAggregate(errors)
 }
 
+// NewQueryClient returns the client used to query the external metrics: the local
+// metrics store of the cluster agent if the local backend is enabled, Datadog otherwise
+func NewQueryClient() (DatadogClient, error) {
+	if config.Datadog.GetBool("external_metrics_provider.local_backend.enabled") {
+		log.Infof("Initialized the local metrics client for HPA")
+		return localmetrics.NewClient(localmetrics.GetStore()), nil
+	}
+	return NewDatadogClient()
+}
+
 // NewDatadogClient generates a new client to query metrics from Datadog
 func NewDatadogClient() (*datadog.Client, error) {
 	apiKey := config.Datadog.GetString("api_key")
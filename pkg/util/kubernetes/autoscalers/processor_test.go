@@ -610,6 +610,12 @@ func TestUpdateRateLimiting(t *testing.T) {
 			// Although several headers are missing, the Aggregate will only return 1 error as they are the same
 			error: fmt.Errorf("strconv.Atoi: parsing \"\": invalid syntax"),
 		},
+		{
+			desc:       "No rate limits case",
+			rateLimits: map[string]datadog.RateLimit{},
+			results:    Results{},
+			error:      nil,
+		},
 	}
 
 	rateLimitsRemaining = &mockGauge{values: make(map[string]float64)}
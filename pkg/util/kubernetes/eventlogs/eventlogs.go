@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package eventlogs carries the Kubernetes events collected by the
+// kubernetes_apiserver check to the logs-agent, which sends them as logs.
+package eventlogs
+
+import (
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// channelSize is the number of events buffered before new events are dropped
+const channelSize = 1000
+
+// ObjectReference is the object an event is about
+type ObjectReference struct {
+	Kind            string `json:"kind,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name,omitempty"`
+	UID             string `json:"uid,omitempty"`
+	APIVersion      string `json:"api_version,omitempty"`
+	ResourceVersion string `json:"resource_version,omitempty"`
+	FieldPath       string `json:"field_path,omitempty"`
+}
+
+// EventSource is the component that reported an event
+type EventSource struct {
+	Component string `json:"component,omitempty"`
+	Host      string `json:"host,omitempty"`
+}
+
+// EventLog is the structured log of a Kubernetes event
+type EventLog struct {
+	Message         string          `json:"message"`
+	Reason          string          `json:"reason,omitempty"`
+	Type            string          `json:"type,omitempty"`
+	Count           int32           `json:"count"`
+	InvolvedObject  ObjectReference `json:"involved_object"`
+	Source          EventSource     `json:"source"`
+	FirstTimestamp  int64           `json:"first_timestamp,omitempty"`
+	LastTimestamp   int64           `json:"last_timestamp,omitempty"`
+	Name            string          `json:"name"`
+	Namespace       string          `json:"namespace,omitempty"`
+	UID             string          `json:"uid,omitempty"`
+	ResourceVersion string          `json:"resource_version,omitempty"`
+
+	// Tags are attached to the log, they are not part of its content
+	Tags []string `json:"-"`
+}
+
+// Channel is the channel of the events to send as logs
+type Channel = chan *EventLog
+
+var (
+	logsChannel = make(Channel, channelSize)
+	// running is 1 while the logs-agent forwards the events of logsChannel
+	running int32
+)
+
+// IsEnabled returns whether the Kubernetes events should be sent as logs
+func IsEnabled() bool {
+	return config.Datadog.GetBool("collect_kubernetes_events_as_logs")
+}
+
+// IsRunning returns whether the logs-agent is forwarding the events queued with Send
+func IsRunning() bool {
+	return atomic.LoadInt32(&running) == 1
+}
+
+// SetRunning records whether the logs-agent is forwarding the events queued with Send
+func SetRunning(isRunning bool) {
+	var value int32
+	if isRunning {
+		value = 1
+	}
+	atomic.StoreInt32(&running, value)
+}
+
+// GetChannel returns the channel of the events to send as logs
+func GetChannel() Channel {
+	return logsChannel
+}
+
+// Send queues an event to be sent as a log. It doesn't block:
+// it returns false if the event was dropped because the queue is full.
+func Send(event *EventLog) bool {
+	select {
+	case logsChannel <- event:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package openstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// declare these as vars not const to ease testing
+var (
+	metadataURL  = "http://169.254.169.254/openstack/latest"
+	timeout      = 300 * time.Millisecond
+	tagsCacheKey = cache.BuildAgentKey("openstack", "GetTags")
+
+	// CloudProviderName contains the inventory name of for OpenStack
+	CloudProviderName = "OpenStack"
+)
+
+// instanceMetadata is the subset of meta_data.json used by the Agent
+type instanceMetadata struct {
+	UUID             string            `json:"uuid"`
+	AvailabilityZone string            `json:"availability_zone"`
+	ProjectID        string            `json:"project_id"`
+	Meta             map[string]string `json:"meta"`
+}
+
+// IsRunningOn returns true if the agent is running on OpenStack
+func IsRunningOn() bool {
+	if _, err := GetInstanceID(); err == nil {
+		return true
+	}
+	return false
+}
+
+// GetHostAlias returns the instance UUID from the OpenStack metadata service
+func GetHostAlias() (string, error) {
+	return GetInstanceID()
+}
+
+// GetInstanceID fetches the instance UUID for current host from the OpenStack metadata service
+func GetInstanceID() (string, error) {
+	if !config.IsCloudProviderEnabled(CloudProviderName) {
+		return "", fmt.Errorf("cloud provider is disabled by configuration")
+	}
+	metadata, err := getInstanceMetadata()
+	if err != nil {
+		return "", fmt.Errorf("unable to get OpenStack instance ID: %s", err)
+	}
+	if metadata.UUID == "" {
+		return "", fmt.Errorf("unable to get OpenStack instance ID: no uuid in the metadata")
+	}
+	if maxLength := config.Datadog.GetInt("metadata_endpoints_max_hostname_size"); len(metadata.UUID) > maxLength {
+		return "", fmt.Errorf("the OpenStack instance ID has a length > to %v", maxLength)
+	}
+	return metadata.UUID, nil
+}
+
+// HostnameProvider gets the hostname
+func HostnameProvider() (string, error) {
+	log.Debug("GetHostname trying OpenStack metadata...")
+	return GetInstanceID()
+}
+
+// GetTags gets the host tags from the OpenStack metadata service: the availability
+// zone, the project and the metadata key-value pairs of the instance
+func GetTags() ([]string, error) {
+	if !config.IsCloudProviderEnabled(CloudProviderName) {
+		return nil, fmt.Errorf("cloud provider is disabled by configuration")
+	}
+
+	metadata, err := getInstanceMetadata()
+	if err != nil {
+		return getCachedTags(err)
+	}
+
+	tags := []string{}
+	if metadata.UUID != "" {
+		tags = append(tags, fmt.Sprintf("instance-id:%s", metadata.UUID))
+	}
+	if metadata.AvailabilityZone != "" {
+		tags = append(tags, fmt.Sprintf("availability-zone:%s", metadata.AvailabilityZone))
+	}
+	if metadata.ProjectID != "" {
+		tags = append(tags, fmt.Sprintf("project-id:%s", metadata.ProjectID))
+	}
+	for k, v := range metadata.Meta {
+		tags = append(tags, fmt.Sprintf("%s:%s", k, v))
+	}
+
+	// save tags to the cache in case we exceed quotas later
+	cache.Cache.Set(tagsCacheKey, tags, cache.NoExpiration)
+
+	return tags, nil
+}
+
+func getCachedTags(err error) ([]string, error) {
+	if tags, found := cache.Cache.Get(tagsCacheKey); found {
+		log.Infof("unable to get tags from OpenStack, returning cached tags: %s", err)
+		return tags.([]string), nil
+	}
+	return nil, log.Warnf("unable to get tags from OpenStack and cache is empty: %s", err)
+}
+
+func getInstanceMetadata() (*instanceMetadata, error) {
+	res, err := getMetadataItem(metadataURL + "/meta_data.json")
+	if err != nil {
+		return nil, err
+	}
+	metadata := &instanceMetadata{}
+	if err := json.Unmarshal([]byte(res), metadata); err != nil {
+		return nil, fmt.Errorf("unable to parse the OpenStack metadata, %s", err)
+	}
+	return metadata, nil
+}
+
+func getMetadataItem(endpoint string) (string, error) {
+	client := http.Client{
+		Timeout: timeout,
+	}
+	res, err := client.Get(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch OpenStack Metadata API, %s", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("status code %d trying to fetch %s", res.StatusCode, endpoint)
+	}
+
+	all, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read response body, %s", err)
+	}
+	return string(all), nil
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package openstack
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func newMetadataServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/meta_data.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{
+			"uuid": "d8e02d56-2648-49a3-bf97-6be8f1204f38",
+			"name": "test",
+			"hostname": "test.novalocal",
+			"availability_zone": "nova",
+			"project_id": "f7ac731cc11f40efbc03a9f9e1d1d21f",
+			"launch_index": 0,
+			"meta": {"role": "webservers", "env": "staging"}
+		}`)
+	}))
+}
+
+func TestGetInstanceID(t *testing.T) {
+	holdValue := config.Datadog.Get("cloud_provider_metadata")
+	defer config.Datadog.Set("cloud_provider_metadata", holdValue)
+	config.Datadog.Set("cloud_provider_metadata", []string{"openstack"})
+
+	ts := newMetadataServer(t)
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	val, err := GetInstanceID()
+	assert.Nil(t, err)
+	assert.Equal(t, "d8e02d56-2648-49a3-bf97-6be8f1204f38", val)
+	assert.True(t, IsRunningOn())
+
+	config.Datadog.Set("cloud_provider_metadata", []string{"aws"})
+	_, err = GetInstanceID()
+	assert.Error(t, err)
+	assert.False(t, IsRunningOn())
+}
+
+func TestGetTags(t *testing.T) {
+	holdValue := config.Datadog.Get("cloud_provider_metadata")
+	defer config.Datadog.Set("cloud_provider_metadata", holdValue)
+	config.Datadog.Set("cloud_provider_metadata", []string{"openstack"})
+
+	ts := newMetadataServer(t)
+	metadataURL = ts.URL
+
+	expected := []string{
+		"instance-id:d8e02d56-2648-49a3-bf97-6be8f1204f38",
+		"availability-zone:nova",
+		"project-id:f7ac731cc11f40efbc03a9f9e1d1d21f",
+		"role:webservers",
+		"env:staging",
+	}
+	tags, err := GetTags()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, tags)
+
+	// The tags are cached in case the metadata service becomes unavailable
+	ts.Close()
+	tags, err = GetTags()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, tags)
+}
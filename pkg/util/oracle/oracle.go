@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package oracle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// declare these as vars not const to ease testing
+var (
+	metadataURL  = "http://169.254.169.254/opc/v2"
+	timeout      = 300 * time.Millisecond
+	tagsCacheKey = cache.BuildAgentKey("oracle", "GetTags")
+
+	// CloudProviderName contains the inventory name of for Oracle Cloud
+	CloudProviderName = "Oracle"
+)
+
+// instanceMetadata is the subset of the instance metadata used by the Agent
+type instanceMetadata struct {
+	ID                  string                       `json:"id"`
+	CanonicalRegionName string                       `json:"canonicalRegionName"`
+	AvailabilityDomain  string                       `json:"availabilityDomain"`
+	FaultDomain         string                       `json:"faultDomain"`
+	Shape               string                       `json:"shape"`
+	FreeformTags        map[string]string            `json:"freeformTags"`
+	DefinedTags         map[string]map[string]string `json:"definedTags"`
+}
+
+// IsRunningOn returns true if the agent is running on Oracle Cloud
+func IsRunningOn() bool {
+	if _, err := GetInstanceID(); err == nil {
+		return true
+	}
+	return false
+}
+
+// GetHostAlias returns the instance OCID from the Oracle Cloud metadata API
+func GetHostAlias() (string, error) {
+	return GetInstanceID()
+}
+
+// GetInstanceID fetches the instance OCID for current host from the Oracle Cloud metadata API
+func GetInstanceID() (string, error) {
+	if !config.IsCloudProviderEnabled(CloudProviderName) {
+		return "", fmt.Errorf("cloud provider is disabled by configuration")
+	}
+	res, err := getMetadataItem(metadataURL + "/instance/id")
+	if err != nil {
+		return "", fmt.Errorf("unable to get Oracle Cloud instance ID: %s", err)
+	}
+	if maxLength := config.Datadog.GetInt("metadata_endpoints_max_hostname_size"); len(res) > maxLength {
+		return "", fmt.Errorf("the Oracle Cloud instance ID has a length > to %v", maxLength)
+	}
+	return res, nil
+}
+
+// HostnameProvider gets the hostname
+func HostnameProvider() (string, error) {
+	log.Debug("GetHostname trying Oracle Cloud metadata...")
+	return GetInstanceID()
+}
+
+// GetTags gets the host tags from the Oracle Cloud metadata API: the region, availability
+// and fault domains, the shape, and the freeform and defined tags of the instance
+func GetTags() ([]string, error) {
+	if !config.IsCloudProviderEnabled(CloudProviderName) {
+		return nil, fmt.Errorf("cloud provider is disabled by configuration")
+	}
+
+	res, err := getMetadataItem(metadataURL + "/instance/")
+	if err != nil {
+		return getCachedTags(err)
+	}
+	metadata := instanceMetadata{}
+	if err := json.Unmarshal([]byte(res), &metadata); err != nil {
+		return getCachedTags(err)
+	}
+
+	tags := []string{}
+	for k, v := range map[string]string{
+		"instance-id":         metadata.ID,
+		"region":              metadata.CanonicalRegionName,
+		"availability-domain": metadata.AvailabilityDomain,
+		"fault-domain":        metadata.FaultDomain,
+		"instance-type":       metadata.Shape,
+	} {
+		if v != "" {
+			tags = append(tags, fmt.Sprintf("%s:%s", k, v))
+		}
+	}
+	for k, v := range metadata.FreeformTags {
+		tags = append(tags, fmt.Sprintf("%s:%s", k, v))
+	}
+	for namespace, definedTags := range metadata.DefinedTags {
+		for k, v := range definedTags {
+			tags = append(tags, fmt.Sprintf("%s.%s:%s", namespace, k, v))
+		}
+	}
+
+	// save tags to the cache in case we exceed quotas later
+	cache.Cache.Set(tagsCacheKey, tags, cache.NoExpiration)
+
+	return tags, nil
+}
+
+func getCachedTags(err error) ([]string, error) {
+	if tags, found := cache.Cache.Get(tagsCacheKey); found {
+		log.Infof("unable to get tags from Oracle Cloud, returning cached tags: %s", err)
+		return tags.([]string), nil
+	}
+	return nil, log.Warnf("unable to get tags from Oracle Cloud and cache is empty: %s", err)
+}
+
+func getMetadataItem(endpoint string) (string, error) {
+	client := http.Client{
+		Timeout: timeout,
+	}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	// The v2 endpoints of the metadata service require this header
+	req.Header.Set("Authorization", "Bearer Oracle")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch Oracle Cloud Metadata API, %s", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("status code %d trying to fetch %s", res.StatusCode, endpoint)
+	}
+
+	all, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read response body, %s", err)
+	}
+	return string(all), nil
+}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package oracle
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const instanceID = "ocid1.instance.oc1.phx.anyhqljrkf6q7yacvmfpb4gvq5fpgwxqhc2bhgxkzvqbc4uzw7lq"
+
+func newMetadataServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer Oracle" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/instance/id":
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, instanceID)
+		case "/instance/":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{
+				"availabilityDomain": "Uocm:PHX-AD-1",
+				"faultDomain": "FAULT-DOMAIN-2",
+				"id": "`+instanceID+`",
+				"canonicalRegionName": "us-phoenix-1",
+				"region": "phx",
+				"shape": "VM.Standard2.1",
+				"freeformTags": {"team": "containers"},
+				"definedTags": {"Operations": {"CostCenter": "42"}}
+			}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGetInstanceID(t *testing.T) {
+	holdValue := config.Datadog.Get("cloud_provider_metadata")
+	defer config.Datadog.Set("cloud_provider_metadata", holdValue)
+	config.Datadog.Set("cloud_provider_metadata", []string{"oracle"})
+
+	ts := newMetadataServer(t)
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	val, err := GetInstanceID()
+	assert.Nil(t, err)
+	assert.Equal(t, instanceID, val)
+	assert.True(t, IsRunningOn())
+
+	config.Datadog.Set("cloud_provider_metadata", []string{"aws"})
+	_, err = GetInstanceID()
+	assert.Error(t, err)
+	assert.False(t, IsRunningOn())
+}
+
+func TestGetTags(t *testing.T) {
+	holdValue := config.Datadog.Get("cloud_provider_metadata")
+	defer config.Datadog.Set("cloud_provider_metadata", holdValue)
+	config.Datadog.Set("cloud_provider_metadata", []string{"oracle"})
+
+	ts := newMetadataServer(t)
+	metadataURL = ts.URL
+
+	expected := []string{
+		"instance-id:" + instanceID,
+		"region:us-phoenix-1",
+		"availability-domain:Uocm:PHX-AD-1",
+		"fault-domain:FAULT-DOMAIN-2",
+		"instance-type:VM.Standard2.1",
+		"team:containers",
+		"Operations.CostCenter:42",
+	}
+	tags, err := GetTags()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, tags)
+
+	// The tags are cached in case the metadata service becomes unavailable
+	ts.Close()
+	tags, err = GetTags()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, tags)
+}